@@ -0,0 +1,209 @@
+package astrobwt
+
+import "fmt"
+import "time"
+import "testing"
+import "math/rand"
+import "encoding/hex"
+import "encoding/binary"
+import "golang.org/x/crypto/sha3"
+import "golang.org/x/crypto/salsa20/salsa"
+
+// every pow variant behind a common signature, variants which do not honour max_limit always succeed
+var pow_variants = []struct {
+	name    string
+	limited bool
+	pow     func(input []byte, max_limit int, data *Data) ([32]byte, bool)
+}{
+	{"POW", false, func(input []byte, max_limit int, data *Data) ([32]byte, bool) { return POW(input), true }},
+	{"POW_0alloc", false, func(input []byte, max_limit int, data *Data) ([32]byte, bool) { return POW_0alloc(input), true }},
+	{"POW_optimized_v1", true, func(input []byte, max_limit int, data *Data) ([32]byte, bool) {
+		return POW_optimized_v1(input, max_limit)
+	}},
+	{"POW_optimized_v2", true, func(input []byte, max_limit int, data *Data) ([32]byte, bool) {
+		return POW_optimized_v2(input, max_limit, data)
+	}},
+}
+
+// every bwt variant, output must be len(input)+1 bytes and zeroed
+var bwt_variants = []struct {
+	name string
+	bwt  func(input []byte, output []byte, data *Data)
+}{
+	{"sais_32", func(input []byte, output []byte, data *Data) {
+		BWT_0alloc(input, make([]int32, len(input)+1), output)
+	}},
+	{"sais_64", bwt_64},
+	{"sort_indices", func(input []byte, output []byte, data *Data) {
+		// buffers are at least full sized as in POW_optimized_v2, output is written 4 entries at a time
+		// and the entries past N may be left over from earlier larger inputs
+		input_extra := make([]byte, len(data.stage2)+len(input))
+		copy(input_extra[1:], input)
+		output_extra := make([]byte, len(data.stage2_result)+len(input))
+		sort_indices(len(input)+1, input_extra, output_extra, data)
+		copy(output, output_extra)
+	}},
+}
+
+// same as BWT_0alloc but uses the int64 suffix array construction
+func bwt_64(input []byte, output []byte, data *Data) {
+	sa := make([]int64, len(input)+1)
+	text_64(input, sa[1:])
+	output[0] = input[len(input)-1]
+	for i := 1; i < len(sa); i++ {
+		if sa[i] != 0 {
+			output[i] = input[sa[i]-1]
+		}
+	}
+}
+
+// recomputes stage2 length independently of the variants under test, used to predict the success flag
+func stage2_length_of(input []byte) int {
+	var counter [16]byte
+	var stage1 [stage1_length]byte
+
+	key := sha3.Sum256(input)
+	salsa.XORKeyStream(stage1[:], stage1[:], &counter, &key)
+	stage1_result, _ := BWT(stage1[:])
+	key = sha3.Sum256(stage1_result)
+	return stage1_length + int(binary.LittleEndian.Uint32(key[:])&0xfffff)
+}
+
+// runs input through all pow variants and reports any difference in hash or success flag
+func check_pow_variants(t testing.TB, input []byte, max_limit int, data *Data) {
+	expected_success := stage2_length_of(input) <= max_limit
+	reference := POW(input)
+
+	for _, v := range pow_variants {
+		hash, success := v.pow(input, max_limit, data)
+		if !v.limited {
+			if hash != reference {
+				t.Fatalf("%s hash %x differs from POW %x, input %x", v.name, hash, reference, input)
+			}
+			continue
+		}
+		if success != expected_success {
+			t.Fatalf("%s success %v expected %v, input %x max_limit %d", v.name, success, expected_success, input, max_limit)
+		}
+		if success && hash != reference {
+			t.Fatalf("%s hash %x differs from POW %x, input %x max_limit %d", v.name, hash, reference, input, max_limit)
+		}
+		if !success {
+			for i := range hash {
+				if hash[i] != 0xff {
+					t.Fatalf("%s failed hash %x must be all 0xff, input %x max_limit %d", v.name, hash, input, max_limit)
+				}
+			}
+		}
+	}
+}
+
+// feeds random inputs and random limits up to MAX_LENGTH to every pow variant
+func TestPOW_Differential(t *testing.T) {
+	seed := time.Now().UnixNano()
+	t.Logf("seed %d", seed)
+	r := rand.New(rand.NewSource(seed))
+
+	iterations := 16
+	if testing.Short() {
+		iterations = 4
+	}
+
+	data := &Data{}
+	for i := 0; i < iterations; i++ {
+		input := make([]byte, r.Intn(256))
+		r.Read(input)
+		max_limit := stage1_length + r.Intn(MAX_LENGTH-stage1_length+1)
+		check_pow_variants(t, input, max_limit, data)
+	}
+
+	// both edges of the limit
+	input, _ := hex.DecodeString("41419e40c3d2a65a8e1a6bb9b6ea2b6cbd5b4efd2d1c8b06ce1a7b1b2b3f7ea0")
+	length := stage2_length_of(input)
+	check_pow_variants(t, input, length, data)
+	check_pow_variants(t, input, length-1, data)
+}
+
+// feeds random buffers of random sizes up to MAX_LENGTH to every bwt variant
+// sort_indices only compares a limited prefix of every suffix, so the buffers must be random
+// which is always the case for salsa output
+func TestBWT_Differential(t *testing.T) {
+	seed := time.Now().UnixNano()
+	t.Logf("seed %d", seed)
+	r := rand.New(rand.NewSource(seed))
+
+	sizes := []int{1, 2, 7, 8, 9, 63, 64, 65, stage1_length, MAX_LENGTH - 1}
+	iterations := 8
+	if testing.Short() {
+		iterations = 2
+	}
+	for i := 0; i < iterations; i++ {
+		sizes = append(sizes, 1+r.Intn(MAX_LENGTH-1))
+	}
+
+	data := &Data{}
+	for _, size := range sizes {
+		input := make([]byte, size)
+		r.Read(input)
+
+		reference, _ := BWT(input)
+		for _, v := range bwt_variants {
+			output := make([]byte, size+1)
+			v.bwt(input, output, data)
+			if string(output) != string(reference) {
+				t.Fatalf("%s bwt differs from BWT for size %d seed %d", v.name, size, seed)
+			}
+		}
+	}
+}
+
+// reports hashes per second and allocations for every pow variant and limit
+// go test -run XXX -bench POW_Matrix ./astrobwt/
+func BenchmarkPOW_Matrix(b *testing.B) {
+	limits := []int{MAX_LENGTH, stage1_length + 512*1024, stage1_length + 256*1024}
+	for _, v := range pow_variants {
+		for _, max_limit := range limits {
+			if !v.limited && max_limit != MAX_LENGTH {
+				continue
+			}
+			v, max_limit := v, max_limit
+			b.Run(fmt.Sprintf("%s/limit=%d", v.name, max_limit), func(b *testing.B) {
+				var input [76]byte
+				data := &Data{}
+				hashes := 0
+				b.ReportAllocs()
+				b.ResetTimer()
+				start := time.Now()
+				for i := 0; i < b.N; i++ {
+					binary.LittleEndian.PutUint32(input[39:], uint32(i))
+					if _, success := v.pow(input[:], max_limit, data); success {
+						hashes++
+					}
+				}
+				b.ReportMetric(float64(hashes)/time.Since(start).Seconds(), "hashes/s")
+			})
+		}
+	}
+}
+
+// reports throughput and allocations for every bwt variant and size
+func BenchmarkBWT_Matrix(b *testing.B) {
+	sizes := []int{64 * 1024, stage1_length, 1024 * 1024, MAX_LENGTH - 1}
+	for _, v := range bwt_variants {
+		for _, size := range sizes {
+			v, size := v, size
+			b.Run(fmt.Sprintf("%s/size=%d", v.name, size), func(b *testing.B) {
+				input := make([]byte, size)
+				rand.Read(input)
+				output := make([]byte, size+1)
+				data := &Data{}
+				b.SetBytes(int64(size))
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					v.bwt(input, output, data)
+				}
+			})
+		}
+	}
+}
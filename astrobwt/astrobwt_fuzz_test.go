@@ -0,0 +1,20 @@
+//go:build go1.18
+// +build go1.18
+
+package astrobwt
+
+import "testing"
+
+// go test -run XXX -fuzz FuzzPOW ./astrobwt/
+// limit is folded into the valid range [stage1_length, MAX_LENGTH]
+func FuzzPOW(f *testing.F) {
+	f.Add([]byte{0, 0, 0, 0}, uint32(MAX_LENGTH))
+	f.Add([]byte("DERO"), uint32(0))
+	f.Add(make([]byte, 76), uint32(512*1024))
+
+	data := &Data{}
+	f.Fuzz(func(t *testing.T, input []byte, limit uint32) {
+		max_limit := stage1_length + int(limit%uint32(MAX_LENGTH-stage1_length+1))
+		check_pow_variants(t, input, max_limit, data)
+	})
+}
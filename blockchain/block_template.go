@@ -0,0 +1,176 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+// this file selects txs for a new block, so as miner revenue ( fees + block reward ) is maximized
+// selection is a knapsack problem, txs have size and fee, block has limited size
+// additionally a reward curve may lower the block reward as block grows, ( see emission.GetBlockReward )
+// in that case a tx is only worth including if its fee is more than the reward lost by growing the block
+// txs spending same key images conflict, only the one paying more fee per byte is considered
+// two solutions are computed and the better one is used
+// 1) greedy, txs in fee per byte order, every tx which fits and pays more than the reward it costs is taken
+// 2) dynamic programming over block size in TEMPLATE_SIZE_UNIT steps, which finds best fees for every size,
+//    the size with best fees + reward is chosen, left over space is then filled greedily
+
+import "sort"
+import "math/bits"
+
+import "github.com/deroproject/derosuite/crypto"
+
+// granularity of dynamic programming, tx sizes are rounded up to this
+const TEMPLATE_SIZE_UNIT = 1024
+
+// dynamic programming is skipped if it requires more steps, greedy solution is used then
+const TEMPLATE_MAX_STEPS = 16 * 1024 * 1024
+
+// a tx which can be included in block
+type template_tx struct {
+	Hash       crypto.Hash
+	Size       uint64
+	Fee        uint64
+	Key_Images []crypto.Hash
+}
+
+// reward as block grows, nil means reward does not depend on size
+type reward_curve func(size uint64) uint64
+
+// select txs maximizing fees + reward, total size of selected txs will not exceed max_size
+// returns indexes of selected txs in fee per byte order
+func select_template_txs(candidates []template_tx, max_size uint64, reward reward_curve) (selected []int, fees uint64, size uint64) {
+	if reward == nil {
+		reward = func(uint64) uint64 { return 0 }
+	}
+
+	order := resolve_template_conflicts(candidates)
+
+	greedy, greedy_fees, greedy_size := fill_template(candidates, order, nil, 0, 0, max_size, reward)
+	selected, fees, size = greedy, greedy_fees, greedy_size
+
+	units := max_size / TEMPLATE_SIZE_UNIT
+	if len(order) > 0 && uint64(len(order))*(units+1) <= TEMPLATE_MAX_STEPS {
+		dp, dp_fees, dp_size := knapsack_template(candidates, order, units, reward)
+		dp, dp_fees, dp_size = fill_template(candidates, order, dp, dp_fees, dp_size, max_size, reward)
+		if dp_fees+reward(dp_size) > fees+reward(size) {
+			selected, fees, size = dp, dp_fees, dp_size
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool { return better_feerate(candidates[selected[i]], candidates[selected[j]]) })
+	return
+}
+
+// whether a pays more fee per byte than b, fee_a/size_a > fee_b/size_b, without losing precision
+func better_feerate(a, b template_tx) bool {
+	l_hi, l_lo := bits.Mul64(a.Fee, b.Size)
+	r_hi, r_lo := bits.Mul64(b.Fee, a.Size)
+	if l_hi != r_hi {
+		return l_hi > r_hi
+	}
+	if l_lo != r_lo {
+		return l_lo > r_lo
+	}
+	return a.Size < b.Size
+}
+
+// sort candidates in fee per byte order and drop txs conflicting with better paying txs
+func resolve_template_conflicts(candidates []template_tx) (order []int) {
+	for i := range candidates {
+		order = append(order, i)
+	}
+	sort.SliceStable(order, func(i, j int) bool { return better_feerate(candidates[order[i]], candidates[order[j]]) })
+
+	used := map[crypto.Hash]bool{}
+	result := order[:0]
+	for _, i := range order {
+		conflict := false
+		for _, ki := range candidates[i].Key_Images {
+			if used[ki] {
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			continue
+		}
+		for _, ki := range candidates[i].Key_Images {
+			used[ki] = true
+		}
+		result = append(result, i)
+	}
+	return result
+}
+
+// add txs in fee per byte order to an existing selection, as long as they fit and pay more than the reward they cost
+func fill_template(candidates []template_tx, order []int, selected []int, fees, size, max_size uint64, reward reward_curve) ([]int, uint64, uint64) {
+	taken := map[int]bool{}
+	for _, i := range selected {
+		taken[i] = true
+	}
+	for _, i := range order {
+		tx := candidates[i]
+		if taken[i] || size+tx.Size > max_size {
+			continue
+		}
+		if lost := reward(size) - reward(size+tx.Size); tx.Fee <= lost && lost > 0 { // not worth growing the block
+			continue
+		}
+		selected = append(selected, i)
+		fees += tx.Fee
+		size += tx.Size
+	}
+	return selected, fees, size
+}
+
+// best fees for every block size upto units, sizes are rounded up to TEMPLATE_SIZE_UNIT
+// the size with best fees + reward is chosen, reward at rounded size is never more than reward at actual size
+func knapsack_template(candidates []template_tx, order []int, units uint64, reward reward_curve) (selected []int, fees uint64, size uint64) {
+	words := units/64 + 1
+	best := make([]uint64, units+1)                   // best fees using atmost this many units
+	taken := make([]uint64, uint64(len(order))*words) // bit set if tx was taken to reach best at size
+	for k, i := range order {
+		w := (candidates[i].Size + TEMPLATE_SIZE_UNIT - 1) / TEMPLATE_SIZE_UNIT
+		if w > units {
+			continue
+		}
+		row := taken[uint64(k)*words:]
+		for u := units; u >= w; u-- {
+			if v := best[u-w] + candidates[i].Fee; v > best[u] {
+				best[u] = v
+				row[u/64] |= 1 << (u % 64)
+			}
+		}
+	}
+
+	u := uint64(0)
+	for v := range best {
+		if best[v]+reward(uint64(v)*TEMPLATE_SIZE_UNIT) > best[u]+reward(u*TEMPLATE_SIZE_UNIT) {
+			u = uint64(v)
+		}
+	}
+
+	// walk back from the chosen size
+	for k := len(order) - 1; k >= 0; k-- {
+		if taken[uint64(k)*words+u/64]&(1<<(u%64)) != 0 {
+			i := order[k]
+			selected = append(selected, i)
+			fees += candidates[i].Fee
+			size += candidates[i].Size
+			u -= (candidates[i].Size + TEMPLATE_SIZE_UNIT - 1) / TEMPLATE_SIZE_UNIT
+		}
+	}
+	return
+}
@@ -0,0 +1,163 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+import "testing"
+import "math/rand"
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/emission"
+
+// selection used earlier, txs in fee per byte order till the first one which does not fit
+func greedy_template(candidates []template_tx, max_size uint64) (fees uint64, size uint64) {
+	for _, i := range resolve_template_conflicts(candidates) {
+		if size+candidates[i].Size > max_size {
+			break
+		}
+		fees += candidates[i].Fee
+		size += candidates[i].Size
+	}
+	return
+}
+
+// reward curve with median size penalty
+func penalty_curve(median uint64) reward_curve {
+	return func(size uint64) uint64 {
+		if size > 2*median {
+			return 0
+		}
+		return emission.GetBlockReward(median, size, config.MAINNET_HARDFORK_1_TOTAL_SUPPLY, 0, 0)
+	}
+}
+
+func random_template_txs(r *rand.Rand, count int) (candidates []template_tx) {
+	for i := 0; i < count; i++ {
+		var tx template_tx
+		r.Read(tx.Hash[:])
+		tx.Size = 1500 + uint64(r.Intn(60*1024))
+		tx.Fee = (tx.Size/1024 + 1) * config.FEE_PER_KB * uint64(1+r.Intn(20))
+		var ki crypto.Hash
+		r.Read(ki[:])
+		tx.Key_Images = append(tx.Key_Images, ki)
+		candidates = append(candidates, tx)
+	}
+	return
+}
+
+func check_template(t *testing.T, candidates []template_tx, selected []int, fees, size, max_size uint64) {
+	used := map[crypto.Hash]bool{}
+	var total_fees, total_size uint64
+	for _, i := range selected {
+		for _, ki := range candidates[i].Key_Images {
+			if used[ki] {
+				t.Fatalf("Conflicting txs selected")
+			}
+			used[ki] = true
+		}
+		total_fees += candidates[i].Fee
+		total_size += candidates[i].Size
+	}
+	if total_fees != fees || total_size != size || size > max_size {
+		t.Fatalf("Wrong selection fees %d/%d size %d/%d max %d", total_fees, fees, total_size, size, max_size)
+	}
+}
+
+// a large well paying tx must not crowd out smaller txs paying more in total
+func Test_Template_Knapsack(t *testing.T) {
+	max_size := uint64(100 * 1024)
+	candidates := []template_tx{
+		{Hash: crypto.Hash{1}, Size: 60 * 1024, Fee: 61},
+		{Hash: crypto.Hash{2}, Size: 50 * 1024, Fee: 50},
+		{Hash: crypto.Hash{3}, Size: 50 * 1024, Fee: 50},
+	}
+
+	selected, fees, size := select_template_txs(candidates, max_size, nil)
+	check_template(t, candidates, selected, fees, size, max_size)
+	greedy_fees, _ := greedy_template(candidates, max_size)
+	if fees != 100 || greedy_fees != 61 {
+		t.Fatalf("Expected fees 100 ( greedy 61 ), got %d ( greedy %d )", fees, greedy_fees)
+	}
+}
+
+// txs spending same key images cannot both be selected
+func Test_Template_Conflicts(t *testing.T) {
+	ki := crypto.Hash{9}
+	candidates := []template_tx{
+		{Hash: crypto.Hash{1}, Size: 1000, Fee: 1000, Key_Images: []crypto.Hash{ki}},
+		{Hash: crypto.Hash{2}, Size: 1000, Fee: 2000, Key_Images: []crypto.Hash{ki}},
+		{Hash: crypto.Hash{3}, Size: 1000, Fee: 500, Key_Images: []crypto.Hash{{8}}},
+	}
+	selected, fees, size := select_template_txs(candidates, config.CRYPTONOTE_MAX_BLOCK_SIZE, nil)
+	check_template(t, candidates, selected, fees, size, config.CRYPTONOTE_MAX_BLOCK_SIZE)
+	if len(selected) != 2 || selected[0] != 1 || selected[1] != 2 {
+		t.Fatalf("Wrong txs selected %+v", selected)
+	}
+}
+
+// growing the block beyond median size is only done while fees pay for the lost reward
+func Test_Template_Penalty(t *testing.T) {
+	median := config.CRYPTONOTE_BLOCK_GRANTED_FULL_REWARD_ZONE
+	max_size := 2 * median
+	reward := penalty_curve(median)
+
+	candidates := random_template_txs(rand.New(rand.NewSource(1)), 40)
+	selected, fees, size := select_template_txs(candidates, max_size, reward)
+	check_template(t, candidates, selected, fees, size, max_size)
+
+	greedy_fees, greedy_size := greedy_template(candidates, max_size)
+	revenue, greedy_revenue := fees+reward(size), greedy_fees+reward(greedy_size)
+	t.Logf("penalty: selected %d bytes revenue %d, greedy %d bytes revenue %d", size, revenue, greedy_size, greedy_revenue)
+	if size > median+median/2 || revenue <= greedy_revenue {
+		t.Fatalf("Penalty not considered, size %d revenue %d greedy revenue %d", size, revenue, greedy_revenue)
+	}
+}
+
+// selection never earns less than greedy
+func Test_Template_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	median := config.CRYPTONOTE_BLOCK_GRANTED_FULL_REWARD_ZONE
+
+	var total, greedy_total uint64
+	for round := 0; round < 50; round++ {
+		candidates := random_template_txs(r, 10+r.Intn(80))
+		for i := range candidates { // create some conflicts
+			if j := r.Intn(len(candidates)); r.Intn(10) == 0 && j != i {
+				candidates[i].Key_Images = append(candidates[i].Key_Images, candidates[j].Key_Images[0])
+			}
+		}
+
+		var reward reward_curve
+		max_size := config.CRYPTONOTE_MAX_BLOCK_SIZE
+		if round%2 == 1 {
+			reward, max_size = penalty_curve(median), 2*median
+		}
+
+		selected, fees, size := select_template_txs(candidates, max_size, reward)
+		check_template(t, candidates, selected, fees, size, max_size)
+		greedy_fees, greedy_size := greedy_template(candidates, max_size)
+		if reward != nil {
+			fees, greedy_fees = fees+reward(size), greedy_fees+reward(greedy_size)
+		}
+		if fees < greedy_fees {
+			t.Fatalf("Round %d revenue %d less than greedy %d", round, fees, greedy_fees)
+		}
+		total += fees
+		greedy_total += greedy_fees
+	}
+	t.Logf("revenue %d greedy revenue %d, %.2f%% more", total, greedy_total, float64(total-greedy_total)*100/float64(greedy_total))
+}
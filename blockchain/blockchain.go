@@ -80,20 +80,50 @@ type Blockchain struct {
 	Top_Block_Median_Size uint64 // median block size of current top block
 	Top_Block_Base_Reward uint64 // top block base reward
 
-	checkpints_disabled bool // are checkpoints disabled
-	simulator           bool // is simulator mode
+	checkpints_disabled bool  // are checkpoints disabled
+	simulator           bool  // is simulator mode
+	prune_depth         int64 // blocks deeper than this are pruned, 0 disables pruning
 
 	P2P_Block_Relayer func(*block.Complete_Block, uint64) // tell p2p to broadcast any block this daemon hash found
 
+	event_lock       sync.RWMutex
+	event_handlers   map[int]Event_Handler // see events.go
+	event_handler_id int
+
+	indexer          bool // is view key indexer enabled, see indexer.go
+	indexer_lock     sync.RWMutex
+	indexer_accounts []*indexer_account
+	indexer_rescan   int32 // set while a registration rescans, only one registration rescans at a time
+
+	preverify_queue chan func() // see preverify.go
+
 	sync.RWMutex
 }
 
 var logger *log.Entry
 
+var metrics_register_once sync.Once
+
 //var Exit_Event = make(chan bool) // causes all threads to exit
 
 // All blockchain activity is store in a single
 
+// select storage backend as requested by the user
+func select_store(params map[string]interface{}) storage.Store {
+	if params["--memdb"] == true || globals.Arguments["--memdb"] == true { // nothing touches the disk, every chain gets its own store
+		return &storage.MemStore{}
+	}
+
+	if params["--simulator"] == true { // simulator uses boltdb backend unless memdb is requested
+		return storage.Bolt_backend
+	}
+
+	if (runtime.GOARCH == "amd64" && !globals.Arguments["--badgerdb"].(bool)) || globals.Arguments["--boltdb"].(bool) {
+		return storage.Bolt_backend
+	}
+	return storage.Badger_backend
+}
+
 /* do initialisation , setup storage, put genesis block and chain in store
    This is the first component to get up
    Global parameters are picked up  from the config package
@@ -109,19 +139,8 @@ func Blockchain_Start(params map[string]interface{}) (*Blockchain, error) {
 	//init_static_checkpoints()           // init some hard coded checkpoints
 	checkpoints.LoadCheckPoints(logger) // load checkpoints from file if provided
 
-	if params["--simulator"] == true { // simulator always uses boltdb backend
-		chain.store = storage.Bolt_backend // setup backend
-		chain.store.Init(params)           // init backend
-
-	} else {
-		if (runtime.GOARCH == "amd64" && !globals.Arguments["--badgerdb"].(bool)) || globals.Arguments["--boltdb"].(bool) {
-			chain.store = storage.Bolt_backend // setup backend
-			chain.store.Init(params)           // init backend
-		} else {
-			chain.store = storage.Badger_backend // setup backend
-			chain.store.Init(params)             // init backend
-		}
-	}
+	chain.store = select_store(params) // setup backend
+	chain.store.Init(params)           // init backend
 
 	/*
 
@@ -151,10 +170,22 @@ func Blockchain_Start(params map[string]interface{}) (*Blockchain, error) {
 		chain.simulator = true // enable simulator mode, this will set hard coded difficulty to 1
 	}
 
+	if depth, ok := params["--prune"].(int64); ok {
+		if depth < PRUNE_MIN_DEPTH {
+			return nil, fmt.Errorf("Prune depth %d is less than minimum %d", depth, PRUNE_MIN_DEPTH)
+		}
+		chain.prune_depth = depth
+	}
+
+	if params["--indexer"] == true {
+		chain.indexer = true
+	}
+
 	chain.Exit_Event = make(chan bool) // init exit channel
 
 	// init mempool before chain starts
 	chain.Mempool, err = mempool.Init_Mempool(params)
+	chain.Mempool.TX_Notifier = chain.mempool_notifier
 
 	// we need to check mainnet/testnet check whether the genesis block matches the testnet/mainet
 	// mean whether the user is trying to use mainnet db with testnet option or vice-versa
@@ -241,13 +272,31 @@ func Blockchain_Start(params map[string]interface{}) (*Blockchain, error) {
 	// load the chain from the disk
 	chain.Initialise_Chain_From_DB()
 
+	// catch up with pruning, an existing full chain may have to be pruned now
+	if chain.prune_depth > 0 {
+		if err = chain.Prune_Chain(); err != nil {
+			logger.Warnf("Error pruning chain err %s", err)
+		}
+	}
+
 	//   logger.Fatalf("Testing complete quitting")
 
+	if chain.indexer {
+		chain.load_indexer_accounts()
+	}
+
 	// hard forks must be initialized after chain is up
 	init_hard_forks(params)
 
+	// txs persisted by mempool during previous run are verified against current chain, before they are accepted
+	if restored, dropped := chain.Mempool.Mempool_Restore(chain.Add_TXs_To_Pool); restored+dropped > 0 {
+		logger.Infof("Restored %d txs to mempool, %d txs dropped as they are no longer valid", restored, dropped)
+	}
+
 	go clean_up_valid_cache() // clean up valid cache
 
+	chain.preverify_start() // start block preverification workers
+
 	/*  txlist := chain.Mempool.Mempool_List_TX()
 	    for i := range txlist {
 	       // if fmt.Sprintf("%s", txlist[i]) == "0fe0e7270ba911956e91d9ea099e4d12aa1bce2473d4064e239731bc37acfd86"{
@@ -268,14 +317,21 @@ func Blockchain_Start(params map[string]interface{}) (*Blockchain, error) {
 
 	atomic.AddUint32(&globals.Subsystem_Active, 1) // increment subsystem
 
-	// register the metrics with the metrics registry
-	metrics.Registry.MustRegister(blockchain_tx_counter)
-	metrics.Registry.MustRegister(mempool_tx_counter)
-	metrics.Registry.MustRegister(mempool_tx_count)
-	metrics.Registry.MustRegister(block_size)
-	metrics.Registry.MustRegister(transaction_size)
-	metrics.Registry.MustRegister(block_tx_count)
-	metrics.Registry.MustRegister(block_processing_time)
+	// register the metrics with the metrics registry, only once even if more chains are started
+	metrics_register_once.Do(func() {
+		metrics.Registry.MustRegister(blockchain_tx_counter)
+		metrics.Registry.MustRegister(mempool_tx_counter)
+		metrics.Registry.MustRegister(mempool_tx_count)
+		metrics.Registry.MustRegister(mempool_size_bytes)
+		metrics.Registry.MustRegister(mempool_max_bytes)
+		metrics.Registry.MustRegister(mempool_max_tx_count)
+		metrics.Registry.MustRegister(mempool_min_fee_per_kb)
+		metrics.Registry.MustRegister(mempool_evicted_tx_count)
+		metrics.Registry.MustRegister(block_size)
+		metrics.Registry.MustRegister(transaction_size)
+		metrics.Registry.MustRegister(block_tx_count)
+		metrics.Registry.MustRegister(block_processing_time)
+	})
 
 	return &chain, nil
 }
@@ -443,6 +499,37 @@ var mempool_tx_count = prometheus.NewGauge(prometheus.GaugeOpts{
 	Name: "mempool_tx_count",
 	Help: "Number of tx in mempool at this point",
 })
+var mempool_size_bytes = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "mempool_size_bytes",
+	Help: "Size of all tx in mempool at this point",
+})
+var mempool_max_bytes = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "mempool_max_bytes",
+	Help: "Mempool size limit in bytes",
+})
+var mempool_max_tx_count = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "mempool_max_tx_count",
+	Help: "Mempool limit on number of tx",
+})
+var mempool_min_fee_per_kb = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "mempool_min_fee_per_kb",
+	Help: "Minimum fee per KB required to enter mempool, rises while mempool is full",
+})
+var mempool_evicted_tx_count = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "mempool_evicted_tx_count",
+	Help: "Number of tx evicted from full mempool since start",
+})
+
+// update mempool metrics
+func (chain *Blockchain) update_mempool_metrics() {
+	stats := chain.Mempool.Mempool_Stats()
+	mempool_tx_count.Set(float64(stats.TXs))
+	mempool_size_bytes.Set(float64(stats.Bytes))
+	mempool_max_bytes.Set(float64(stats.Max_Bytes))
+	mempool_max_tx_count.Set(float64(stats.Max_TXs))
+	mempool_min_fee_per_kb.Set(float64(stats.Min_Fee_Per_KB))
+	mempool_evicted_tx_count.Set(float64(stats.Evicted))
+}
 
 //  track block size about 2 MB
 var block_size = prometheus.NewHistogram(prometheus.HistogramOpts{
@@ -477,6 +564,13 @@ var block_processing_time = prometheus.NewHistogram(prometheus.HistogramOpts{
 // verifying everything  means everything possible
 // this only change mempool, no DB changes
 func (chain *Blockchain) Add_TX_To_Pool(tx *transaction.Transaction) (result bool) {
+	return chain.Add_TXs_To_Pool([]*transaction.Transaction{tx})[0]
+}
+
+// same as Add_TX_To_Pool, but for txs arriving together, results[i] is the result of txs[i]
+// ring signatures of all the txs are verified together, so that their bulletproofs are batch verified
+func (chain *Blockchain) Add_TXs_To_Pool(txs []*transaction.Transaction) (results []bool) {
+	results = make([]bool, len(txs))
 
 	// chain lock is no longer required as we only do readonly processing
 	//	chain.Lock()
@@ -485,43 +579,78 @@ func (chain *Blockchain) Add_TX_To_Pool(tx *transaction.Transaction) (result boo
 	dbtx, err := chain.store.BeginTX(false)
 	if err != nil {
 		logger.Warnf("Could NOT create DB transaction  err %s", err)
-		return true // just make it rebroadcast
+		for i := range results {
+			results[i] = true // just make it rebroadcast
+		}
+		return
 	}
 
 	// track counter for the amount of mempool tx
-	defer mempool_tx_count.Set(float64(len(chain.Mempool.Mempool_List_TX())))
+	defer chain.update_mempool_metrics()
 
 	defer dbtx.Rollback()
 
+	hf_version := chain.Get_Current_Version_at_Height(chain.Get_Height())
+
+	var pending []*transaction.Transaction // txs which passed the cheap checks and must be fully verified
+	var pending_index []int
+	for i := range txs {
+		var verify bool
+		if results[i], verify = chain.check_tx_for_pool(dbtx, hf_version, txs[i]); verify {
+			pending = append(pending, txs[i])
+			pending_index = append(pending_index, i)
+		}
+	}
+
+	for j, verified := range chain.Verify_Transactions_NonCoinbase(dbtx, hf_version, pending) {
+		tx, txhash := pending[j], pending[j].GetHash()
+		if verified && chain.Verify_Transaction_NonCoinbase_DoubleSpend_Check(dbtx, tx) {
+			if chain.Mempool.Mempool_Add_TX(tx, 0) { // new tx come with 0 marker
+				rlog.Tracef(2, "Successfully added tx %s to pool", txhash)
+
+				mempool_tx_counter.Inc()
+				results[pending_index[j]] = true
+			} else {
+				rlog.Tracef(2, "TX %s rejected by pool", txhash)
+			}
+			continue
+		}
+
+		rlog.Warnf("Incoming TX %s could not be verified", txhash)
+	}
+	return
+}
+
+// checks done before the expensive verification of a tx entering the pool
+// if verify is false, result is final and the tx must not be verified further
+func (chain *Blockchain) check_tx_for_pool(dbtx storage.DBTX, hf_version int64, tx *transaction.Transaction) (result bool, verify bool) {
 	txhash := tx.GetHash()
 
 	// Coin base TX can not come through this path
 	if tx.IsCoinbase() {
 		logger.WithFields(log.Fields{"txid": txhash}).Warnf("TX rejected  coinbase tx cannot appear in mempool")
-		return false
+		return false, false
 	}
 
 	// quick check without calculating everything whether tx is in pool, if yes we do nothing
 	if chain.Mempool.Mempool_TX_Exist(txhash) {
 		rlog.Tracef(2,"TX %s rejected Already in MEMPOOL", txhash)
-		return true
+		return true, false
 	}
 
-	hf_version := chain.Get_Current_Version_at_Height(chain.Get_Height())
-
 	// TODO if someone is relaying existing tx again and again, we need to quickly figure it and avoid expensive verification
 	// a simple technique seems to be to do key image verification for double spend, if it's reject
 	// this test is placed to avoid ring signature verification cost for faulty tx as early as possible
 	if !chain.Verify_Transaction_NonCoinbase_DoubleSpend_Check(dbtx, tx) { // BUG BUG BUG we must use dbtx to confirm
 		rlog.Tracef(2,"TX %s rejected due to double spending", txhash)
-		return false
+		return false, false
 	}
 
 	// if TX is too big, then it cannot be mined due to fixed block size, reject such TXs here
 	// currently, limits are  as per consensus
 	if uint64(len(tx.Serialize())) > config.CRYPTONOTE_MAX_TX_SIZE {
 		logger.WithFields(log.Fields{"txid": txhash}).Warnf("TX rejected  Size %d byte Max possible %d", len(tx.Serialize()), config.CRYPTONOTE_MAX_TX_SIZE)
-		return false
+		return false, false
 	}
 
 	// check whether enough fees is provided in the transaction
@@ -532,24 +661,16 @@ func (chain *Blockchain) Add_TX_To_Pool(tx *transaction.Transaction) (result boo
 		logger.WithFields(log.Fields{"txid": txhash}).Warnf("TX rejected due to low fees  provided fee %d calculated fee %d", provided_fee, calculated_fee)
 
 		rlog.Warnf("TX  %s rejected due to low fees  provided fee %d calculated fee %d", txhash, provided_fee, calculated_fee)
-		return false
+		return false, false
 	}
 
-	if chain.Verify_Transaction_NonCoinbase(dbtx, hf_version, tx) && chain.Verify_Transaction_NonCoinbase_DoubleSpend_Check(dbtx, tx) {
-		if chain.Mempool.Mempool_Add_TX(tx, 0) { // new tx come with 0 marker
-			rlog.Tracef(2,"Successfully added tx %s to pool", txhash)
-
-			mempool_tx_counter.Inc()
-			return true
-		} else {
-			rlog.Tracef(2,"TX %s rejected by pool", txhash)
-			return false
-		}
+	// while mempool is full, fees must be higher than txs evicted from it
+	if !chain.Mempool.Mempool_Fee_Sufficient(provided_fee, uint64(len(tx.Serialize()))) {
+		rlog.Warnf("TX  %s rejected due to low fees  provided fee %d mempool minimum fee per KB %d", txhash, provided_fee, chain.Mempool.Mempool_Min_Fee_Per_KB())
+		return false, false
 	}
 
-	rlog.Warnf("Incoming TX %s could not be verified", txhash)
-	return false
-
+	return false, true
 }
 
 // structure used to rank/sort  blocks on a number of factors
@@ -658,6 +779,7 @@ func (chain *Blockchain) isblock_SideBlock(dbtx storage.DBTX, blid crypto.Hash,
 func (chain *Blockchain) Add_Complete_Block(cbl *block.Complete_Block) (err error, result bool) {
 
 	var block_hash crypto.Hash
+	var events []Event // delivered only after block is committed
 	chain.Lock()
 	defer chain.Unlock()
 	result = false
@@ -690,6 +812,10 @@ func (chain *Blockchain) Add_Complete_Block(cbl *block.Complete_Block) (err erro
 
 			rlog.Infof("Block successfully acceppted by chain %s", block_hash)
 
+			for i := range events {
+				chain.notify_event(events[i])
+			}
+
 			// gracefully try to instrument
 			func() {
 				defer func() {
@@ -995,25 +1121,18 @@ func (chain *Blockchain) Add_Complete_Block(cbl *block.Complete_Block) (err erro
 		*/
 
 		// we need to anyways verify the TXS since RCT signatures are not covered by checksum
-		fail_count := int32(0)
-		wg := sync.WaitGroup{}
-		wg.Add(len(cbl.Txs)) // add total number of tx as work
-
+		// all the txs are verified together, so bulletproofs of the entire block are batch verified
+		// NOTE : do NOT skip verification of Ring Signatures, even if the TX is already stored
+		//        as change of conditions might cause the signature to be invalid
+		fail_count := 0
 		hf_version := chain.Get_Current_Version_at_Height(chain.Calculate_Height_At_Tips(dbtx, bl.Tips))
-		for i := 0; i < len(cbl.Txs); i++ {
-			go func(j int) {
-
-				// NOTE : do NOT skip verification of Ring Signatures, even if the TX is already stored
-				//        as change of conditions might cause the signature to be invalid
-				if !chain.Verify_Transaction_NonCoinbase(dbtx, hf_version, cbl.Txs[j]) { // transaction verification failed
-					atomic.AddInt32(&fail_count, 1) // increase fail count by 1
-					block_logger.Warnf("Block verification failed rejecting since TX  %s verification failed", cbl.Txs[j].GetHash())
-				}
-				wg.Done()
-			}(i)
+		for i, result := range chain.Verify_Transactions_NonCoinbase(dbtx, hf_version, cbl.Txs) {
+			if !result { // transaction verification failed
+				fail_count++
+				block_logger.Warnf("Block verification failed rejecting since TX  %s verification failed", cbl.Txs[i].GetHash())
+			}
 		}
 
-		wg.Wait()           // wait for verifications to finish
 		if fail_count > 0 { // check the result
 			block_logger.Warnf("Block verification failed  rejecting since TX verification failed ")
 			return errormsg.ErrInvalidTX, false
@@ -1096,6 +1215,9 @@ skip_checks:
 		rlog.Infof("Full order %+v base %s base topo pos %d", full_order, base, base_topo_index)
 
 		last_topo_height := chain.Load_TOPO_HEIGHT(dbtx)
+		old_states := map[crypto.Hash]topo_state{} // blocks whose client protocol is reversed, to report topo changes
+		new_topo := map[crypto.Hash]int64{}
+		indexer_accounts := chain.indexer_accounts_list() // nil if indexer is disabled
 
 		if len(bl.Tips) == 0 {
 			base_topo_index = 0
@@ -1118,6 +1240,7 @@ skip_checks:
 
 			rlog.Debugf("running client protocol in reverse for %s", last_topo_block)
 
+			chain.record_topo_state(dbtx, old_states, bl_current, last_topo_block, last_topo_height)
 			chain.client_protocol_reverse(dbtx, bl_current, last_topo_block)
 
 			// run client protocol in reverse till we reach base
@@ -1132,6 +1255,7 @@ skip_checks:
 		for i := int64(0); i < int64(len(full_order)); i++ {
 
 			chain.Store_Block_Topological_order(dbtx, full_order[i], i+base_topo_index)
+			new_topo[full_order[i]] = i + base_topo_index
 			highest_topo = base_topo_index + i
 
 			rlog.Debugf("%d %s   topo_index %d  base topo %d", i, full_order[i], i+base_topo_index, base_topo_index)
@@ -1168,71 +1292,18 @@ skip_checks:
 			//mark tx found in this block is valid
 			chain.mark_TX(dbtx, bl_current_hash, bl_current.Miner_TX.GetHash(), true)
 
-			// hard fork version is used to import transactions from earlier version of DERO chain
-			// in order to keep things simple, the earlier emission/fees calculation/dynamic block size has been discarded
-			// due to above reasons miner TX from the earlier could NOT be verified
-			// emission calculations/ total supply should NOT change when importing earlier chain
-			if hard_fork_version_current == 1 {
-
-				// store total  reward
-				dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, bl_current_hash[:], PLANET_MINERTX_REWARD, bl_current.Miner_TX.Vout[0].Amount)
-
-				// store base reward
-				dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, bl_current_hash[:], PLANET_BASEREWARD, bl_current.Miner_TX.Vout[0].Amount)
-
-				// store total generated coins
-				// this is hardcoded at initial chain import, keeping original emission schedule
-				if globals.IsMainnet(){
-						dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, bl_current_hash[:], PLANET_ALREADY_GENERATED_COINS, config.MAINNET_HARDFORK_1_TOTAL_SUPPLY)
-					}else{
-						dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, bl_current_hash[:], PLANET_ALREADY_GENERATED_COINS, config.TESTNET_HARDFORK_1_TOTAL_SUPPLY)
-					}
-				
-
-			} else { //  hf 2 or later generate miner TX rewards as per client protocol
-
-				past_coins_generated := chain.Load_Already_Generated_Coins_for_Topo_Index(dbtx, highest_topo-1)
-
-				base_reward := emission.GetBlockReward_Atlantis(hard_fork_version_current, past_coins_generated)
-
-				// base reward is only 90%, rest 10 % is pushed back
-				if globals.IsMainnet(){
-					base_reward = (base_reward * 9) / 10
-				}
-
-				// lower reward for byzantine behaviour
-				// for as many block as added
-				if chain.isblock_SideBlock(dbtx, bl_current_hash, highest_topo) { // lost race (or byzantine behaviour)
-                                    if  hard_fork_version_current == 2 {
-					base_reward = (base_reward * 67) / 100 // give only 67 % reward
-                                    }else{
-                                        base_reward = (base_reward * 8) / 100 // give only 8 % reward
-                                    }
-				}
-
-				// logger.Infof("past coins generated %d base reward %d", past_coins_generated, base_reward)
-
-				// the total reward must be given to the miner TX, since it contains 0, we patch only the output
-				// and leave the original TX untouched
-				total_reward := base_reward + total_fees
-
-				if hard_fork_version_current >= 5 {
-					total_reward = 1 // 1 atomic unit
-					base_reward = 0 // 0 atomic unit 
-				}
-
-				// store total  reward
-				dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, bl_current_hash[:], PLANET_MINERTX_REWARD, total_reward)
+			// calculate rewards as per client protocol
+			past_coins_generated := chain.Load_Already_Generated_Coins_for_Topo_Index(dbtx, highest_topo-1)
+			base_reward, total_reward, already_generated_coins := chain.calculate_block_rewards(dbtx, bl_current, bl_current_hash, highest_topo, hard_fork_version_current, past_coins_generated, total_fees)
 
-				// store base reward
-				dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, bl_current_hash[:], PLANET_BASEREWARD, base_reward)
+			// store total  reward
+			dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, bl_current_hash[:], PLANET_MINERTX_REWARD, total_reward)
 
-				// store total generated coins
-				dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, bl_current_hash[:], PLANET_ALREADY_GENERATED_COINS, past_coins_generated+base_reward)
+			// store base reward
+			dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, bl_current_hash[:], PLANET_BASEREWARD, base_reward)
 
-				//logger.Infof("base reward %s  total generated %s",globals.FormatMoney12(base_reward), globals.FormatMoney12(past_coins_generated+base_reward))
-
-			}
+			// store total generated coins
+			dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, bl_current_hash[:], PLANET_ALREADY_GENERATED_COINS, already_generated_coins)
 
 			// TODO FIXME valid transactions must be found and thier fees should be added as reward
 
@@ -1254,12 +1325,25 @@ skip_checks:
 				return errormsg.ErrInvalidBlock, false
 			}
 
+			// scan outputs for registered view keys
+			chain.index_block(dbtx, bl_current_hash, indexer_accounts)
+
 			// this tx must be stored, linked with this block
 
 		}
 
 		chain.Store_TOPO_HEIGHT(dbtx, int64(highest_topo))
 
+		if blocks, txs, changed := chain.topo_changes(dbtx, old_states, new_topo); changed >= 0 {
+			events = append(events, Event{Type: EVENT_TOPO_CHANGE, Changed: changed, Blocks: blocks, TXs: txs, Height: atomic.LoadInt64(&chain.Height), Topoheight: highest_topo})
+		}
+		events = append(events, Event{Type: EVENT_NEW_BLOCK, BLID: bl.GetHash(), Height: atomic.LoadInt64(&chain.Height), Topoheight: highest_topo})
+
+		// prune a few blocks which are now deep enough
+		if chain.prune_depth > 0 {
+			chain.prune_blocks(dbtx, highest_topo, PRUNE_BLOCKS_PER_TX)
+		}
+
 		// set main chain as new topo order
 		// we must discard any rusty tips after they go stale
 		best_height := int64(chain.Load_Height_for_BL_ID(dbtx, best))
@@ -1330,7 +1414,7 @@ skip_checks:
 			txid := cbl.Txs[i].GetHash()
 			if chain.Mempool.Mempool_TX_Exist(txid) {
 				rlog.Tracef(1, "Deleting TX from pool txid=%s", txid)
-				chain.Mempool.Mempool_Delete_TX(txid)
+				chain.Mempool.Mempool_Delete_Mined_TX(txid, uint64(block_height))
 			}
 		}
 
@@ -1339,11 +1423,58 @@ skip_checks:
 		chain.Mempool.HouseKeeping(uint64(block_height), func(tx *transaction.Transaction) bool {
 			return chain.Verify_Transaction_NonCoinbase_DoubleSpend_Check(dbtx, tx)
 		})
+		chain.update_mempool_metrics()
 	}()
 
 	return // run any handlers necesary to atomically
 }
 
+// calculates the rewards of a block as per client protocol
+// past_coins_generated are the coins generated till the previous block in topo order
+func (chain *Blockchain) calculate_block_rewards(dbtx storage.DBTX, bl *block.Block, blid crypto.Hash, topoheight int64, hard_fork_version_current int64, past_coins_generated uint64, total_fees uint64) (base_reward uint64, total_reward uint64, already_generated_coins uint64) {
+
+	// hard fork version is used to import transactions from earlier version of DERO chain
+	// in order to keep things simple, the earlier emission/fees calculation/dynamic block size has been discarded
+	// due to above reasons miner TX from the earlier could NOT be verified
+	// emission calculations/ total supply should NOT change when importing earlier chain
+	if hard_fork_version_current == 1 {
+		// this is hardcoded at initial chain import, keeping original emission schedule
+		if globals.IsMainnet() {
+			return bl.Miner_TX.Vout[0].Amount, bl.Miner_TX.Vout[0].Amount, config.MAINNET_HARDFORK_1_TOTAL_SUPPLY
+		}
+		return bl.Miner_TX.Vout[0].Amount, bl.Miner_TX.Vout[0].Amount, config.TESTNET_HARDFORK_1_TOTAL_SUPPLY
+	}
+
+	//  hf 2 or later generate miner TX rewards as per client protocol
+	base_reward = emission.GetBlockReward_Atlantis(hard_fork_version_current, past_coins_generated)
+
+	// base reward is only 90%, rest 10 % is pushed back
+	if globals.IsMainnet() {
+		base_reward = (base_reward * 9) / 10
+	}
+
+	// lower reward for byzantine behaviour
+	// for as many block as added
+	if chain.isblock_SideBlock(dbtx, blid, topoheight) { // lost race (or byzantine behaviour)
+		if hard_fork_version_current == 2 {
+			base_reward = (base_reward * 67) / 100 // give only 67 % reward
+		} else {
+			base_reward = (base_reward * 8) / 100 // give only 8 % reward
+		}
+	}
+
+	// the total reward must be given to the miner TX, since it contains 0, we patch only the output
+	// and leave the original TX untouched
+	total_reward = base_reward + total_fees
+
+	if hard_fork_version_current >= 5 {
+		total_reward = 1 // 1 atomic unit
+		base_reward = 0  // 0 atomic unit
+	}
+
+	return base_reward, total_reward, past_coins_generated + base_reward
+}
+
 // runs the client protocol which includes the following operations
 // if any TX are being duplicate or double-spend ignore them
 // mark all the valid transactions as valid
@@ -1422,15 +1553,17 @@ func (chain *Blockchain) transaction_scavenger(dbtx storage.DBTX, blid crypto.Ha
 		if chain.Is_Block_Orphan(k) {
 			bl, err := chain.Load_BL_FROM_ID(dbtx, k)
 			if err == nil {
+				var txs []*transaction.Transaction
 				for i := range bl.Tx_hashes {
 					tx, err := chain.Load_TX_FROM_ID(dbtx, bl.Tx_hashes[i])
 					if err != nil {
 						rlog.Warnf("err while scavenging blid %s  txid %s err %s", k, bl.Tx_hashes[i], err)
 					} else {
-						// add tx to pool, it will do whatever is necessarry
-						chain.Add_TX_To_Pool(tx)
+						txs = append(txs, tx)
 					}
 				}
+				// add txs to pool, it will do whatever is necessarry
+				chain.Add_TXs_To_Pool(txs)
 			} else {
 				rlog.Warnf("err while scavenging blid %s err %s", k, err)
 			}
@@ -1583,6 +1716,10 @@ func (chain *Blockchain) Expand_Transaction_v2(dbtx storage.DBTX, hf_version int
 
 	// fill up the key images from the blockchain
 	for i := 0; i < len(tx.Vin); i++ {
+		if tx.RctSignature.Get_Sig_Type() == ringct.RCTTypeCLSAG {
+			tx.RctSignature.ClsagSigs[i].I = crypto.Key(tx.Vin[i].(transaction.Txin_to_key).K_image)
+			continue
+		}
 		tx.RctSignature.MlsagSigs[i].II = tx.RctSignature.MlsagSigs[i].II[:0] // zero it out
 		tx.RctSignature.MlsagSigs[i].II = make([]crypto.Key, 1, 1)
 		tx.RctSignature.MlsagSigs[i].II[0] = crypto.Key(tx.Vin[i].(transaction.Txin_to_key).K_image)
@@ -1631,7 +1768,7 @@ func (chain *Blockchain) Expand_Transaction_v2(dbtx storage.DBTX, hf_version int
 			}
 		}
 
-	case ringct.RCTTypeSimple, ringct.RCTTypeSimpleBulletproof:
+	case ringct.RCTTypeSimple, ringct.RCTTypeSimpleBulletproof, ringct.RCTTypeSimpleBulletproofAggregate, ringct.RCTTypeCLSAG:
 		mixin := len(tx.Vin[0].(transaction.Txin_to_key).Key_offsets)
 		_ = mixin
 		tx.RctSignature.MixRing = make([][]ringct.CtKey, len(tx.Vin), len(tx.Vin))
@@ -1731,6 +1868,8 @@ func sliceExists(slice []crypto.Hash, hash crypto.Hash) bool {
 // this function will rewind the chain from the topo height one block at a time
 // this function also runs the client protocol in reverse and also deletes the block from the storage
 func (chain *Blockchain) Rewind_Chain(rewind_count int) (result bool) {
+	var event Event // delivered only after rewind is committed
+	old_states := map[crypto.Hash]topo_state{} // blocks removed from topo order
 	chain.Lock()
 	defer chain.Unlock()
 
@@ -1751,6 +1890,7 @@ func (chain *Blockchain) Rewind_Chain(rewind_count int) (result bool) {
 		if result == true { // block was successfully added, commit it atomically
 			dbtx.Commit()
 			dbtx.Sync() // sync the DB to disk after every execution of this function
+			chain.notify_event(event)
 		} else {
 			dbtx.Rollback() // if block could not be added, rollback all changes to previous block
 		}
@@ -1821,6 +1961,10 @@ func (chain *Blockchain) Rewind_Chain(rewind_count int) (result bool) {
 				return false
 			}
 
+			if chain.Is_Block_Topological_order(dbtx, blid) {
+				chain.record_topo_state(dbtx, old_states, bl_current, blid, chain.Load_Block_Topological_order(dbtx, blid))
+			}
+
 			logger.Debugf("running client protocol in reverse for %s", blid)
 			// run client protocol in reverse
 			chain.client_protocol_reverse(dbtx, bl_current, blid)
@@ -1881,6 +2025,8 @@ func (chain *Blockchain) Rewind_Chain(rewind_count int) (result bool) {
 	}
 	rlog.Infof("height after rewind %d", chain.Load_TOPO_HEIGHT(dbtx))
 
+	blocks, txs, changed := chain.topo_changes(dbtx, old_states, map[crypto.Hash]int64{})
+	event = Event{Type: EVENT_TOPO_CHANGE, Changed: changed, Blocks: blocks, TXs: txs, Height: chain.Load_TOP_HEIGHT(dbtx), Topoheight: chain.Load_TOPO_HEIGHT(dbtx)}
 	return true
 }
 
@@ -85,7 +85,7 @@ func Create_Miner_TX2(hf_version, height int64, miner_address address.Address) (
 
 	//TODO need to fix hard fork version checks
 	switch {
-	case hf_version <= 6:
+	case hf_version <= 7:
 		tx.Version = 2
 	default:
 		err = fmt.Errorf("NO such hardfork version")
@@ -344,7 +344,7 @@ func (chain *Blockchain) VerifyPoW(dbtx storage.DBTX, bl *block.Block) (verified
 
 	//PoW := crypto.Scrypt_1024_1_1_256(block_work)
 	//PoW := crypto.Keccak256(block_work)
-	PoW := bl.GetPoWHash()
+	PoW := get_pow_hash(bl) // may have been computed by preverification
 
 	block_difficulty := chain.Get_Difficulty_At_Tips(dbtx, bl.Tips)
 
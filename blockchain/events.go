@@ -0,0 +1,161 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+// this file implements events emitted by the chain, so as rpc server and others can act as things happen
+// instead of polling the chain
+
+import "sort"
+import "runtime/debug"
+
+import "github.com/deroproject/derosuite/block"
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/storage"
+import "github.com/deroproject/derosuite/transaction"
+
+const (
+	EVENT_NEW_BLOCK      = "new_block"      // block has been accepted by chain
+	EVENT_TOPO_CHANGE    = "topo_change"    // blocks already in topo order have been reordered or removed
+	EVENT_MEMPOOL_ADD    = "mempool_add"    // tx has been added to mempool
+	EVENT_MEMPOOL_DELETE = "mempool_delete" // tx has been removed from mempool, either mined or discarded
+)
+
+type Event struct {
+	Type       string
+	BLID       crypto.Hash              // block added, for EVENT_NEW_BLOCK
+	Height     int64                    // chain height after the event, for chain events
+	Topoheight int64                    // chain topoheight after the event, for chain events
+	Changed    int64                    // topoheight from which order has changed, for EVENT_TOPO_CHANGE
+	Blocks     []Topo_Change_Block      // blocks whose topoheight changed, for EVENT_TOPO_CHANGE
+	TXs        []Topo_Change_TX         // txs whose validity flipped, for EVENT_TOPO_CHANGE
+	TX         *transaction.Transaction // for mempool events
+}
+
+// since the DAG is ordered using Generate_Full_Order, blocks may change topoheight without a classic reorg
+type Topo_Change_Block struct {
+	BLID           crypto.Hash
+	Old_Topoheight int64
+	New_Topoheight int64 // -1 if block is no longer part of topo order
+}
+
+// a tx is valid or invalid with respect to a block, as the same tx may be included in several blocks
+type Topo_Change_TX struct {
+	TXID  crypto.Hash
+	BLID  crypto.Hash
+	Valid bool // validity after the change, before the change it was the opposite
+}
+
+// position and tx validity of a block, recorded before client protocol is run in reverse
+type topo_state struct {
+	topoheight int64
+	txs        []crypto.Hash
+	valid      []bool
+}
+
+func (chain *Blockchain) record_topo_state(dbtx storage.DBTX, states map[crypto.Hash]topo_state, bl *block.Block, blid crypto.Hash, topoheight int64) {
+	state := topo_state{topoheight: topoheight, txs: bl.Tx_hashes}
+	for i := range bl.Tx_hashes {
+		state.valid = append(state.valid, chain.IS_TX_Valid(dbtx, blid, bl.Tx_hashes[i]))
+	}
+	states[blid] = state
+}
+
+// compare recorded states with current order and validity, new_topo contains current topoheight of blocks
+// returns changes ordered by old topoheight, and the lowest topoheight affected or -1 if nothing changed
+func (chain *Blockchain) topo_changes(dbtx storage.DBTX, states map[crypto.Hash]topo_state, new_topo map[crypto.Hash]int64) (blocks []Topo_Change_Block, txs []Topo_Change_TX, changed int64) {
+	changed = -1
+
+	order := make([]crypto.Hash, 0, len(states))
+	for blid := range states {
+		order = append(order, blid)
+	}
+	sort.Slice(order, func(i, j int) bool { return states[order[i]].topoheight < states[order[j]].topoheight })
+
+	for _, blid := range order {
+		state := states[blid]
+		topoheight, ok := new_topo[blid]
+		if !ok {
+			topoheight = -1
+		}
+		if topoheight != state.topoheight {
+			blocks = append(blocks, Topo_Change_Block{BLID: blid, Old_Topoheight: state.topoheight, New_Topoheight: topoheight})
+			if changed < 0 || state.topoheight < changed {
+				changed = state.topoheight
+			}
+		}
+
+		for i := range state.txs {
+			if valid := chain.IS_TX_Valid(dbtx, blid, state.txs[i]); valid != state.valid[i] {
+				txs = append(txs, Topo_Change_TX{TXID: state.txs[i], BLID: blid, Valid: valid})
+				if changed < 0 || state.topoheight < changed {
+					changed = state.topoheight
+				}
+			}
+		}
+	}
+	return
+}
+
+// handlers are called synchronously while the chain or mempool is locked
+// so they must not block and must not call back into the chain
+type Event_Handler func(Event)
+
+// register a handler for all events, returns an id which can be used to remove the handler
+func (chain *Blockchain) Add_Event_Handler(handler Event_Handler) (id int) {
+	chain.event_lock.Lock()
+	defer chain.event_lock.Unlock()
+
+	chain.event_handler_id++
+	if chain.event_handlers == nil {
+		chain.event_handlers = map[int]Event_Handler{}
+	}
+	chain.event_handlers[chain.event_handler_id] = handler
+	return chain.event_handler_id
+}
+
+func (chain *Blockchain) Remove_Event_Handler(id int) {
+	chain.event_lock.Lock()
+	defer chain.event_lock.Unlock()
+	delete(chain.event_handlers, id)
+}
+
+// deliver event to all handlers, a faulty handler cannot bring down the chain
+func (chain *Blockchain) notify_event(event Event) {
+	chain.event_lock.RLock()
+	defer chain.event_lock.RUnlock()
+
+	for id, handler := range chain.event_handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Warnf("Recovered while delivering event %s to handler %d", event.Type, id)
+					logger.Warnf("Stack trace  \n%s", debug.Stack())
+				}
+			}()
+			handler(event)
+		}()
+	}
+}
+
+// mempool notifies us, we notify everyone else
+func (chain *Blockchain) mempool_notifier(tx *transaction.Transaction, added bool) {
+	event := Event{Type: EVENT_MEMPOOL_DELETE, TX: tx}
+	if added {
+		event.Type = EVENT_MEMPOOL_ADD
+	}
+	chain.notify_event(event)
+}
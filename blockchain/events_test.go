@@ -0,0 +1,118 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+import "testing"
+import "encoding/hex"
+
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/transaction"
+
+func Test_Events(t *testing.T) {
+	chain, cleanup := start_test_chain(t)
+	defer cleanup()
+
+	var events []Event
+	id := chain.Add_Event_Handler(func(event Event) { events = append(events, event) })
+
+	mine_test_blocks(t, chain, 40)
+	if len(events) != 40 {
+		t.Fatalf("Expected 40 events, got %d", len(events))
+	}
+	for i := range events {
+		blid, _ := chain.Load_Block_Topological_order_at_index(nil, int64(i+1))
+		if events[i].Type != EVENT_NEW_BLOCK || events[i].BLID != blid || events[i].Topoheight != int64(i+1) || events[i].Height != int64(i+1) {
+			t.Fatalf("Wrong event %d %+v", i, events[i])
+		}
+	}
+
+	// rewinding changes topo order
+	events = events[:0]
+	old_topoheight := chain.Load_TOPO_HEIGHT(nil)
+	if !chain.Rewind_Chain(2) {
+		t.Fatalf("Rewind failed")
+	}
+	topoheight := chain.Load_TOPO_HEIGHT(nil)
+	if len(events) != 1 || events[0].Type != EVENT_TOPO_CHANGE || events[0].Changed != topoheight+1 || events[0].Topoheight != topoheight {
+		t.Fatalf("Wrong rewind events %+v topoheight %d", events, topoheight)
+	}
+	if int64(len(events[0].Blocks)) != old_topoheight-topoheight {
+		t.Fatalf("Rewind removed %d blocks, event contains %d", old_topoheight-topoheight, len(events[0].Blocks))
+	}
+	for i, b := range events[0].Blocks {
+		if b.Old_Topoheight != topoheight+1+int64(i) || b.New_Topoheight != -1 || chain.Is_Block_Topological_order(nil, b.BLID) {
+			t.Fatalf("Wrong rewind block change %+v", b)
+		}
+	}
+
+	events = events[:0]
+	var tx transaction.Transaction
+	tx_raw, _ := hex.DecodeString(test_tx_hex)
+	if err := tx.DeserializeHeader(tx_raw); err != nil {
+		t.Fatalf("Tx deserialisation failed err %s", err)
+	}
+	chain.Mempool.Mempool_Add_TX(&tx, 0)
+	chain.Mempool.Mempool_Delete_TX(tx.GetHash())
+	if len(events) != 2 || events[0].Type != EVENT_MEMPOOL_ADD || events[1].Type != EVENT_MEMPOOL_DELETE || events[1].TX.GetHash() != tx.GetHash() {
+		t.Fatalf("Wrong mempool events %+v", events)
+	}
+
+	// removed handlers are not called again
+	events = events[:0]
+	chain.Remove_Event_Handler(id)
+	mine_test_blocks(t, chain, 1)
+	if len(events) != 0 {
+		t.Fatalf("Removed handler was called %+v", events)
+	}
+}
+
+func Test_Topo_Changes(t *testing.T) {
+	chain, cleanup := start_test_chain(t)
+	defer cleanup()
+
+	mine_test_blocks(t, chain, 5)
+	topoheight := chain.Load_TOPO_HEIGHT(nil)
+	blid, _ := chain.Load_Block_Topological_order_at_index(nil, topoheight)
+	bl, _ := chain.Load_BL_FROM_ID(nil, blid)
+	moved, _ := chain.Load_Block_Topological_order_at_index(nil, topoheight-1)
+	moved_bl, _ := chain.Load_BL_FROM_ID(nil, moved)
+
+	// pretend a tx was valid in top block, and previous block was one position lower
+	txid := crypto.Hash{1}
+	states := map[crypto.Hash]topo_state{}
+	chain.record_topo_state(nil, states, bl, blid, topoheight)
+	states[blid] = topo_state{topoheight: topoheight, txs: []crypto.Hash{txid}, valid: []bool{true}}
+	chain.record_topo_state(nil, states, moved_bl, moved, topoheight-2)
+
+	blocks, txs, changed := chain.topo_changes(nil, states, map[crypto.Hash]int64{blid: topoheight, moved: topoheight - 1})
+	if changed != topoheight-2 {
+		t.Fatalf("Changed topoheight %d expected %d", changed, topoheight-2)
+	}
+	if len(blocks) != 1 || blocks[0].BLID != moved || blocks[0].Old_Topoheight != topoheight-2 || blocks[0].New_Topoheight != topoheight-1 {
+		t.Fatalf("Wrong block changes %+v", blocks)
+	}
+	if len(txs) != 1 || txs[0].TXID != txid || txs[0].BLID != blid || txs[0].Valid {
+		t.Fatalf("Wrong tx changes %+v", txs)
+	}
+
+	// nothing changed
+	states = map[crypto.Hash]topo_state{}
+	chain.record_topo_state(nil, states, bl, blid, topoheight)
+	if blocks, txs, changed := chain.topo_changes(nil, states, map[crypto.Hash]int64{blid: topoheight}); changed != -1 || len(blocks) != 0 || len(txs) != 0 {
+		t.Fatalf("Unexpected changes %+v %+v", blocks, txs)
+	}
+}
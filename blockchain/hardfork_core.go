@@ -51,6 +51,8 @@ var mainnet_hard_forks = []Hard_fork{
         {3, 721000, 0, 0, 0, true}, // version 3 hard fork emission fix, it's mandatory
         {4, 4550555, 0, 0, 0, true}, // version 4 hard fork AstroBWT CPU Mining enabled. It's mandatory
        	{5, config.HF5_HEIGHT, 0, 0, 0, true}, // version 5 hard fork where we keep the chain alive, so who could not swap earlier can swap later
+       	{6, config.HF6_HEIGHT, 0, 0, 0, true}, // version 6 hard fork aggregated bulletproofs, it's mandatory
+       	{7, config.TESTNET_HF7_HEIGHT, 0, 0, 0, true}, // version 7 hard fork CLSAG ring signatures, it's mandatory
 }
 
 // current testnet_hard_forks
@@ -59,6 +61,8 @@ var testnet_hard_forks = []Hard_fork{
 	{3, 0, 0, 0, 0, true}, // version 3 hard fork where we started , it's mandatory
   	{4, 3, 0, 0, 0, true}, // version 4 hard fork where we change mining algorithm it's mandatory
    	{5, 4, 0, 0, 0, true}, // version 5 hard fork where we keep the chain alive, so who could not swap earlier can swap later
+   	{6, config.TESTNET_HF6_HEIGHT, 0, 0, 0, true}, // version 6 hard fork aggregated bulletproofs, it's mandatory
+   	{7, config.HF7_HEIGHT, 0, 0, 0, true}, // version 7 hard fork CLSAG ring signatures, it's mandatory
 }
 
 // current simulation_hard_forks
@@ -0,0 +1,356 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+// this file implements an optional view key indexer
+// services running many view only wallets register spend public key and view secret key ( same as a view only wallet )
+// every block is scanned once as its outputs are indexed as per client protocol, and matching outputs are stored per account
+// transfers are numbered per account, so consumers fetch them incrementally
+// if a block is reordered, its transfers are updated in place with new topoheight and global index
+// transfers are never removed, if a tx is later marked invalid, consumers must follow topo_change events
+// transfers are only returned to callers proving knowledge of the view secret key
+
+import "fmt"
+import "bytes"
+import "sync/atomic"
+import "crypto/subtle"
+
+import "github.com/vmihailenco/msgpack"
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/globals"
+import "github.com/deroproject/derosuite/storage"
+import "github.com/deroproject/derosuite/crypto/ringct"
+
+// transfers, keyed by seq, solar bucket is the account key
+var GALAXY_INDEXER = []byte("GIX")
+
+// output ( txid + index within tx ) to seq mapping and transfer count, solar bucket is the account key
+var GALAXY_INDEXER_OUTPUTS = []byte("GIXO")
+
+// registered accounts, account key to view secret key, stored in GALAXY_KEYVALUE
+var PLANET_INDEXER_ACCOUNTS = []byte("INDEXER_ACCOUNTS")
+
+var PLANET_INDEXER_COUNT = []byte("COUNT")
+
+// blocks rescanned per db tx while registering an account
+const INDEXER_BLOCKS_PER_TX = 1000
+
+// maximum transfers returned in a single call
+const INDEXER_MAX_TRANSFERS = 1000
+
+// maximum accounts which can be registered
+var indexer_max_accounts = 1000
+
+// same as walletapi, used to decrypt 8 byte payment ids
+const ENCRYPTED_PAYMENT_ID_TAIL = 0x8d
+
+// an incoming transfer to a registered account
+type Indexed_Transfer struct {
+	Seq             uint64      `msgpack:"S"`
+	TXID            crypto.Hash `msgpack:"T"`
+	BLID            crypto.Hash `msgpack:"B"`
+	Height          uint64      `msgpack:"H"`
+	TopoHeight      int64       `msgpack:"TH"`
+	Block_Time      uint64      `msgpack:"BT"`
+	Index_within_tx uint64      `msgpack:"IT"`
+	Index_Global    uint64      `msgpack:"IG"`
+	Amount          uint64      `msgpack:"A"`
+	Unlock_Height   uint64      `msgpack:"U"`
+	PaymentID       []byte      `msgpack:"P,omitempty"` // decrypted if it was encrypted
+	Coinbase        bool        `msgpack:"C,omitempty"` // miner tx output
+}
+
+// keys of a registered account, these are the keys of a view only wallet
+type indexer_account struct {
+	key             []byte // spend public key + view public key
+	Spendkey_Public crypto.Key
+	Viewkey_Secret  crypto.Key
+}
+
+// key used to store account data, same for all addresses of an account
+func Indexer_Account_Key(spend_public, view_public crypto.Key) []byte {
+	return append(append([]byte{}, spend_public[:]...), view_public[:]...)
+}
+
+// is indexer enabled
+func (chain *Blockchain) Indexer_Enabled() bool {
+	return chain.indexer
+}
+
+// load registered accounts from DB
+func (chain *Blockchain) load_indexer_accounts() {
+	dbtx, err := chain.store.BeginTX(false)
+	if err != nil {
+		logger.Warnf("Could NOT load indexer accounts. Error opening TX, err %s", err)
+		return
+	}
+	defer dbtx.Rollback()
+
+	keys, values, _ := dbtx.LoadObjects(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, PLANET_INDEXER_ACCOUNTS)
+
+	chain.indexer_lock.Lock()
+	defer chain.indexer_lock.Unlock()
+	chain.indexer_accounts = chain.indexer_accounts[:0]
+	for i := range keys {
+		if len(keys[i]) != 64 || len(values[i]) != 32 {
+			continue
+		}
+		acc := &indexer_account{key: keys[i]}
+		copy(acc.Spendkey_Public[:], keys[i][:32])
+		copy(acc.Viewkey_Secret[:], values[i])
+		chain.indexer_accounts = append(chain.indexer_accounts, acc)
+	}
+	logger.Infof("Indexer enabled, %d accounts registered", len(chain.indexer_accounts))
+}
+
+// register an account and rescan its outputs from start_topoheight
+// registering an existing account only rescans, transfers already indexed are not duplicated
+// history is rescanned without the chain lock, so blocks are accepted meanwhile, only the tail is rescanned with the lock held
+func (chain *Blockchain) Indexer_Register(spend_public crypto.Key, view_secret crypto.Key, start_topoheight int64) (err error) {
+	if !chain.indexer {
+		return fmt.Errorf("Indexer is not enabled")
+	}
+	if !spend_public.Public_Key_Valid() {
+		return fmt.Errorf("Invalid spend public key")
+	}
+	if !view_secret.Private_Key_Valid() {
+		return fmt.Errorf("Invalid view secret key")
+	}
+	if start_topoheight < 0 {
+		start_topoheight = 0
+	}
+
+	acc := &indexer_account{Spendkey_Public: spend_public, Viewkey_Secret: view_secret}
+	acc.key = Indexer_Account_Key(spend_public, *view_secret.PublicKey())
+
+	if !atomic.CompareAndSwapInt32(&chain.indexer_rescan, 0, 1) {
+		return fmt.Errorf("Another registration is in progress, try again later")
+	}
+	defer atomic.StoreInt32(&chain.indexer_rescan, 0)
+
+	if !chain.indexer_registered(acc.key, nil) && len(chain.indexer_accounts_list()) >= indexer_max_accounts {
+		return fmt.Errorf("Indexer already has maximum %d accounts registered", indexer_max_accounts)
+	}
+
+	scanned, err := chain.indexer_rescan_account(acc, start_topoheight)
+	if err != nil {
+		return err
+	}
+
+	chain.Lock()
+	defer chain.Unlock()
+
+	// recent blocks may have been reordered while rescanning, so they are rescanned again along with new blocks
+	// chain lock is held, so no block can be missed
+	if scanned -= 2 * config.STABLE_LIMIT; scanned < start_topoheight {
+		scanned = start_topoheight
+	}
+	if _, err = chain.indexer_rescan_account(acc, scanned); err != nil {
+		return err
+	}
+
+	chain.indexer_lock.Lock()
+	defer chain.indexer_lock.Unlock()
+	for i := range chain.indexer_accounts {
+		if bytes.Equal(chain.indexer_accounts[i].key, acc.key) {
+			return nil
+		}
+	}
+
+	dbtx, err := chain.store.BeginTX(true)
+	if err != nil {
+		return err
+	}
+	dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, PLANET_INDEXER_ACCOUNTS, acc.key, view_secret[:])
+	if err = dbtx.Commit(); err != nil {
+		return err
+	}
+	chain.indexer_accounts = append(chain.indexer_accounts, acc)
+	return nil
+}
+
+// index outputs of an account from topoheight till the top, returns the topoheight after the last block scanned
+func (chain *Blockchain) indexer_rescan_account(acc *indexer_account, topoheight int64) (int64, error) {
+	for {
+		dbtx, err := chain.store.BeginTX(true)
+		if err != nil {
+			return topoheight, err
+		}
+
+		top_topoheight := chain.Load_TOPO_HEIGHT(dbtx)
+		for i := 0; i < INDEXER_BLOCKS_PER_TX && topoheight <= top_topoheight; i, topoheight = i+1, topoheight+1 {
+			blid, err := chain.Load_Block_Topological_order_at_index(dbtx, topoheight)
+			if err != nil {
+				dbtx.Rollback()
+				return topoheight, err
+			}
+			chain.index_block(dbtx, blid, []*indexer_account{acc})
+		}
+
+		if err = dbtx.Commit(); err != nil {
+			return topoheight, err
+		}
+		if topoheight > top_topoheight {
+			return topoheight, nil
+		}
+		logger.Infof("Indexer rescanned till topoheight %d", topoheight)
+	}
+}
+
+// is the account registered, if view_secret is not nil, it must match the registered view secret key
+func (chain *Blockchain) indexer_registered(key []byte, view_secret *crypto.Key) bool {
+	chain.indexer_lock.RLock()
+	defer chain.indexer_lock.RUnlock()
+	for i := range chain.indexer_accounts {
+		if bytes.Equal(chain.indexer_accounts[i].key, key) {
+			return view_secret == nil || subtle.ConstantTimeCompare(chain.indexer_accounts[i].Viewkey_Secret[:], view_secret[:]) == 1
+		}
+	}
+	return false
+}
+
+// returns transfers of an account with seq greater than after, atmost max transfers are returned
+// view secret key of the account is required, since transfers reveal amounts and payment ids
+func (chain *Blockchain) Indexer_Get_Transfers(spend_public crypto.Key, view_secret crypto.Key, after uint64, max int) (transfers []Indexed_Transfer, err error) {
+	if !chain.indexer {
+		return nil, fmt.Errorf("Indexer is not enabled")
+	}
+	if max <= 0 || max > INDEXER_MAX_TRANSFERS {
+		max = INDEXER_MAX_TRANSFERS
+	}
+
+	// wrong view secret and unregistered account are reported the same way
+	key := Indexer_Account_Key(spend_public, *view_secret.PublicKey())
+	if !view_secret.Private_Key_Valid() || !chain.indexer_registered(key, &view_secret) {
+		return nil, fmt.Errorf("Account is not registered")
+	}
+
+	dbtx, err := chain.store.BeginTX(false)
+	if err != nil {
+		return nil, err
+	}
+	defer dbtx.Rollback()
+
+	cursor, err := dbtx.Iterate(BLOCKCHAIN_UNIVERSE, GALAXY_INDEXER, key, itob(after+1), nil, false)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	for cursor.Next() && len(transfers) < max {
+		var transfer Indexed_Transfer
+		if err = msgpack.Unmarshal(cursor.Value(), &transfer); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+	return transfers, nil
+}
+
+// scan outputs of a block, block must already have its output index written
+// this is called for every block entering topo order, with the chain lock held
+func (chain *Blockchain) index_block(dbtx storage.DBTX, blid crypto.Hash, accounts []*indexer_account) {
+	if len(accounts) == 0 {
+		return
+	}
+
+	start, end := chain.Get_Block_Output_Index(dbtx, blid)
+	for index := start; index < end; index++ {
+		o, ok := chain.load_output_index(dbtx, uint64(index))
+		if !ok {
+			continue
+		}
+		for _, acc := range accounts {
+			if chain.indexer_output_ours(acc, &o) {
+				chain.index_output(dbtx, acc, &o)
+			}
+		}
+	}
+}
+
+// same as Is_Output_Ours of a view only wallet
+func (chain *Blockchain) indexer_output_ours(acc *indexer_account, o *globals.TX_Output_Data) bool {
+	derivation := crypto.KeyDerivation(&o.Tx_Public_Key, &acc.Viewkey_Secret)
+	derivation_public_key := derivation.KeyDerivation_To_PublicKey(o.Index_within_tx, acc.Spendkey_Public)
+	return derivation_public_key == crypto.Key(o.InKey.Destination)
+}
+
+// store an output as a transfer, if it is already indexed, it is updated in place
+func (chain *Blockchain) index_output(dbtx storage.DBTX, acc *indexer_account, o *globals.TX_Output_Data) {
+	derivation := crypto.KeyDerivation(&o.Tx_Public_Key, &acc.Viewkey_Secret)
+
+	transfer := Indexed_Transfer{TXID: o.TXID, BLID: o.BLID, Height: o.Height, TopoHeight: o.TopoHeight, Block_Time: o.Block_Time,
+		Index_within_tx: o.Index_within_tx, Index_Global: o.Index_Global, Unlock_Height: o.Unlock_Height}
+
+	switch o.SigType {
+	case 0: // miner tx, amount is not hidden
+		transfer.Amount = o.Amount
+		transfer.Coinbase = true
+	case 1, 2, 4, 5, 6: // ringct full/simple, simplebulletproof, aggregated bulletproof, clsag
+		scalar_key := derivation.KeyDerivationToScalar(o.Index_within_tx)
+		amount, _, ok := ringct.Decode_Amount(o.ECDHTuple, *scalar_key, crypto.Key(o.InKey.Mask))
+		if !ok {
+			logger.Warnf("Indexer could not decode amount of tx %s output %d", o.TXID, o.Index_within_tx)
+			return
+		}
+		transfer.Amount = amount
+	default:
+		return
+	}
+
+	switch len(o.PaymentID) {
+	case 8: // encrypted payment id
+		var tmp_buf [33]byte
+		copy(tmp_buf[:], derivation[:])
+		tmp_buf[32] = ENCRYPTED_PAYMENT_ID_TAIL
+		hash := crypto.Keccak256(tmp_buf[:])
+		transfer.PaymentID = make([]byte, 8, 8)
+		for i := range o.PaymentID {
+			transfer.PaymentID[i] = o.PaymentID[i] ^ hash[i]
+		}
+	case 32:
+		transfer.PaymentID = append([]byte{}, o.PaymentID...)
+	}
+
+	output_key := append(append([]byte{}, o.TXID[:]...), itob(o.Index_within_tx)...)
+	if seq, err := dbtx.LoadUint64(BLOCKCHAIN_UNIVERSE, GALAXY_INDEXER_OUTPUTS, acc.key, output_key); err == nil {
+		transfer.Seq = seq // already indexed, block was reordered or account rescanned
+	} else {
+		count, _ := dbtx.LoadUint64(BLOCKCHAIN_UNIVERSE, GALAXY_INDEXER_OUTPUTS, acc.key, PLANET_INDEXER_COUNT)
+		transfer.Seq = count + 1
+		dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_INDEXER_OUTPUTS, acc.key, PLANET_INDEXER_COUNT, transfer.Seq)
+		dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_INDEXER_OUTPUTS, acc.key, output_key, transfer.Seq)
+	}
+
+	serialized, err := msgpack.Marshal(&transfer)
+	if err != nil {
+		panic(err)
+	}
+	dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_INDEXER, acc.key, itob(transfer.Seq), serialized)
+}
+
+// accounts to scan new blocks for
+func (chain *Blockchain) indexer_accounts_list() []*indexer_account {
+	if !chain.indexer {
+		return nil
+	}
+	chain.indexer_lock.RLock()
+	defer chain.indexer_lock.RUnlock()
+	return append([]*indexer_account{}, chain.indexer_accounts...)
+}
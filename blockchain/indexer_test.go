@@ -0,0 +1,146 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+import "testing"
+
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/address"
+import "github.com/deroproject/derosuite/globals"
+
+// mines blocks rewarding the given keys
+func mine_test_blocks_to(t *testing.T, chain *Blockchain, spend_public, view_public crypto.Key, count int) {
+	miner_address := *address.NewAddressFromKeys(spend_public, view_public)
+	miner_address.Network = globals.Config.Public_Address_Prefix
+
+	for i := 0; i < count; i++ {
+		cbl, _ := chain.Create_new_miner_block(miner_address)
+		if err, ok := chain.Add_Complete_Block(cbl); !ok {
+			t.Fatalf("Cannot add block at height %d err %v", chain.Get_Height(), err)
+		}
+	}
+}
+
+func Test_Indexer(t *testing.T) {
+	chain, cleanup := start_test_chain(t)
+	defer cleanup()
+
+	_, spend_a := crypto.NewKeyPair()
+	view_secret_a, view_a := crypto.NewKeyPair()
+	_, spend_b := crypto.NewKeyPair()
+	view_secret_b, view_b := crypto.NewKeyPair()
+
+	if err := chain.Indexer_Register(*spend_a, *view_secret_a, 0); err == nil {
+		t.Fatalf("Registration must fail when indexer is disabled")
+	}
+	chain.indexer = true
+
+	var invalid_secret crypto.Key // not reduced modulo curve order
+	for i := range invalid_secret {
+		invalid_secret[i] = 0xff
+	}
+	if err := chain.Indexer_Register(*spend_a, invalid_secret, 0); err == nil {
+		t.Fatalf("Registration must fail with invalid view secret")
+	}
+	if err := chain.Indexer_Register(*spend_a, *view_secret_a, 0); err != nil {
+		t.Fatalf("Cannot register account err %s", err)
+	}
+	if transfers, err := chain.Indexer_Get_Transfers(*spend_a, *view_secret_a, 0, 0); err != nil || len(transfers) != 0 {
+		t.Fatalf("Fresh account has %d transfers err %v", len(transfers), err)
+	}
+
+	// new blocks are indexed as they arrive
+	mine_test_blocks_to(t, chain, *spend_a, *view_a, 5)
+	mine_test_blocks_to(t, chain, *spend_b, *view_b, 3)
+
+	transfers, err := chain.Indexer_Get_Transfers(*spend_a, *view_secret_a, 0, 0)
+	if err != nil || len(transfers) != 5 {
+		t.Fatalf("Expected 5 transfers, found %d err %v", len(transfers), err)
+	}
+	for i := range transfers {
+		if transfers[i].Seq != uint64(i+1) || transfers[i].TopoHeight != int64(i+1) || !transfers[i].Coinbase || transfers[i].Amount == 0 {
+			t.Fatalf("Wrong transfer %d %+v", i, transfers[i])
+		}
+		blid, _ := chain.Load_Block_Topological_order_at_index(nil, transfers[i].TopoHeight)
+		if blid != transfers[i].BLID {
+			t.Fatalf("Transfer %d block mismatch", i)
+		}
+	}
+
+	// incremental fetch
+	if transfers, _ = chain.Indexer_Get_Transfers(*spend_a, *view_secret_a, 3, 0); len(transfers) != 2 || transfers[0].Seq != 4 {
+		t.Fatalf("Incremental fetch returned %+v", transfers)
+	}
+	if transfers, _ = chain.Indexer_Get_Transfers(*spend_a, *view_secret_a, 0, 2); len(transfers) != 2 || transfers[1].Seq != 2 {
+		t.Fatalf("Limited fetch returned %+v", transfers)
+	}
+
+	// transfers are not served without the view secret key of the account
+	if _, err = chain.Indexer_Get_Transfers(*spend_a, *view_secret_b, 0, 0); err == nil {
+		t.Fatalf("Transfers must not be served with wrong view secret")
+	}
+
+	// unregistered account
+	if _, err = chain.Indexer_Get_Transfers(*spend_b, *view_secret_b, 0, 0); err == nil {
+		t.Fatalf("Unregistered account must not be served")
+	}
+
+	// account registered later is rescanned
+	if err = chain.Indexer_Register(*spend_b, *view_secret_b, 0); err != nil {
+		t.Fatalf("Cannot register account err %s", err)
+	}
+	if transfers, _ = chain.Indexer_Get_Transfers(*spend_b, *view_secret_b, 0, 0); len(transfers) != 3 || transfers[0].TopoHeight != 6 {
+		t.Fatalf("Rescan returned %+v", transfers)
+	}
+
+	// registering again does not duplicate transfers
+	if err = chain.Indexer_Register(*spend_a, *view_secret_a, 0); err != nil {
+		t.Fatalf("Cannot register account again err %s", err)
+	}
+	if transfers, _ = chain.Indexer_Get_Transfers(*spend_a, *view_secret_a, 0, 0); len(transfers) != 5 {
+		t.Fatalf("Rescan duplicated transfers, found %d", len(transfers))
+	}
+
+	// only one registration rescans at a time
+	chain.indexer_rescan = 1
+	if err = chain.Indexer_Register(*spend_a, *view_secret_a, 0); err == nil {
+		t.Fatalf("Concurrent registration must fail")
+	}
+	chain.indexer_rescan = 0
+
+	// new accounts are rejected once maximum accounts are registered, existing ones can still rescan
+	indexer_max_accounts = 2
+	defer func() { indexer_max_accounts = 1000 }()
+	view_secret_c, _ := crypto.NewKeyPair()
+	if err = chain.Indexer_Register(*spend_b, *view_secret_c, 0); err == nil {
+		t.Fatalf("Registration over maximum accounts must fail")
+	}
+	if err = chain.Indexer_Register(*spend_b, *view_secret_b, 0); err != nil {
+		t.Fatalf("Registered account cannot rescan at maximum accounts err %s", err)
+	}
+
+	// accounts survive restart
+	chain.indexer_accounts = nil
+	chain.load_indexer_accounts()
+	if len(chain.indexer_accounts) != 2 {
+		t.Fatalf("Expected 2 accounts after reload, found %d", len(chain.indexer_accounts))
+	}
+	mine_test_blocks_to(t, chain, *spend_b, *view_b, 1)
+	if transfers, _ = chain.Indexer_Get_Transfers(*spend_b, *view_secret_b, 3, 0); len(transfers) != 1 || transfers[0].Seq != 4 {
+		t.Fatalf("Block after reload not indexed %+v", transfers)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package mempool
+
+// this file implements fee estimation
+// for every tx mined, the number of blocks it waited in the pool is recorded in its fee per byte bucket
+// to estimate the fee for confirmation within N blocks, buckets are walked from highest fee downwards
+// the lowest fee range in which most txs ( including the ones still waiting ) got mined within N blocks is returned
+// old data decays with every block, so as estimates follow the congestion
+
+import "sync"
+
+import "github.com/deroproject/derosuite/config"
+
+// fee per byte of the lowest bucket, this is the minimum relay fee
+const FEE_ESTIMATE_MIN_FEE_PER_BYTE = config.FEE_PER_KB / 1024
+
+// every bucket is this much higher than the previous one
+const FEE_ESTIMATE_BUCKET_SPACING = 1.2
+
+// number of buckets, the highest bucket is roughly 1500 times the minimum fee
+const FEE_ESTIMATE_BUCKETS = 40
+
+// maximum confirmation target in blocks
+const FEE_ESTIMATE_MAX_BLOCKS = 48
+
+// data is multiplied by this every block
+const FEE_ESTIMATE_DECAY = 0.998
+
+// a fee range must have atleast these many txs, before it can be used for estimation
+const FEE_ESTIMATE_MIN_TXS = 8
+
+// this fraction of txs in a fee range must have been mined within target
+const FEE_ESTIMATE_SUCCESS = 0.85
+
+type fee_estimator struct {
+	buckets []uint64                                               // lower bound of fee per byte of every bucket
+	mined   [FEE_ESTIMATE_BUCKETS][FEE_ESTIMATE_MAX_BLOCKS]float64 // txs mined within 1.. blocks
+	total   [FEE_ESTIMATE_BUCKETS]float64                          // all txs mined
+	height  uint64                                                 // height till which data has been decayed
+	sync.Mutex
+}
+
+// bucket which contains this fee per byte
+func (e *fee_estimator) bucket(fee_per_byte uint64) int {
+	if e.buckets == nil {
+		fee := float64(FEE_ESTIMATE_MIN_FEE_PER_BYTE)
+		for i := 0; i < FEE_ESTIMATE_BUCKETS; i++ {
+			e.buckets = append(e.buckets, uint64(fee))
+			fee *= FEE_ESTIMATE_BUCKET_SPACING
+		}
+	}
+
+	for i := FEE_ESTIMATE_BUCKETS - 1; i > 0; i-- {
+		if fee_per_byte >= e.buckets[i] {
+			return i
+		}
+	}
+	return 0
+}
+
+// decay old data, once per block
+func (e *fee_estimator) new_height(height uint64) {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.height != 0 { // decay atmost 1000 blocks, older data is already negligible
+		for h := e.height; h < height && h < e.height+1000; h++ {
+			for i := range e.total {
+				e.total[i] *= FEE_ESTIMATE_DECAY
+				for j := range e.mined[i] {
+					e.mined[i][j] *= FEE_ESTIMATE_DECAY
+				}
+			}
+		}
+	}
+	if height > e.height {
+		e.height = height
+	}
+}
+
+// record a tx mined after waiting these many blocks
+func (e *fee_estimator) record(fee_per_byte uint64, blocks uint64) {
+	e.Lock()
+	defer e.Unlock()
+
+	if blocks < 1 {
+		blocks = 1
+	}
+
+	b := e.bucket(fee_per_byte)
+	e.total[b]++
+	for j := blocks - 1; j < FEE_ESTIMATE_MAX_BLOCKS; j++ {
+		e.mined[b][j]++
+	}
+}
+
+// estimate fee per byte to get mined within target blocks
+// waiting contains fee per byte of txs still in pool, which have already waited target blocks
+// these are counted as failures, so as a congested pool raises the estimate immediately
+func (e *fee_estimator) estimate(target uint64, waiting []uint64) (fee_per_byte uint64, estimated bool) {
+	e.Lock()
+	defer e.Unlock()
+
+	if target < 1 {
+		target = 1
+	}
+	if target > FEE_ESTIMATE_MAX_BLOCKS {
+		target = FEE_ESTIMATE_MAX_BLOCKS
+	}
+
+	var failed [FEE_ESTIMATE_BUCKETS]float64
+	for i := range waiting {
+		failed[e.bucket(waiting[i])]++
+	}
+
+	best := -1
+	mined, total := float64(0), float64(0)
+	for i := FEE_ESTIMATE_BUCKETS - 1; i >= 0; i-- {
+		mined += e.mined[i][target-1]
+		total += e.total[i] + failed[i]
+
+		if total < FEE_ESTIMATE_MIN_TXS {
+			continue
+		}
+		if mined/total < FEE_ESTIMATE_SUCCESS {
+			break
+		}
+		best = i // this range passed, continue with the next range
+		mined, total = 0, 0
+	}
+
+	if best < 0 {
+		return FEE_ESTIMATE_MIN_FEE_PER_BYTE, false
+	}
+	return e.buckets[best], true
+}
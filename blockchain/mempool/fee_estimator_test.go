@@ -0,0 +1,57 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package mempool
+
+import "testing"
+
+func Test_Fee_Estimator(t *testing.T) {
+	var e fee_estimator
+
+	if fee, estimated := e.estimate(1, nil); estimated || fee != FEE_ESTIMATE_MIN_FEE_PER_BYTE {
+		t.Fatalf("Empty estimator must return minimum fee")
+	}
+
+	high_fee := uint64(FEE_ESTIMATE_MIN_FEE_PER_BYTE * 10)
+	e.new_height(100)
+	for i := 0; i < 20; i++ {
+		e.record(high_fee, 1)
+		e.record(FEE_ESTIMATE_MIN_FEE_PER_BYTE, 10)
+	}
+
+	fee, estimated := e.estimate(1, nil)
+	if !estimated || fee > high_fee || fee <= high_fee*10/12 {
+		t.Fatalf("Next block estimate %d, expected bucket of %d", fee, high_fee)
+	}
+	if fee, estimated = e.estimate(10, nil); !estimated || fee != FEE_ESTIMATE_MIN_FEE_PER_BYTE {
+		t.Fatalf("10 blocks estimate %d, expected minimum fee", fee)
+	}
+
+	// txs stuck in pool count as failures
+	var waiting []uint64
+	for i := 0; i < 30; i++ {
+		waiting = append(waiting, FEE_ESTIMATE_MIN_FEE_PER_BYTE)
+	}
+	if fee, estimated = e.estimate(10, waiting); !estimated || fee != e.buckets[e.bucket(high_fee)] {
+		t.Fatalf("Congested 10 blocks estimate %d, expected bucket of %d", fee, high_fee)
+	}
+
+	// data decays, after long time there is not enough data
+	e.new_height(100 + 1000)
+	if _, estimated = e.estimate(1, nil); estimated {
+		t.Fatalf("Decayed data must not be used for estimation")
+	}
+}
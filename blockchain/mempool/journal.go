@@ -0,0 +1,328 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package mempool
+
+// this file implements crash safe mempool persistence
+// every tx added to or deleted from the pool is appended to a journal file in binary form
+// each record is length prefixed and checksummed, so a torn record at the tail ( crash during write ) is detected and discarded
+// the journal is compacted by rewriting the live pool to a temporary file and renaming it over the journal
+// txs restored from the journal are verified against the chain again before they are accepted
+
+import "io"
+import "os"
+import "fmt"
+import "sync"
+import "bytes"
+import "hash/crc32"
+import "path/filepath"
+import "encoding/binary"
+
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/transaction"
+
+const MEMPOOL_JOURNAL_FILE = "mempool.journal"
+
+// journal is compacted once it grows beyond twice the pool size plus this much
+const MEMPOOL_JOURNAL_COMPACT_BYTES = 8 * 1024 * 1024
+
+const journal_op_add = byte(1)    // op | added time | height | tx in binary form
+const journal_op_delete = byte(2) // op | txid
+
+const journal_header_size = 8 // body length and crc32 of body, both little endian uint32
+
+type journal struct {
+	path  string
+	file  *os.File
+	size  uint64 // bytes in journal
+	dirty bool   // data written since last sync
+	sync.Mutex
+}
+
+// open journal and replay it, returning objects still in pool in the order they were added
+// torn or corrupt records at the tail are truncated
+func open_journal(path string) (j *journal, objects []mempool_object, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	var order []crypto.Hash
+	live := map[crypto.Hash]*mempool_object{}
+
+	offset := 0
+	for offset+journal_header_size <= len(data) {
+		length := int(binary.LittleEndian.Uint32(data[offset:]))
+		checksum := binary.LittleEndian.Uint32(data[offset+4:])
+		if length < 1 || offset+journal_header_size+length > len(data) {
+			break
+		}
+		body := data[offset+journal_header_size : offset+journal_header_size+length]
+		if crc32.ChecksumIEEE(body) != checksum {
+			break
+		}
+		offset += journal_header_size + length
+
+		switch body[0] {
+		case journal_op_add:
+			var object mempool_object
+			if object.decode_journal(body[1:]) != nil {
+				loggerpool.Warnf("Skipping undecodable tx in mempool journal")
+				continue
+			}
+			txid := object.Tx.GetHash()
+			if _, ok := live[txid]; !ok {
+				order = append(order, txid)
+			}
+			live[txid] = &object
+		case journal_op_delete:
+			if len(body) == 1+32 {
+				var txid crypto.Hash
+				copy(txid[:], body[1:])
+				delete(live, txid)
+			}
+		}
+	}
+
+	if offset != len(data) {
+		loggerpool.Warnf("Mempool journal has %d bytes of torn or corrupt records, discarding them", len(data)-offset)
+	}
+
+	for _, txid := range order {
+		if object, ok := live[txid]; ok {
+			objects = append(objects, *object)
+			delete(live, txid) // txs added again after deletion appear only once
+		}
+	}
+
+	j = &journal{path: path}
+	if j.file, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600); err != nil {
+		return nil, nil, err
+	}
+	if err = j.file.Truncate(int64(offset)); err == nil {
+		_, err = j.file.Seek(int64(offset), io.SeekStart)
+	}
+	if err != nil {
+		j.file.Close()
+		return nil, nil, err
+	}
+	j.size = uint64(offset)
+	return
+}
+
+// binary form of object in journal
+func (obj *mempool_object) encode_journal(tx_bytes []byte) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	body := []byte{journal_op_add}
+	body = append(body, buf[:binary.PutUvarint(buf[:], obj.Added)]...)
+	body = append(body, buf[:binary.PutUvarint(buf[:], obj.Height)]...)
+	return append(body, tx_bytes...)
+}
+
+func (obj *mempool_object) decode_journal(data []byte) (err error) {
+	reader := bytes.NewReader(data)
+	if obj.Added, err = binary.ReadUvarint(reader); err != nil {
+		return
+	}
+	if obj.Height, err = binary.ReadUvarint(reader); err != nil {
+		return
+	}
+
+	tx_bytes := data[len(data)-reader.Len():]
+	obj.Tx = &transaction.Transaction{}
+	if err = obj.Tx.DeserializeHeader(tx_bytes); err != nil {
+		return
+	}
+	obj.Size = uint64(len(tx_bytes))
+	obj.FEEperBYTE = obj.Tx.RctSignature.Get_TX_Fee() / obj.Size
+	return
+}
+
+// append a record, records are written in a single call, so a crash can only tear the last record
+func write_journal_record(w io.Writer, body []byte) (n int, err error) {
+	record := make([]byte, journal_header_size, journal_header_size+len(body))
+	binary.LittleEndian.PutUint32(record, uint32(len(body)))
+	binary.LittleEndian.PutUint32(record[4:], crc32.ChecksumIEEE(body))
+	record = append(record, body...)
+	return w.Write(record)
+}
+
+func (j *journal) append(body []byte) {
+	if j == nil { // journal disabled
+		return
+	}
+	j.Lock()
+	defer j.Unlock()
+	if j.file == nil {
+		return
+	}
+	n, err := write_journal_record(j.file, body)
+	j.size += uint64(n)
+	j.dirty = true
+	if err != nil {
+		loggerpool.Warnf("Error writing mempool journal err %s", err)
+	}
+}
+
+func (j *journal) append_add(obj *mempool_object, tx_bytes []byte) {
+	if j != nil {
+		j.append(obj.encode_journal(tx_bytes))
+	}
+}
+
+func (j *journal) append_delete(txid crypto.Hash) {
+	if j != nil {
+		j.append(append([]byte{journal_op_delete}, txid[:]...))
+	}
+}
+
+// flush journal to disk, called periodically, so as power loss can lose only last few seconds
+func (j *journal) sync() {
+	if j == nil {
+		return
+	}
+	j.Lock()
+	defer j.Unlock()
+	if j.file != nil && j.dirty {
+		if err := j.file.Sync(); err != nil {
+			loggerpool.Warnf("Error syncing mempool journal err %s", err)
+		}
+		j.dirty = false
+	}
+}
+
+// rewrite journal with only the given objects
+// new journal is written completely and synced before it replaces the old one, so a crash leaves either of them intact
+func (j *journal) compact(objects []*mempool_object) (err error) {
+	if j == nil {
+		return
+	}
+	j.Lock()
+	defer j.Unlock()
+	if j.file == nil { // journal closed
+		return
+	}
+
+	tmp_path := j.path + ".tmp"
+	tmp, err := os.OpenFile(tmp_path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return
+	}
+
+	size := uint64(0)
+	for _, obj := range objects {
+		n, err := write_journal_record(tmp, obj.encode_journal(obj.Tx.Serialize()))
+		size += uint64(n)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return
+	}
+	if err = os.Rename(tmp_path, j.path); err != nil {
+		tmp.Close()
+		return
+	}
+	if dir, err := os.Open(filepath.Dir(j.path)); err == nil { // make the rename durable
+		dir.Sync()
+		dir.Close()
+	}
+
+	if j.file != nil {
+		j.file.Close()
+	}
+	j.file, j.size, j.dirty = tmp, size, false
+	return
+}
+
+func (j *journal) close() {
+	if j == nil {
+		return
+	}
+	j.Lock()
+	defer j.Unlock()
+	if j.file != nil {
+		j.file.Sync()
+		j.file.Close()
+		j.file = nil
+	}
+}
+
+// compact journal if it has grown too much, this function assummes lock is already taken
+func (pool *Mempool) compact_journal(force bool) error {
+	if pool.journal == nil {
+		return nil
+	}
+
+	pool.journal.Lock()
+	size := pool.journal.size
+	pool.journal.Unlock()
+	if !force && size <= 2*pool.Mempool_Stats().Bytes+MEMPOOL_JOURNAL_COMPACT_BYTES {
+		return nil
+	}
+
+	var objects []*mempool_object
+	pool.txs.Range(func(k, value interface{}) bool {
+		objects = append(objects, value.(*mempool_object))
+		return true
+	})
+	if err := pool.journal.compact(objects); err != nil {
+		return fmt.Errorf("Error compacting mempool journal err %s", err)
+	}
+	return nil
+}
+
+// restore txs persisted during previous run, every tx is verified again using add
+// add must verify the txs against the chain and add them to the pool, all txs are given at once so they can be batch verified
+func (pool *Mempool) Mempool_Restore(add func([]*transaction.Transaction) []bool) (restored, dropped int) {
+	pool.Lock()
+	objects := pool.restored
+	pool.restored = nil
+	pool.Unlock()
+
+	txs := make([]*transaction.Transaction, len(objects), len(objects))
+	for i := range objects {
+		txs[i] = objects[i].Tx
+	}
+	results := add(txs)
+
+	for i := range objects {
+		if !results[i] {
+			dropped++
+			continue
+		}
+		restored++
+		if objecti, ok := pool.txs.Load(objects[i].Tx.GetHash()); ok { // keep original time, so as expiry is not extended by restarts
+			objecti.(*mempool_object).Added = objects[i].Added
+		}
+	}
+
+	if pool.legacy_file != "" { // txs are now in journal
+		os.Remove(pool.legacy_file)
+		pool.legacy_file = ""
+	}
+
+	// dropped txs are still in journal and restored txs carry new time, rewrite it
+	pool.Lock()
+	if err := pool.compact_journal(len(objects) > 0); err != nil {
+		loggerpool.Warnf("%s", err)
+	}
+	pool.Unlock()
+	return
+}
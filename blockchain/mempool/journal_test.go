@@ -0,0 +1,88 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package mempool
+
+import "os"
+import "testing"
+import "path/filepath"
+
+import log "github.com/sirupsen/logrus"
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/globals"
+import "github.com/deroproject/derosuite/transaction"
+
+// pool survives a crash, torn records are discarded and restored txs are verified again
+func Test_Mempool_Journal(t *testing.T) {
+	globals.Logger = log.New()
+	globals.Logger.SetLevel(log.WarnLevel)
+
+	journal_file := filepath.Join(t.TempDir(), MEMPOOL_JOURNAL_FILE)
+	fee := uint64(config.FEE_PER_KB * 100)
+
+	pool, _ := Init_Mempool(nil)
+	var err error
+	if pool.journal, pool.restored, err = open_journal(journal_file); err != nil || len(pool.restored) != 0 {
+		t.Fatalf("Cannot open empty journal err %v", err)
+	}
+
+	var txs []*transaction.Transaction
+	for i := 1; i <= 4; i++ {
+		txs = append(txs, test_tx_unique(t, i, fee*uint64(i)))
+		if !pool.Mempool_Add_TX(txs[i-1], 0) {
+			t.Fatalf("Cannot add tx %d to pool", i)
+		}
+	}
+	pool.Mempool_Delete_TX(txs[1].GetHash())
+	pool.journal.sync()
+
+	// crash in the middle of writing a record
+	pool.journal.file.Write([]byte{0xff, 0, 0, 0, 1, 2, 3, 4, journal_op_add})
+	pool.journal.file.Close()
+
+	pool2, _ := Init_Mempool(nil)
+	if pool2.journal, pool2.restored, err = open_journal(journal_file); err != nil {
+		t.Fatalf("Cannot reopen journal err %v", err)
+	}
+	if len(pool2.restored) != 3 || pool2.restored[0].Tx.GetHash() != txs[0].GetHash() || pool2.restored[1].Tx.GetHash() != txs[2].GetHash() || pool2.restored[2].Tx.GetHash() != txs[3].GetHash() {
+		t.Fatalf("Wrong txs restored from journal %d", len(pool2.restored))
+	}
+	added := pool2.restored[0].Added
+
+	// tx 3 is no longer valid
+	restored, dropped := pool2.Mempool_Restore(func(restored_txs []*transaction.Transaction) (results []bool) {
+		for _, tx := range restored_txs {
+			results = append(results, tx.GetHash() != txs[2].GetHash() && pool2.Mempool_Add_TX(tx, 0))
+		}
+		return
+	})
+	if restored != 2 || dropped != 1 || pool2.Mempool_TX_Exist(txs[2].GetHash()) || !pool2.Mempool_TX_Exist(txs[3].GetHash()) {
+		t.Fatalf("Restore failed restored %d dropped %d", restored, dropped)
+	}
+	if objecti, ok := pool2.txs.Load(txs[0].GetHash()); !ok || objecti.(*mempool_object).Added != added {
+		t.Fatalf("Restored tx lost its time")
+	}
+
+	// restore compacts journal to the verified txs
+	pool2.journal.close()
+	if _, objects, err := open_journal(journal_file); err != nil || len(objects) != 2 || objects[0].Added != added {
+		t.Fatalf("Journal not compacted after restore err %v", err)
+	}
+	if _, err := os.Stat(journal_file + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("Temporary journal left behind")
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package mempool
+
+// this file implements pool size limits
+// once the pool is full, txs paying lowest fee per byte are evicted to make room for better paying txs
+// every eviction raises the minimum relay fee above the evicted fee, so the same txs cannot be relayed back again
+// the minimum relay fee decays back every block, once the pool has drained below half its limits
+
+import "sort"
+import "sync/atomic"
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/crypto"
+
+// default limits, these can be changed using --mempool-max-bytes and --mempool-max-txs
+const MEMPOOL_MAX_BYTES = uint64(64 * 1024 * 1024)
+const MEMPOOL_MAX_TXS = uint64(10000)
+
+// minimum relay fee is raised this much above the highest evicted fee per byte, 10% of minimum fee
+const MEMPOOL_FEE_INCREMENT_PER_BYTE = FEE_ESTIMATE_MIN_FEE_PER_BYTE / 10
+
+// pool statistics, reported in get_info and metrics
+type Stats struct {
+	TXs            uint64 // txs in pool
+	Bytes          uint64 // size of all txs in pool
+	Max_TXs        uint64
+	Max_Bytes      uint64
+	Min_Fee_Per_KB uint64 // minimum fee per KB required to enter the pool
+	Evicted        uint64 // txs evicted since start
+}
+
+// return pool limits
+func (pool *Mempool) limits() (max_bytes, max_txs uint64) {
+	if max_bytes = pool.Max_Bytes; max_bytes == 0 {
+		max_bytes = MEMPOOL_MAX_BYTES
+	}
+	if max_txs = pool.Max_TXs; max_txs == 0 {
+		max_txs = MEMPOOL_MAX_TXS
+	}
+	return
+}
+
+// minimum fee per KB, a new tx must pay to enter the pool
+func (pool *Mempool) Mempool_Min_Fee_Per_KB() uint64 {
+	if fee_per_kb := atomic.LoadUint64(&pool.min_fee_per_byte) * 1024; fee_per_kb > config.FEE_PER_KB {
+		return fee_per_kb
+	}
+	return config.FEE_PER_KB
+}
+
+// minimum fee a tx of this size pays to be relayed, a replacement must pay this on top of the fees it replaces
+// otherwise same key images can be replaced and relayed again and again at almost no cost
+func (pool *Mempool) Mempool_Min_Relay_Fee(size uint64) uint64 {
+	return pool.Mempool_Min_Fee_Per_KB() * size / 1024
+}
+
+// whether a tx paying these fees can enter the pool
+func (pool *Mempool) Mempool_Fee_Sufficient(fee, size uint64) bool {
+	return size > 0 && fee/size >= atomic.LoadUint64(&pool.min_fee_per_byte)
+}
+
+// pool statistics
+func (pool *Mempool) Mempool_Stats() (stats Stats) {
+	stats.TXs = atomic.LoadUint64(&pool.count)
+	stats.Bytes = atomic.LoadUint64(&pool.bytes)
+	stats.Max_Bytes, stats.Max_TXs = pool.limits()
+	stats.Min_Fee_Per_KB = pool.Mempool_Min_Fee_Per_KB()
+	stats.Evicted = atomic.LoadUint64(&pool.evicted)
+	return
+}
+
+// find txs to evict, so as a tx of given size and fee per byte fits in the pool
+// txs being replaced are already accounted, alt-chain txs held for reorganisation are never evicted
+// if room cannot be made by evicting only lower paying txs, ok is false
+// this function assummes lock is already taken
+func (pool *Mempool) select_evictions(size, fee_per_byte uint64, replaced map[crypto.Hash]bool) (evict []crypto.Hash, ok bool) {
+	max_bytes, max_txs := pool.limits()
+
+	count := atomic.LoadUint64(&pool.count) + 1
+	bytes := atomic.LoadUint64(&pool.bytes) + size
+	for txid := range replaced {
+		if objecti, ok := pool.txs.Load(txid); ok {
+			count--
+			bytes -= objecti.(*mempool_object).Size
+		}
+	}
+
+	if count <= max_txs && bytes <= max_bytes { // fits without evicting anything
+		return nil, true
+	}
+
+	var candidates []TX_Sorting_struct
+	pool.txs.Range(func(k, value interface{}) bool {
+		txhash := k.(crypto.Hash)
+		v := value.(*mempool_object)
+		if v.Height == 0 && !replaced[txhash] {
+			candidates = append(candidates, TX_Sorting_struct{Hash: txhash, FeesPerByte: v.FEEperBYTE, Size: v.Size})
+		}
+		return true
+	})
+
+	// lowest paying txs first
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].FeesPerByte < candidates[j].FeesPerByte })
+
+	for i := range candidates {
+		if count <= max_txs && bytes <= max_bytes {
+			break
+		}
+		if candidates[i].FeesPerByte >= fee_per_byte { // new tx does not pay more than remaining txs
+			return nil, false
+		}
+		evict = append(evict, candidates[i].Hash)
+		count--
+		bytes -= candidates[i].Size
+	}
+
+	if count <= max_txs && bytes <= max_bytes {
+		return evict, true
+	}
+	return nil, false
+}
+
+// raise minimum relay fee above the fee of an evicted tx
+func (pool *Mempool) raise_min_fee(evicted_fee_per_byte uint64) {
+	if min := evicted_fee_per_byte + MEMPOOL_FEE_INCREMENT_PER_BYTE; min > atomic.LoadUint64(&pool.min_fee_per_byte) {
+		atomic.StoreUint64(&pool.min_fee_per_byte, min)
+	}
+}
+
+// called every block, minimum relay fee halves if pool has drained below half its limits
+func (pool *Mempool) decay_min_fee() {
+	max_bytes, max_txs := pool.limits()
+	if atomic.LoadUint64(&pool.count) > max_txs/2 || atomic.LoadUint64(&pool.bytes) > max_bytes/2 {
+		return
+	}
+
+	min := atomic.LoadUint64(&pool.min_fee_per_byte) / 2
+	if min < FEE_ESTIMATE_MIN_FEE_PER_BYTE { // below consensus minimum, it's meaningless
+		min = 0
+	}
+	atomic.StoreUint64(&pool.min_fee_per_byte, min)
+}
@@ -0,0 +1,133 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package mempool
+
+import "testing"
+import "encoding/hex"
+
+import log "github.com/sirupsen/logrus"
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/globals"
+import "github.com/deroproject/derosuite/transaction"
+
+// build a tx with given fee, spending key images unique to n
+func test_tx_unique(t *testing.T, n int, fee uint64) *transaction.Transaction {
+	var tx transaction.Transaction
+	tx_raw, _ := hex.DecodeString(test_tx_hex)
+	if err := tx.DeserializeHeader(tx_raw); err != nil {
+		t.Fatalf("Tx Deserialisation failed")
+	}
+
+	new_tx := test_tx_with_fee(t, &tx, fee)
+	for i := range new_tx.Vin {
+		input := new_tx.Vin[i].(transaction.Txin_to_key)
+		input.K_image[0], input.K_image[1], input.K_image[2] = byte(n), byte(n>>8), byte(i)
+		new_tx.Vin[i] = input
+	}
+	return new_tx
+}
+
+// full pool evicts lowest fee per byte txs and raises minimum relay fee
+func Test_Mempool_Limits(t *testing.T) {
+	globals.Logger = log.New()
+	globals.Logger.SetLevel(log.WarnLevel)
+
+	pool, _ := Init_Mempool(nil)
+	pool.Max_TXs = 4
+
+	fee := uint64(config.FEE_PER_KB * 100)
+	var txs []*transaction.Transaction
+	var bytes uint64
+	for i := 1; i <= 4; i++ {
+		txs = append(txs, test_tx_unique(t, i, fee*uint64(i)))
+		if !pool.Mempool_Add_TX(txs[i-1], 0) {
+			t.Fatalf("Cannot add tx %d to pool", i)
+		}
+		bytes += uint64(len(txs[i-1].Serialize()))
+	}
+
+	stats := pool.Mempool_Stats()
+	if stats.TXs != 4 || stats.Bytes != bytes || stats.Evicted != 0 || stats.Min_Fee_Per_KB != config.FEE_PER_KB {
+		t.Fatalf("Wrong pool stats %+v", stats)
+	}
+
+	// lower paying tx cannot enter full pool
+	if pool.Mempool_Add_TX(test_tx_unique(t, 5, fee/2), 0) {
+		t.Fatalf("Full pool accepted lower paying tx")
+	}
+
+	// higher paying tx evicts the lowest paying one
+	if !pool.Mempool_Add_TX(test_tx_unique(t, 6, fee*3/2), 0) {
+		t.Fatalf("Full pool rejected higher paying tx")
+	}
+	if pool.Mempool_TX_Exist(txs[0].GetHash()) || !pool.Mempool_TX_Exist(txs[1].GetHash()) || pool.Mempool_Keyimage_Spent(txs[0].Vin[0].(transaction.Txin_to_key).K_image) {
+		t.Fatalf("Lowest paying tx must be evicted")
+	}
+
+	stats = pool.Mempool_Stats()
+	if stats.TXs != 4 || stats.Evicted != 1 || stats.Min_Fee_Per_KB <= config.FEE_PER_KB {
+		t.Fatalf("Wrong pool stats after eviction %+v", stats)
+	}
+
+	// evicted tx cannot come back, even when there is room
+	pool.Mempool_Delete_TX(txs[3].GetHash())
+	if pool.Mempool_Add_TX(txs[0], 0) {
+		t.Fatalf("Tx below minimum relay fee accepted")
+	}
+
+	// but txs pushed back during reorganisation are always accepted
+	if !pool.Mempool_Add_TX(txs[0], 1) || pool.Mempool_Stats().TXs != 4 {
+		t.Fatalf("Reorganisation tx rejected")
+	}
+
+	// minimum relay fee decays only once pool has drained below half
+	min_fee := pool.Mempool_Min_Fee_Per_KB()
+	pool.decay_min_fee()
+	if pool.Mempool_Min_Fee_Per_KB() != min_fee {
+		t.Fatalf("Minimum fee decayed while pool is full")
+	}
+	pool.Mempool_flush()
+	if stats = pool.Mempool_Stats(); stats.TXs != 0 || stats.Bytes != 0 {
+		t.Fatalf("Wrong pool stats after flush %+v", stats)
+	}
+	for i := 0; i < 64 && pool.Mempool_Min_Fee_Per_KB() != config.FEE_PER_KB; i++ {
+		pool.decay_min_fee()
+	}
+	if pool.Mempool_Min_Fee_Per_KB() != config.FEE_PER_KB || !pool.Mempool_Add_TX(txs[0], 0) {
+		t.Fatalf("Minimum fee did not decay")
+	}
+}
+
+// byte limit is enforced the same way
+func Test_Mempool_Byte_Limit(t *testing.T) {
+	globals.Logger = log.New()
+	globals.Logger.SetLevel(log.WarnLevel)
+
+	fee := uint64(config.FEE_PER_KB * 100)
+	low, high := test_tx_unique(t, 1, fee), test_tx_unique(t, 2, fee*2)
+
+	pool, _ := Init_Mempool(nil)
+	pool.Max_Bytes = uint64(len(low.Serialize())) + uint64(len(high.Serialize())) - 1
+
+	if !pool.Mempool_Add_TX(low, 0) || !pool.Mempool_Add_TX(high, 0) {
+		t.Fatalf("Pool rejected tx within limits")
+	}
+	if pool.Mempool_TX_Exist(low.GetHash()) || !pool.Mempool_TX_Exist(high.GetHash()) || pool.Mempool_Stats().Evicted != 1 {
+		t.Fatalf("Lowest paying tx must be evicted once bytes limit is reached")
+	}
+}
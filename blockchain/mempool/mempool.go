@@ -29,6 +29,7 @@ import "encoding/json"
 import "github.com/romana/rlog"
 import log "github.com/sirupsen/logrus"
 
+import "github.com/deroproject/derosuite/config"
 import "github.com/deroproject/derosuite/transaction"
 import "github.com/deroproject/derosuite/globals"
 import "github.com/deroproject/derosuite/crypto"
@@ -48,17 +49,32 @@ type TX_Sorting_struct struct {
 // at this point in time, this is an ultrafast written mempool,
 // it will not scale for more than 10000 transactions  but is good enough for now
 // we can always come back and rewrite it
-// NOTE: the pool is now persistant
+// NOTE: the pool is now persistant, see journal.go
+// NOTE: the pool is now bounded, see limits.go
 type Mempool struct {
+	count            uint64 // txs in pool, 64 bit atomics must be first for 32 bit systems
+	bytes            uint64 // size of all txs in pool
+	evicted          uint64 // txs evicted since start
+	min_fee_per_byte uint64 // dynamic minimum relay fee, raised while pool is full
+
+	Max_Bytes uint64 // pool size limit, 0 means MEMPOOL_MAX_BYTES
+	Max_TXs   uint64 // pool tx count limit, 0 means MEMPOOL_MAX_TXS
+
 	txs           sync.Map //map[crypto.Hash]*mempool_object
 	key_images    sync.Map //map[crypto.Hash]bool // contains key images of all txs
 	sorted_by_fee []crypto.Hash        // contains txids sorted by fees
 	sorted        []TX_Sorting_struct  // contains TX sorting information, so as new block can be forged easily
 	modified      bool                 // used to monitor whethel mem pool contents have changed,
 	height        uint64               // track blockchain height
+	estimator     fee_estimator        // tracks how long txs wait before getting mined
+	journal       *journal             // persists pool, nil if pool is not persisted
+	restored      []mempool_object     // txs loaded from disk, waiting to be verified again
+	legacy_file   string               // json pool file from older versions, removed once restored
 
 	P2P_TX_Relayer p2p_TX_Relayer // actual pointer, setup by the dero daemon during runtime
 
+	TX_Notifier tx_Notifier // called whenever a tx is added or removed, setup by blockchain during runtime
+
 	// global variable , but don't see it utilisation here except fot tx verification
 	//chain *Blockchain
 	Exit_Mutex chan bool
@@ -75,6 +91,7 @@ type mempool_object struct {
 	RelayedAt  int64  // when was tx last relayed
 	Size       uint64 // size in bytes of the TX
 	FEEperBYTE uint64 // fee per byte
+	Added_Height uint64 // chain height when tx was added, 0 if unknown
 }
 
 var loggerpool *log.Entry
@@ -140,35 +157,31 @@ func Init_Mempool(params map[string]interface{}) (*Mempool, error) {
 
 	mempool.Exit_Mutex = make(chan bool)
 
+	if max_bytes, ok := params["--mempool-max-bytes"].(uint64); ok {
+		mempool.Max_Bytes = max_bytes
+	}
+	if max_txs, ok := params["--mempool-max-txs"].(uint64); ok {
+		mempool.Max_TXs = max_txs
+	}
+	max_bytes, max_txs := mempool.limits()
+	loggerpool.Infof("Mempool limits %d bytes %d txs", max_bytes, max_txs)
+
 	// initialize maps
 	//mempool.txs = map[crypto.Hash]*mempool_object{}
 	//mempool.key_images = map[crypto.Hash]bool{}
 
-	//TODO load any trasactions saved at previous exit
+	// load any trasactions saved at previous exit, they are added to pool by Mempool_Restore after verification
+	// pool is not persisted for in memory chains and temporary pools
+	if params != nil && params["--memdb"] != true && globals.Arguments["--memdb"] != true {
+		journal_file := filepath.Join(globals.GetDataDirectory(), MEMPOOL_JOURNAL_FILE)
 
-	mempool_file := filepath.Join(globals.GetDataDirectory(), "mempool.json")
-
-	file, err := os.Open(mempool_file)
-	if err != nil {
-		loggerpool.Warnf("Error opening mempool data file %s err %s", mempool_file, err)
-	} else {
-		defer file.Close()
-
-		var objects []mempool_object
-		decoder := json.NewDecoder(file)
-		err = decoder.Decode(&objects)
-		if err != nil {
-			loggerpool.Warnf("Error unmarshalling mempool data err %s", err)
-		} else { // successfully unmarshalled data, add it to mempool
-			loggerpool.Debugf("Will try to load %d txs from mempool file", (len(objects)))
-			for i := range objects {
-				result := mempool.Mempool_Add_TX(objects[i].Tx, 0)
-				if result { // setup time
-					//mempool.txs[objects[i].Tx.GetHash()] = &objects[i] // setup time and other artifacts
-					mempool.txs.Store(objects[i].Tx.GetHash(),&objects[i] )
-				}
-			}
+		var err error
+		if mempool.journal, mempool.restored, err = open_journal(journal_file); err != nil {
+			loggerpool.Warnf("Error opening mempool journal %s err %s, pool will not be persisted", journal_file, err)
 		}
+
+		mempool.load_legacy_file(filepath.Join(globals.GetDataDirectory(), "mempool.json"))
+		loggerpool.Debugf("Will try to restore %d txs from disk", len(mempool.restored))
 	}
 
 	go mempool.Relayer_and_Cleaner()
@@ -190,6 +203,8 @@ func Init_Block_Mempool(params map[string]interface{}) (*Mempool, error) {
 
 func (pool *Mempool) HouseKeeping(height uint64, Verifier func(*transaction.Transaction) bool) {
 	pool.height = height
+	pool.estimator.new_height(height)
+	pool.decay_min_fee()
 
 	// this code is executed in rare conditions which are as follows
 	// chain has a tx which has spent most recent input possible (10 block)
@@ -238,47 +253,39 @@ func (pool *Mempool) HouseKeeping(height uint64, Verifier func(*transaction.Tran
 	}
 }
 
-func (pool *Mempool) Shutdown() {
-	//TODO save mempool tx somewhere
+// load pool saved as json by older versions
+func (pool *Mempool) load_legacy_file(mempool_file string) {
+	file, err := os.Open(mempool_file)
+	if err != nil {
+		return
+	}
+	defer file.Close()
 
+	var objects []mempool_object
+	decoder := json.NewDecoder(file)
+	if err = decoder.Decode(&objects); err != nil {
+		loggerpool.Warnf("Error unmarshalling mempool data err %s", err)
+		return
+	}
+	pool.restored = append(pool.restored, objects...)
+	pool.legacy_file = mempool_file
+}
+
+func (pool *Mempool) Shutdown() {
 	close(pool.Exit_Mutex) // stop relaying
 
 	pool.Lock()
 	defer pool.Unlock()
 
-	mempool_file := filepath.Join(globals.GetDataDirectory(), "mempool.json")
-
-	// collect all txs in pool and serialize them and store them
-	var objects []mempool_object
-
-
-	pool.txs.Range(func(k, value interface{}) bool {
-		v := value.(*mempool_object)
-		objects = append(objects, *v)
-		return true
-	})
-
-	/*for _, v := range pool.txs {
-		objects = append(objects, *v)
-	}*/
-
-	var file, err = os.Create(mempool_file)
-	if err == nil {
-		defer file.Close()
-		encoder := json.NewEncoder(file)
-		encoder.SetIndent("", "\t")
-		err = encoder.Encode(objects)
-
-		if err != nil {
-			loggerpool.Warnf("Error marshaling mempool data err %s", err)
+	// journal is always up to date, compact it so as next start is fast
+	if pool.journal != nil {
+		if err := pool.compact_journal(true); err != nil {
+			loggerpool.Warnf("%s", err)
 		}
-
-	} else {
-		loggerpool.Warnf("Error creating new file to store mempool data file %s err %s", mempool_file, err)
+		pool.journal.close()
+		loggerpool.Infof("Succesfully saved %d txs to file", pool.Mempool_Stats().TXs)
 	}
 
-	loggerpool.Infof("Succesfully saved %d txs to file", (len(objects)))
-
 	loggerpool.Infof("Mempool stopped")
 	atomic.AddUint32(&globals.Subsystem_Active, ^uint32(0)) // this decrement 1 fom subsystem
 
@@ -316,31 +323,88 @@ func (pool *Mempool) Mempool_Add_TX(tx *transaction.Transaction, Height uint64)
 		return false
 	}
 
+	tx_bytes := tx.Serialize()
+	object.Size = uint64(len(tx_bytes))
+	object.FEEperBYTE = tx.RctSignature.Get_TX_Fee() / object.Size
+
+	// new txs must pay atleast the dynamic minimum relay fee, txs pushed back during reorganisation are always accepted
+	if Height == 0 && !pool.Mempool_Fee_Sufficient(tx.RctSignature.Get_TX_Fee(), object.Size) {
+		rlog.Warnf("TX %s rejected, fee per byte %d below pool minimum fee per KB %d", tx_hash, object.FEEperBYTE, pool.Mempool_Min_Fee_Per_KB())
+		return false
+	}
+
 	// we should also extract all key images and add them to have multiple pending
+	// a tx spending key images of txs already in pool replaces them, if it pays strictly higher fee per byte
+	// and its fee covers fees of all replaced txs plus the relay fee of itself
+	// this allows stuck txs to be resent with higher fees
+	replaced := map[crypto.Hash]bool{}
+	replaced_fees := uint64(0)
 	for i := 0; i < len(tx.Vin); i++ {
-		if _, ok := pool.key_images.Load(tx.Vin[i].(transaction.Txin_to_key).K_image); ok {
+		if txidi, ok := pool.key_images.Load(tx.Vin[i].(transaction.Txin_to_key).K_image); ok {
+			conflict := txidi.(crypto.Hash)
+			if objecti, ok := pool.txs.Load(conflict); ok && object.FEEperBYTE > objecti.(*mempool_object).FEEperBYTE {
+				if !replaced[conflict] {
+					replaced[conflict] = true
+					replaced_fees += objecti.(*mempool_object).Tx.RctSignature.Get_TX_Fee()
+				}
+				continue
+			}
 			rlog.Warnf("TX using inputs  which have already been used, Possible Double spend attack rejected txid %s kimage %s",  tx_hash,
 				 tx.Vin[i].(transaction.Txin_to_key).K_image)
 			return false
 		}
 	}
+	if len(replaced) > 0 && tx.RctSignature.Get_TX_Fee() < replaced_fees+pool.Mempool_Min_Relay_Fee(object.Size) {
+		rlog.Warnf("TX %s rejected, fee %d does not cover replaced fees %d plus relay fee %d", tx_hash, tx.RctSignature.Get_TX_Fee(), replaced_fees, pool.Mempool_Min_Relay_Fee(object.Size))
+		return false
+	}
+
+	// if pool is full, make room by evicting lower paying txs
+	var evict []crypto.Hash
+	if Height == 0 {
+		var ok bool
+		if evict, ok = pool.select_evictions(object.Size, object.FEEperBYTE, replaced); !ok {
+			rlog.Warnf("TX %s rejected, pool is full and fee per byte %d is not higher than txs in pool", tx_hash, object.FEEperBYTE)
+			return false
+		}
+	}
+
+	for txid := range replaced {
+		rlog.Infof("TX %s replaced by %s paying higher fee per byte %d", txid, tx_hash, object.FEEperBYTE)
+		pool.Mempool_Delete_TX(txid)
+	}
+
+	for i := range evict {
+		if objecti, ok := pool.txs.Load(evict[i]); ok {
+			fee_per_byte := objecti.(*mempool_object).FEEperBYTE
+			rlog.Infof("TX %s evicted from full pool by %s, fee per byte %d", evict[i], tx_hash, fee_per_byte)
+			pool.Mempool_Delete_TX(evict[i])
+			pool.raise_min_fee(fee_per_byte)
+			atomic.AddUint64(&pool.evicted, 1)
+		}
+	}
 
 	// add all the key images to check double spend attack within the pool
 	for i := 0; i < len(tx.Vin); i++ {
-		pool.key_images.Store(tx.Vin[i].(transaction.Txin_to_key).K_image,true) // add element to map for next check
+		pool.key_images.Store(tx.Vin[i].(transaction.Txin_to_key).K_image,tx_hash) // add element to map for next check
 	}
 
 	// we are here means we can add it to pool
 	object.Tx = tx
 	object.Height = Height
 	object.Added = uint64(time.Now().UTC().Unix())
-
-	object.Size = uint64(len(tx.Serialize()))
-	object.FEEperBYTE = tx.RctSignature.Get_TX_Fee() / object.Size
+	object.Added_Height = pool.height
 
 	pool.txs.Store(tx_hash,&object)
+	atomic.AddUint64(&pool.count, 1)
+	atomic.AddUint64(&pool.bytes, object.Size)
+	pool.journal.append_add(&object, tx_bytes)
 	pool.modified = true // pool has been modified
 
+	if pool.TX_Notifier != nil {
+		pool.TX_Notifier(tx, true)
+	}
+
 	//pool.sort_list() // sort and update pool list
 
 	return true
@@ -378,16 +442,17 @@ func (pool *Mempool) Mempool_Delete_TX(txid crypto.Hash) (tx *transaction.Transa
 	var objecti interface{}
 
 	// check if tx already exists, skip it
-	if objecti, ok = pool.txs.Load(txid); !ok {
+	// load and delete is single step, so as counters are updated only once even if tx is deleted concurrently
+	if objecti, ok = pool.txs.LoadAndDelete(txid); !ok {
 		rlog.Warnf("Pool does NOT contain %s, returning nil", txid)
 		return nil
 	}
 
-
-
 	// we reached here means, we have the tx remove it from our list, do maintainance cleapup and discard it
 	object := objecti.(*mempool_object)
-	pool.txs.Delete(txid)
+	atomic.AddUint64(&pool.count, ^uint64(0))
+	atomic.AddUint64(&pool.bytes, ^uint64(object.Size-1))
+	pool.journal.append_delete(txid)
 
 	// remove all the key images
 	for i := 0; i < len(object.Tx.Vin); i++ {
@@ -396,7 +461,43 @@ func (pool *Mempool) Mempool_Delete_TX(txid crypto.Hash) (tx *transaction.Transa
 
 	//pool.sort_list()     // sort and update pool list
 	pool.modified = true // pool has been modified
-	return object.Tx     // return the tx
+
+	if pool.TX_Notifier != nil {
+		pool.TX_Notifier(object.Tx, false)
+	}
+	return object.Tx // return the tx
+}
+
+// delete a tx which has been mined at specific height, the wait is recorded for fee estimation
+func (pool *Mempool) Mempool_Delete_Mined_TX(txid crypto.Hash, height uint64) (tx *transaction.Transaction) {
+	if objecti, ok := pool.txs.Load(txid); ok {
+		object := objecti.(*mempool_object)
+		// skip txs whose wait is not known and txs which were pushed back during reorganisation
+		if object.Added_Height != 0 && object.Height == 0 && height > object.Added_Height {
+			pool.estimator.record(object.FEEperBYTE, height-object.Added_Height)
+		}
+	}
+	return pool.Mempool_Delete_TX(txid)
+}
+
+// estimate fee per KB required to get mined within specified blocks
+// if there is not enough data, minimum fee is returned with estimated set to false
+func (pool *Mempool) Mempool_Estimate_Fee(blocks uint64) (fee_per_kb uint64, estimated bool) {
+	var waiting []uint64 // txs which have already waited longer than target
+
+	pool.txs.Range(func(k, value interface{}) bool {
+		v := value.(*mempool_object)
+		if v.Added_Height != 0 && v.Height == 0 && pool.height >= v.Added_Height+blocks {
+			waiting = append(waiting, v.FEEperBYTE)
+		}
+		return true
+	})
+
+	fee_per_byte, estimated := pool.estimator.estimate(blocks, waiting)
+	if fee_per_kb = fee_per_byte * 1024; fee_per_kb < config.FEE_PER_KB { // lowest bucket is rounded down
+		fee_per_kb = config.FEE_PER_KB
+	}
+	return
 }
 
 // get specific tx from mem pool without removing it
@@ -480,6 +581,9 @@ func (pool *Mempool) Mempool_Print() {
 
 
 	fmt.Printf("Total TX in mempool = %d\n", len(klist))
+	stats := pool.Mempool_Stats()
+	fmt.Printf("Pool size %d/%d bytes %d/%d txs, minimum fee per KB %s, evicted %d\n", stats.Bytes, stats.Max_Bytes, stats.TXs, stats.Max_TXs,
+		globals.FormatMoney12(stats.Min_Fee_Per_KB), stats.Evicted)
 	fmt.Printf("%20s  %14s %7s %7s %6s %32s\n", "Added", "Last Relayed", "Relayed", "Size", "Height", "TXID")
 
 	for i := range klist {
@@ -545,6 +649,8 @@ func (pool *Mempool) sort_list() ([]crypto.Hash, []TX_Sorting_struct){
 
 type p2p_TX_Relayer func(*transaction.Transaction, uint64) int // function type, exported in p2p but cannot use due to cyclic dependency
 
+type tx_Notifier func(tx *transaction.Transaction, added bool) // added is false, when tx is removed from pool
+
 // this tx relayer keeps on relaying tx and cleaning mempool
 // if a tx has been relayed less than 10 peers, tx relaying is agressive
 // otherwise the tx are relayed every 30 minutes, till it has been relayed to 20
@@ -611,5 +717,18 @@ func (pool *Mempool) Relayer_and_Cleaner() {
 
 		// loggerpool.Warnf("send Pool lock released")
 		//pool.Unlock()
+
+		select { // journal is closed during shutdown
+		case <-pool.Exit_Mutex:
+			return
+		default:
+		}
+
+		pool.journal.sync()
+		pool.Lock()
+		if err := pool.compact_journal(false); err != nil {
+			loggerpool.Warnf("%s", err)
+		}
+		pool.Unlock()
 	}
 }
@@ -0,0 +1,304 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+// this file copies the complete chain from one storage backend to another
+// badger concatenates bucket names, so buckets cannot be discovered generically
+// instead the buckets are derived from the chain itself, every block and tx is a solar system
+// each bucket is streamed in key order, and verified using a checksum after it has been copied
+// progress is stored in the destination within the same tx, so an interrupted migration resumes
+
+import "fmt"
+import "encoding/binary"
+
+import "github.com/ebfe/keccak"
+
+import "github.com/deroproject/derosuite/block"
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/globals"
+import "github.com/deroproject/derosuite/storage"
+
+// stores the progress of an ongoing migration, only exists in destination
+var MIGRATION = []byte("MIGRATION")
+
+// limits the size of single tx, badger rejects too large txs
+var migrate_chunk_keys = 1000
+var migrate_chunk_bytes = 4 * 1024 * 1024
+
+// a bucket in universe BLOCKCHAIN_UNIVERSE
+type migrate_bucket struct {
+	galaxy []byte
+	solar  []byte
+}
+
+// buckets which do not depend on blocks/txs
+var migrate_static_buckets = []migrate_bucket{
+	{GALAXY_KEYVALUE, TOP_HEIGHT},
+	{GALAXY_KEYVALUE, TOPO_HEIGHT},
+	{GALAXY_KEYVALUE, TIPS},
+	{GALAXY_KEYVALUE, PRUNE_HEIGHT},
+	{GALAXY_KEYVALUE, PLANET_INDEXER_ACCOUNTS},
+	{GALAXY_TOPOLOGICAL_ORDER, GALAXY_TOPOLOGICAL_ORDER},
+	{GALAXY_TOPOLOGICAL_INDEX, GALAXY_TOPOLOGICAL_INDEX},
+	{GALAXY_HEIGHT, PLANET_HEIGHT},
+	{GALAXY_TRANSACTION_VALIDITY, GALAXY_TRANSACTION_VALIDITY},
+	{GALAXY_KEYIMAGE, GALAXY_KEYIMAGE},
+	{GALAXY_OUTPUT_INDEX, GALAXY_OUTPUT_INDEX},
+}
+
+// migrate the chain from the backend selected on command line to the named backend
+// derod must not be running on the same data directory
+func Migrate_Chain(params map[string]interface{}, target string) (err error) {
+	src := select_store(params)
+
+	var dst storage.Store
+	switch target {
+	case "boltdb":
+		dst = storage.Bolt_backend
+	case "badgerdb":
+		dst = storage.Badger_backend
+	default:
+		return fmt.Errorf("Unknown backend \"%s\", valid backends are boltdb and badgerdb", target)
+	}
+
+	if src == dst {
+		return fmt.Errorf("Chain is already stored in %s", target)
+	}
+
+	if err = src.Init(params); err != nil {
+		return
+	}
+	defer src.Shutdown()
+	if err = dst.Init(params); err != nil {
+		return
+	}
+	defer dst.Shutdown()
+
+	return Migrate_Store(src, dst)
+}
+
+// copies every bucket used by the chain from src to dst
+func Migrate_Store(src storage.Store, dst storage.Store) (err error) {
+	buckets, err := migrate_buckets(src)
+	if err != nil {
+		return
+	}
+
+	bucket_index, next_key, err := migrate_load_progress(dst)
+	if err != nil {
+		return
+	}
+	if bucket_index == 0 && next_key == nil {
+		globals.Logger.Infof("Migrating %d buckets", len(buckets))
+	} else {
+		globals.Logger.Infof("Resuming migration at bucket %d/%d", bucket_index, len(buckets))
+	}
+
+	for bucket_index < uint64(len(buckets)) {
+		if bucket_index, next_key, err = migrate_chunk(src, dst, buckets, bucket_index, next_key); err != nil {
+			return
+		}
+		globals.Logger.Infof("Migrated %d/%d buckets", bucket_index, len(buckets))
+	}
+
+	// clear progress, so the destination looks like any other chain
+	dbtx, err := dst.BeginTX(true)
+	if err != nil {
+		return
+	}
+	if err = dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, MIGRATION, MIGRATION, []byte{}); err != nil {
+		dbtx.Rollback()
+		return
+	}
+	if err = dbtx.Commit(); err != nil {
+		return
+	}
+
+	globals.Logger.Infof("Migration completed successfully, %d buckets verified", len(buckets))
+	return nil
+}
+
+// copy as many buckets as fit within a tx, starting at bucket_index/next_key
+// returns where the next chunk must start
+func migrate_chunk(src storage.Store, dst storage.Store, buckets []migrate_bucket, bucket_index uint64, next_key []byte) (uint64, []byte, error) {
+	src_tx, err := src.BeginTX(false)
+	if err != nil {
+		return bucket_index, next_key, err
+	}
+	defer src_tx.Rollback()
+
+	dst_tx, err := dst.BeginTX(true)
+	if err != nil {
+		return bucket_index, next_key, err
+	}
+
+	keys, size := 0, 0
+	for bucket_index < uint64(len(buckets)) && keys < migrate_chunk_keys && size < migrate_chunk_bytes {
+		b := buckets[bucket_index]
+
+		completed := true
+		cursor, err := src_tx.Iterate(BLOCKCHAIN_UNIVERSE, b.galaxy, b.solar, next_key, nil, false) // missing buckets are empty
+		if err != nil {
+			dst_tx.Rollback()
+			return bucket_index, next_key, err
+		}
+		for cursor.Next() {
+			if keys >= migrate_chunk_keys || size >= migrate_chunk_bytes {
+				next_key = cursor.Key()
+				completed = false
+				break
+			}
+			if err = dst_tx.StoreObject(BLOCKCHAIN_UNIVERSE, b.galaxy, b.solar, cursor.Key(), cursor.Value()); err != nil {
+				cursor.Close()
+				dst_tx.Rollback()
+				return bucket_index, next_key, err
+			}
+			keys++
+			size += len(cursor.Key()) + len(cursor.Value())
+		}
+		cursor.Close()
+
+		if !completed {
+			break
+		}
+
+		// bucket is complete, both sides must match before we move ahead
+		src_checksum, src_count := migrate_checksum(src_tx, b)
+		dst_checksum, dst_count := migrate_checksum(dst_tx, b)
+		if src_checksum != dst_checksum || src_count != dst_count {
+			dst_tx.Rollback()
+			return bucket_index, next_key, fmt.Errorf("Checksum mismatch for bucket %s/%x, source %s (%d keys) destination %s (%d keys)", b.galaxy, b.solar, src_checksum, src_count, dst_checksum, dst_count)
+		}
+
+		bucket_index++
+		next_key = nil
+	}
+
+	if err = migrate_store_progress(dst_tx, bucket_index, next_key); err != nil {
+		dst_tx.Rollback()
+		return bucket_index, next_key, err
+	}
+	return bucket_index, next_key, dst_tx.Commit()
+}
+
+// checksum all keys/values of a bucket in key order
+func migrate_checksum(dbtx storage.DBTX, b migrate_bucket) (checksum crypto.Hash, count uint64) {
+	h := keccak.New256()
+	cursor, err := dbtx.Iterate(BLOCKCHAIN_UNIVERSE, b.galaxy, b.solar, nil, nil, false)
+	if err == nil {
+		var buf [binary.MaxVarintLen64]byte
+		for cursor.Next() {
+			h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(cursor.Key())))])
+			h.Write(cursor.Key())
+			h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(cursor.Value())))])
+			h.Write(cursor.Value())
+			count++
+		}
+		cursor.Close()
+	}
+	copy(checksum[:], h.Sum(nil))
+	return
+}
+
+// progress is bucket index followed by the next key to copy within that bucket
+func migrate_store_progress(dbtx storage.DBTX, bucket_index uint64, next_key []byte) error {
+	progress := make([]byte, 8, 8+len(next_key))
+	binary.BigEndian.PutUint64(progress, bucket_index)
+	progress = append(progress, next_key...)
+	return dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, MIGRATION, MIGRATION, progress)
+}
+
+func migrate_load_progress(dst storage.Store) (bucket_index uint64, next_key []byte, err error) {
+	dbtx, err := dst.BeginTX(false)
+	if err != nil {
+		return
+	}
+	defer dbtx.Rollback()
+
+	progress, err := dbtx.LoadObject(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, MIGRATION, MIGRATION)
+	if err != nil || len(progress) == 0 { // no migration is in progress, destination must be empty
+		if topo, err := dbtx.LoadUint64(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, TOPO_HEIGHT, TOPO_HEIGHT); err == nil {
+			return 0, nil, fmt.Errorf("Destination already contains a chain at topoheight %d, refusing to overwrite", topo)
+		}
+		return 0, nil, nil
+	}
+
+	if len(progress) < 8 {
+		return 0, nil, fmt.Errorf("Corrupted migration progress %x", progress)
+	}
+	bucket_index = binary.BigEndian.Uint64(progress)
+	if len(progress) > 8 {
+		next_key = progress[8:]
+	}
+	return
+}
+
+// list every bucket, blocks are discovered through heights and txs through blocks
+// the order is deterministic as long as the source is not modified, which is required for resuming
+func migrate_buckets(src storage.Store) (buckets []migrate_bucket, err error) {
+	dbtx, err := src.BeginTX(false)
+	if err != nil {
+		return
+	}
+	defer dbtx.Rollback()
+
+	buckets = append(buckets, migrate_static_buckets...)
+
+	// every account registered with the indexer has its transfers and output seqs in its own buckets
+	accounts, err := dbtx.Iterate(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, PLANET_INDEXER_ACCOUNTS, nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	for accounts.Next() {
+		buckets = append(buckets, migrate_bucket{GALAXY_INDEXER, accounts.Key()}, migrate_bucket{GALAXY_INDEXER_OUTPUTS, accounts.Key()})
+	}
+	accounts.Close()
+
+	cursor, err := dbtx.Iterate(BLOCKCHAIN_UNIVERSE, GALAXY_HEIGHT, PLANET_HEIGHT, nil, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("Source does not contain a chain err %s", err)
+	}
+	defer cursor.Close()
+
+	tx_seen := map[crypto.Hash]bool{}
+	var tx_buckets []migrate_bucket
+	for cursor.Next() {
+		blocks_at_height := cursor.Value()
+		for i := 0; i+32 <= len(blocks_at_height); i += 32 {
+			blid := append([]byte{}, blocks_at_height[i:i+32]...)
+			buckets = append(buckets, migrate_bucket{GALAXY_BLOCK, blid})
+
+			block_data, err := dbtx.LoadObject(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid, PLANET_BLOB)
+			if err != nil || len(block_data) == 0 { // block has been rewound
+				continue
+			}
+			var bl block.Block
+			if err = bl.Deserialize(block_data); err != nil {
+				return nil, fmt.Errorf("Cannot deserialize block %x err %s", blid, err)
+			}
+			// miner tx is stored as a tx of its own, just like the txs of the block
+			for _, txid := range append([]crypto.Hash{bl.Miner_TX.GetHash()}, bl.Tx_hashes...) {
+				if !tx_seen[txid] {
+					tx_seen[txid] = true
+					tx_buckets = append(tx_buckets, migrate_bucket{GALAXY_TRANSACTION, append([]byte{}, txid[:]...)})
+				}
+			}
+		}
+	}
+
+	return append(buckets, tx_buckets...), nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+import "fmt"
+import "testing"
+
+import log "github.com/sirupsen/logrus"
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/globals"
+import "github.com/deroproject/derosuite/storage"
+
+// fails commits after a few successful ones, simulates an interrupted migration
+type failing_store struct {
+	storage.Store
+	commits int
+}
+
+type failing_tx struct {
+	storage.DBTX
+	store *failing_store
+}
+
+func (f *failing_store) BeginTX(writable bool) (storage.DBTX, error) {
+	dbtx, err := f.Store.BeginTX(writable)
+	if err != nil {
+		return nil, err
+	}
+	return &failing_tx{DBTX: dbtx, store: f}, nil
+}
+
+// records every bucket written, so the buckets expected after migration do not depend on migrate_buckets
+type recording_tx struct {
+	storage.DBTX
+	buckets map[string]migrate_bucket
+}
+
+func (r *recording_tx) StoreObject(universe []byte, galaxy []byte, solar []byte, key []byte, data []byte) error {
+	r.buckets[string(galaxy)+"/"+string(solar)] = migrate_bucket{galaxy, solar}
+	return r.DBTX.StoreObject(universe, galaxy, solar, key, data)
+}
+
+func (r *recording_tx) StoreUint64(universe []byte, galaxy []byte, solar []byte, key []byte, data uint64) error {
+	r.buckets[string(galaxy)+"/"+string(solar)] = migrate_bucket{galaxy, solar}
+	return r.DBTX.StoreUint64(universe, galaxy, solar, key, data)
+}
+
+func (f *failing_tx) Commit() error {
+	if f.store.commits <= 0 {
+		f.DBTX.Rollback()
+		return fmt.Errorf("simulated crash")
+	}
+	f.store.commits--
+	return f.DBTX.Commit()
+}
+
+// creates a small chain with a single block containing a tx, and a large output index
+// returns every bucket written
+func migrate_test_source(t *testing.T) (storage.Store, map[string]migrate_bucket) {
+	src := &storage.MemStore{}
+	src.Init(nil)
+
+	bl := Generate_Genesis_Block()
+	txid := crypto.Keccak256([]byte("tx"))
+	bl.Tx_hashes = append(bl.Tx_hashes, txid)
+	blid := bl.GetHash()
+	miner_txid := bl.Miner_TX.GetHash()
+
+	src_tx, _ := src.BeginTX(true)
+	dbtx := &recording_tx{DBTX: src_tx, buckets: map[string]migrate_bucket{}}
+	dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], PLANET_BLOB, bl.Serialize())
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], PLANET_HEIGHT, 0)
+	dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_HEIGHT, PLANET_HEIGHT, itob(0), blid[:])
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, TOPO_HEIGHT, TOPO_HEIGHT, 0)
+	dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, TIPS, TIPS, blid[:])
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, PRUNE_HEIGHT, PRUNE_HEIGHT, 1)
+	account := append(txid[:], blid[:]...)
+	dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, PLANET_INDEXER_ACCOUNTS, account, txid[:])
+	dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_INDEXER, account, itob(1), []byte("transfer"))
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_INDEXER_OUTPUTS, account, PLANET_INDEXER_COUNT, 1)
+	dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_TRANSACTION, txid[:], PLANET_TX_BLOB, []byte("tx data"))
+	dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_TRANSACTION, miner_txid[:], PLANET_TX_BLOB, bl.Miner_TX.Serialize())
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_TRANSACTION, miner_txid[:], PLANET_TX_MINED_IN_BLOCK, 0)
+	for i := uint64(0); i < 2500; i++ { // spans multiple chunks
+		output := crypto.Keccak256(itob(i))
+		dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_OUTPUT_INDEX, GALAXY_OUTPUT_INDEX, itob(i), output[:])
+	}
+	if err := dbtx.Commit(); err != nil {
+		t.Fatalf("Cannot create source chain err %s", err)
+	}
+	return src, dbtx.buckets
+}
+
+func Test_Migrate_Store(t *testing.T) {
+	globals.Logger = log.New()
+	globals.Config = config.Testnet
+
+	src, written := migrate_test_source(t)
+	dst := &storage.MemStore{}
+	dst.Init(nil)
+
+	if err := Migrate_Store(src, &failing_store{Store: dst, commits: 2}); err == nil {
+		t.Fatalf("Interrupted migration must fail")
+	}
+
+	if bucket_index, next_key, err := migrate_load_progress(dst); err != nil || (bucket_index == 0 && next_key == nil) {
+		t.Fatalf("Progress not stored for interrupted migration bucket %d key %x err %v", bucket_index, next_key, err)
+	}
+
+	if err := Migrate_Store(src, dst); err != nil {
+		t.Fatalf("Resumed migration failed err %s", err)
+	}
+
+	src_tx, _ := src.BeginTX(false)
+	defer src_tx.Rollback()
+	dst_tx, _ := dst.BeginTX(false)
+	defer dst_tx.Rollback()
+	for _, b := range written {
+		src_checksum, src_count := migrate_checksum(src_tx, b)
+		dst_checksum, dst_count := migrate_checksum(dst_tx, b)
+		if src_checksum != dst_checksum || src_count != dst_count {
+			t.Errorf("Bucket %s/%x differs after migration", b.galaxy, b.solar)
+		}
+	}
+
+	// miner tx has its own bucket, which must be migrated too
+	bl := Generate_Genesis_Block()
+	if tx, err := (&Blockchain{}).Load_TX_FROM_ID(dst_tx, bl.Miner_TX.GetHash()); err != nil || tx.GetHash() != bl.Miner_TX.GetHash() {
+		t.Errorf("Miner tx not migrated err %v", err)
+	}
+
+	// a pruned chain must continue pruning where it stopped
+	if prune_height, err := dst_tx.LoadUint64(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, PRUNE_HEIGHT, PRUNE_HEIGHT); err != nil || prune_height != 1 {
+		t.Errorf("Prune height not migrated, got %d err %v", prune_height, err)
+	}
+
+	// indexer accounts and their transfers must survive a migration
+	accounts, _, err := dst_tx.LoadObjects(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, PLANET_INDEXER_ACCOUNTS)
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("Indexer accounts not migrated, got %d err %v", len(accounts), err)
+	}
+	if count, err := dst_tx.LoadUint64(BLOCKCHAIN_UNIVERSE, GALAXY_INDEXER_OUTPUTS, accounts[0], PLANET_INDEXER_COUNT); err != nil || count != 1 {
+		t.Errorf("Indexer seq counter not migrated, got %d err %v", count, err)
+	}
+	if transfer, err := dst_tx.LoadObject(BLOCKCHAIN_UNIVERSE, GALAXY_INDEXER, accounts[0], itob(1)); err != nil || string(transfer) != "transfer" {
+		t.Errorf("Indexed transfer not migrated, got %x err %v", transfer, err)
+	}
+
+	// a completed migration must not be repeated over an existing chain
+	if err := Migrate_Store(src, dst); err == nil {
+		t.Errorf("Migration into existing chain must fail")
+	}
+}
@@ -50,7 +50,7 @@ import "github.com/deroproject/derosuite/transaction"
 // so we do a bruterforce till the reward is obtained but lets try to KISS
 // the top hash over which to do mining now ( it should already be in the chain)
 // this is work in progress
-// TODO we need to rework fees algorithm, to improve its quality and lower fees
+// txs are selected so as fees are maximized, see block_template.go
 func (chain *Blockchain) Create_new_miner_block(miner_address address.Address) (cbl *block.Complete_Block, bl block.Block) {
 	//chain.Lock()
 	//defer chain.Unlock()
@@ -95,92 +95,50 @@ func (chain *Blockchain) Create_new_miner_block(miner_address address.Address) (
 	}
 	height := chain.Calculate_Height_At_Tips(dbtx, bl.Tips) // we are 1 higher than previous highest tip
 
-	sizeoftxs := uint64(0) // size of all non coinbase tx included within this block
-	//fees_collected := uint64(0)
-
 	rlog.Infof("Total tx in pool %d", len(tx_hash_list_sorted))
 
 	reachable_key_images := chain.BuildReachabilityKeyImages(dbtx, &bl) // this requires only bl.Tips
 
-	// select 10%  tx based on fees
-	// select 90%  tx randomly
-	// random selection helps us to easily reach 80 TPS
-	i := 0
-	for ; i < len(tx_hash_list_sorted); i++ {
-
-		if (sizeoftxs + tx_hash_list_sorted[i].Size) > (10*config.CRYPTONOTE_MAX_BLOCK_SIZE)/100 { // limit block to max possible
-			break
-		}
-
+	// collect txs which can be mined on top of these tips, selection is done in block_template.go
+	var candidates []template_tx
+	var candidate_txs []*transaction.Transaction
+	for i := range tx_hash_list_sorted {
 		tx := chain.Mempool.Mempool_Get_TX(tx_hash_list_sorted[i].Hash)
-		if tx != nil {
-			// skip and delete any mempool tx
-			if chain.Verify_Transaction_NonCoinbase_DoubleSpend_Check(dbtx, tx) == false {
-				chain.Mempool.Mempool_Delete_TX(tx_hash_list_sorted[i].Hash)
-				continue
-			}
-
-			failed := false
-			for j := 0; j < len(tx.Vin); j++ {
-				if _, ok := reachable_key_images[tx.Vin[j].(transaction.Txin_to_key).K_image]; ok {
-					rlog.Warnf("TX already in history, but tx %s  is still in mempool HOW ?? skipping it", tx_hash_list_sorted[i].Hash)
-					failed = true
-					break
-				}
-			}
-			if failed {
-				continue
-			}
-
-			rlog.Tracef(1,"Adding Top  Sorted tx %s to Complete_Block current size %.2f KB max possible %.2f KB\n", tx_hash_list_sorted[i].Hash, float32(sizeoftxs+tx_hash_list_sorted[i].Size)/1024.0, float32(config.CRYPTONOTE_MAX_BLOCK_SIZE)/1024.0)
-			sizeoftxs += tx_hash_list_sorted[i].Size
-			cbl.Txs = append(cbl.Txs, tx)
-			tx_hash_list_included = append(tx_hash_list_included, tx_hash_list_sorted[i].Hash)
+		if tx == nil {
+			continue
 		}
-	}
-	// any left over transactions, should be randomly selected
-	tx_hash_list_sorted = tx_hash_list_sorted[i:]
 
-	// do random shuffling, can we get away with len/2 random shuffling
-	rand.Shuffle(len(tx_hash_list_sorted), func(i, j int) {
-		tx_hash_list_sorted[i], tx_hash_list_sorted[j] = tx_hash_list_sorted[j], tx_hash_list_sorted[i]
-	})
-
-	// if we were crossing limit, transactions would be randomly selected
-	// otherwise they will sorted by fees
-
-	// now select as much as possible
-	for i := range tx_hash_list_sorted {
-		if (sizeoftxs + tx_hash_list_sorted[i].Size) > (config.CRYPTONOTE_MAX_BLOCK_SIZE) { // limit block to max possible
-			break
+		// skip and delete any mempool tx
+		if chain.Verify_Transaction_NonCoinbase_DoubleSpend_Check(dbtx, tx) == false {
+			chain.Mempool.Mempool_Delete_TX(tx_hash_list_sorted[i].Hash)
+			continue
 		}
 
-		tx := chain.Mempool.Mempool_Get_TX(tx_hash_list_sorted[i].Hash)
-		if tx != nil {
-
-			// skip and delete any mempool tx
-			if chain.Verify_Transaction_NonCoinbase_DoubleSpend_Check(dbtx, tx) == false {
-				chain.Mempool.Mempool_Delete_TX(tx_hash_list_sorted[i].Hash)
-				continue
+		candidate := template_tx{Hash: tx_hash_list_sorted[i].Hash, Size: tx_hash_list_sorted[i].Size, Fee: tx.RctSignature.Get_TX_Fee()}
+		failed := false
+		for j := 0; j < len(tx.Vin); j++ {
+			if _, ok := reachable_key_images[tx.Vin[j].(transaction.Txin_to_key).K_image]; ok {
+				rlog.Warnf("TX already in history, but tx %s  is still in mempool HOW ?? skipping it", tx_hash_list_sorted[i].Hash)
+				failed = true
+				break
 			}
-			failed := false
-			for j := 0; j < len(tx.Vin); j++ {
-				if _, ok := reachable_key_images[tx.Vin[j].(transaction.Txin_to_key).K_image]; ok {
-					rlog.Warnf("TX already in history, but tx %s  is still in mempool HOW ?? skipping it", tx_hash_list_sorted[i].Hash)
-					failed = true
-					break
-				}
-			}
-			if failed {
-				continue
-			}
-
-			rlog.Tracef(1,"Adding Random tx %s to Complete_Block current size %.2f KB max possible %.2f KB\n", tx_hash_list_sorted[i].Hash, float32(sizeoftxs+tx_hash_list_sorted[i].Size)/1024.0, float32(config.CRYPTONOTE_MAX_BLOCK_SIZE)/1024.0)
-			sizeoftxs += tx_hash_list_sorted[i].Size
-			cbl.Txs = append(cbl.Txs, tx)
-			tx_hash_list_included = append(tx_hash_list_included, tx_hash_list_sorted[i].Hash)
+			candidate.Key_Images = append(candidate.Key_Images, tx.Vin[j].(transaction.Txin_to_key).K_image)
 		}
+		if failed {
+			continue
+		}
+		candidates = append(candidates, candidate)
+		candidate_txs = append(candidate_txs, tx)
+	}
+
+	// block reward does not depend on block size since atlantis, so no reward curve is needed
+	selected, fees_collected, sizeoftxs := select_template_txs(candidates, config.CRYPTONOTE_MAX_BLOCK_SIZE, nil)
+	for _, i := range selected {
+		rlog.Tracef(1, "Adding tx %s to Complete_Block size %d fee %d\n", candidates[i].Hash, candidates[i].Size, candidates[i].Fee)
+		cbl.Txs = append(cbl.Txs, candidate_txs[i])
+		tx_hash_list_included = append(tx_hash_list_included, candidates[i].Hash)
 	}
+	rlog.Infof("Selected %d txs size %.2f KB fees %d", len(selected), float32(sizeoftxs)/1024.0, fees_collected)
 
 	// collect tx list + their fees
 
@@ -0,0 +1,133 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+import "sync"
+import "time"
+import "runtime"
+import "runtime/debug"
+
+import "github.com/deroproject/derosuite/block"
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/checkpoints"
+import "github.com/deroproject/derosuite/transaction"
+
+// blocks received during sync are preverified in parallel, before they reach Add_Complete_Block
+// only the checks which do not depend on chain state are done here, PoW hash and ringct signatures
+// results are only cached, Add_Complete_Block still does every check serialized under the chain lock
+// but finds the expensive parts in the caches
+// key images, tips, difficulty and topo order are never checked here
+
+// PoW hashes computed by preverification, indexed by block hash
+// block hash covers the entire block work, so the hash can be reused safely
+var pow_hash_cache sync.Map
+
+type pow_hash_entry struct {
+	pow        crypto.Hash
+	first_seen time.Time
+}
+
+// jobs waiting for a preverify worker
+const PREVERIFY_QUEUE_SIZE = 1024
+
+// starts one preverify worker per cpu, they exit when chain shuts down
+func (chain *Blockchain) preverify_start() {
+	chain.preverify_queue = make(chan func(), PREVERIFY_QUEUE_SIZE)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		go chain.preverify_worker()
+	}
+}
+
+func (chain *Blockchain) preverify_worker() {
+	for {
+		select {
+		case <-chain.Exit_Event:
+			return
+		case job := <-chain.preverify_queue:
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						logger.Warnf("Recovered while preverifying block, Stack trace below")
+						logger.Warnf("Stack trace  \n%s", debug.Stack())
+					}
+				}()
+				job()
+			}()
+		}
+	}
+}
+
+// Preverify_Block queues PoW and ringct signature checks of a block to the worker pool
+// the returned channel is closed once all checks of this block have finished
+// caller must wait for it before the block is given to Add_Complete_Block, as the txs are expanded in place
+// a failure is not reported here, Add_Complete_Block will find and report it
+func (chain *Blockchain) Preverify_Block(cbl *block.Complete_Block) (done chan bool) {
+	done = make(chan bool)
+
+	// blocks with known checksums skip deep checks entirely, no point verifying them
+	if chain.preverify_queue == nil || (chain.checkpints_disabled == false && checkpoints.IsCheckSumKnown(chain.BlockCheckSum(cbl))) {
+		close(done)
+		return
+	}
+
+	jobs := []func(){func() { chain.preverify_pow(cbl.Bl) }}
+
+	// hard fork version is taken from the height claimed by the miner tx, since tips may not be in chain yet
+	// the claim is verified later, signatures cached using a wrong version are still valid signatures
+	if len(cbl.Bl.Miner_TX.Vin) == 1 {
+		if gen, ok := cbl.Bl.Miner_TX.Vin[0].(transaction.Txin_gen); ok {
+			hf_version := chain.Get_Current_Version_at_Height(int64(gen.Height))
+			// all txs are verified together so that bulletproofs of the block are batch verified
+			// inputs which are not yet in chain cannot be expanded, such txs are verified by Add_Complete_Block
+			if len(cbl.Txs) > 0 {
+				jobs = append(jobs, func() { chain.Verify_Transactions_NonCoinbase(nil, hf_version, cbl.Txs) })
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(jobs))
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for i := range jobs {
+		job := jobs[i]
+		select {
+		case chain.preverify_queue <- func() { defer wg.Done(); job() }:
+		case <-chain.Exit_Event:
+			wg.Done()
+		}
+	}
+	return
+}
+
+// computes the PoW hash and caches it for VerifyPoW
+func (chain *Blockchain) preverify_pow(bl *block.Block) {
+	pow_hash_cache.Store(bl.GetHash(), pow_hash_entry{pow: bl.GetPoWHash(), first_seen: time.Now()})
+}
+
+// returns PoW hash of the block, using the preverified hash if available
+func get_pow_hash(bl *block.Block) crypto.Hash {
+	blid := bl.GetHash()
+	if entry, ok := pow_hash_cache.Load(blid); ok {
+		pow_hash_cache.Delete(blid)
+		return entry.(pow_hash_entry).pow
+	}
+	return bl.GetPoWHash()
+}
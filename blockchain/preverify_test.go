@@ -0,0 +1,61 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+import "time"
+import "testing"
+
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/address"
+import "github.com/deroproject/derosuite/globals"
+
+func Test_Preverify_Block(t *testing.T) {
+	chain, cleanup := start_test_chain(t)
+	defer cleanup()
+
+	mine_test_blocks(t, chain, 5)
+
+	_, spend := crypto.NewKeyPair()
+	_, view := crypto.NewKeyPair()
+	miner_address := *address.NewAddressFromKeys(*spend, *view)
+	miner_address.Network = globals.Config.Public_Address_Prefix
+
+	for i := 0; i < 3; i++ {
+		cbl, _ := chain.Create_new_miner_block(miner_address)
+
+		select {
+		case <-chain.Preverify_Block(cbl):
+		case <-time.After(20 * time.Second):
+			t.Fatalf("Preverification did not finish")
+		}
+
+		entry, ok := pow_hash_cache.Load(cbl.Bl.GetHash())
+		if !ok {
+			t.Fatalf("PoW hash was not preverified")
+		}
+		if entry.(pow_hash_entry).pow != cbl.Bl.GetPoWHash() {
+			t.Fatalf("Preverified PoW hash mismatch")
+		}
+
+		if err, ok := chain.Add_Complete_Block(cbl); !ok {
+			t.Fatalf("Cannot add preverified block err %v", err)
+		}
+		if _, ok := pow_hash_cache.Load(cbl.Bl.GetHash()); ok {
+			t.Fatalf("Preverified PoW hash must be consumed by VerifyPoW")
+		}
+	}
+}
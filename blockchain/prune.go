@@ -0,0 +1,144 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+// this file implements pruning of old transactions
+// ring signatures and range proofs are the bulk of a tx, but once a tx is deep enough they are never verified again
+// these are replaced by their hash, so tx hash can still be calculated
+// tx prefix, ecdh info and commitments are kept, so output index, key images and wallets keep working
+// pruned node cannot serve old blocks to peers syncing from scratch
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/storage"
+import "github.com/deroproject/derosuite/transaction"
+
+// stores topoheight of next block to prune
+var PRUNE_HEIGHT = []byte("PRUNE_HEIGHT")
+
+// key image reachability walks upto 2*STABLE_LIMIT levels, however it only requires key images
+// any block shallower than this may still be reorganised, so its tx must be verifiable
+const PRUNE_MIN_DEPTH = 2 * config.STABLE_LIMIT
+
+// blocks pruned while adding a block, so as an existing chain is pruned gradually
+const PRUNE_BLOCKS_PER_TX = 100
+
+// prune all blocks which are deep enough, commits after every PRUNE_BLOCKS_PER_TX blocks
+func (chain *Blockchain) Prune_Chain() error {
+	chain.Lock()
+	defer chain.Unlock()
+
+	for {
+		dbtx, err := chain.store.BeginTX(true)
+		if err != nil {
+			return err
+		}
+
+		count := chain.prune_blocks(dbtx, chain.Load_TOPO_HEIGHT(dbtx), PRUNE_BLOCKS_PER_TX)
+		if err = dbtx.Commit(); err != nil {
+			return err
+		}
+		if count < PRUNE_BLOCKS_PER_TX {
+			return nil
+		}
+		logger.Infof("Pruned blocks till topoheight %d", chain.Load_Prune_Height(nil))
+	}
+}
+
+// topoheight upto which ( excluded ) blocks have been pruned
+func (chain *Blockchain) Load_Prune_Height(dbtx storage.DBTX) int64 {
+	var err error
+	if dbtx == nil {
+		dbtx, err = chain.store.BeginTX(false)
+		if err != nil {
+			logger.Warnf("Could NOT load prune height. Error opening TX, err %s", err)
+			return 0
+		}
+
+		defer dbtx.Rollback()
+	}
+
+	prune_height, err := dbtx.LoadUint64(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, PRUNE_HEIGHT, PRUNE_HEIGHT)
+	if err != nil { // chain has never been pruned
+		return 0
+	}
+	return int64(prune_height)
+}
+
+// prune upto limit blocks, which are at least prune_depth deep from top_topoheight
+// returns number of blocks pruned
+func (chain *Blockchain) prune_blocks(dbtx storage.DBTX, top_topoheight int64, limit int) (count int) {
+	prune_height := chain.Load_Prune_Height(dbtx)
+	for ; count < limit && prune_height+chain.prune_depth <= top_topoheight; count++ {
+		blid, err := chain.Load_Block_Topological_order_at_index(dbtx, prune_height)
+		if err != nil {
+			logger.Warnf("Cannot prune, no block at topoheight %d err %s", prune_height, err)
+			break
+		}
+		bl, err := chain.Load_BL_FROM_ID(dbtx, blid)
+		if err != nil {
+			logger.Warnf("Cannot prune, block %s could not be loaded err %s", blid, err)
+			break
+		}
+		for i := range bl.Tx_hashes {
+			chain.prune_TX(dbtx, bl.Tx_hashes[i])
+		}
+		prune_height++
+	}
+
+	if count > 0 {
+		dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_KEYVALUE, PRUNE_HEIGHT, PRUNE_HEIGHT, uint64(prune_height))
+	}
+	return
+}
+
+// replace tx blob with pruned version
+func (chain *Blockchain) prune_TX(dbtx storage.DBTX, txid crypto.Hash) {
+	if chain.Is_TX_Pruned(dbtx, txid) {
+		return
+	}
+	tx, err := chain.Load_TX_FROM_ID(dbtx, txid)
+	if err != nil {
+		logger.Warnf("Cannot prune tx %s err %s", txid, err)
+		return
+	}
+	chain.store_pruned_TX(dbtx, tx)
+}
+
+// size is not touched, as block size calculations require size of full tx
+func (chain *Blockchain) store_pruned_TX(dbtx storage.DBTX, tx *transaction.Transaction) {
+	hash := tx.GetHash()
+	dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_TRANSACTION, hash[:], PLANET_TX_BLOB, tx.SerializePruned())
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_TRANSACTION, hash[:], PLANET_TX_PRUNED, 1)
+}
+
+// whether the tx stored in DB has been pruned
+func (chain *Blockchain) Is_TX_Pruned(dbtx storage.DBTX, txid crypto.Hash) bool {
+	var err error
+	if dbtx == nil {
+		dbtx, err = chain.store.BeginTX(false)
+		if err != nil {
+			logger.Warnf("Could NOT check tx pruning. Error opening TX, err %s", err)
+			return false
+		}
+
+		defer dbtx.Rollback()
+	}
+
+	pruned, err := dbtx.LoadUint64(BLOCKCHAIN_UNIVERSE, GALAXY_TRANSACTION, txid[:], PLANET_TX_PRUNED)
+	return err == nil && pruned != 0
+}
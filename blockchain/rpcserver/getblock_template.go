@@ -68,18 +68,23 @@ func (h GetBlockTemplate_Handler) ServeJSONRPC(c context.Context, params *fastjs
 
 	*/
 
+	return block_template(p), nil
+}
+
+// template is also pushed to miners over /ws/getblocktemplate
+func block_template(p structures.GetBlockTemplate_Params) structures.GetBlockTemplate_Result {
 	// validate address
 	miner_address, err := address.NewAddress(p.Wallet_Address)
 	if err != nil {
 		return structures.GetBlockTemplate_Result{
 			Status: "Wallet address could not be parsed",
-		}, nil
+		}
 	}
 
 	if p.Reserve_size > 255 || p.Reserve_size < 1 {
 		return structures.GetBlockTemplate_Result{
 			Status: "Reserve size should be > 0 and < 255",
-		}, nil
+		}
 	}
 	
 	bl, block_hashing_blob_hex, block_template_hex, reserved_pos := chain.Create_new_block_template_mining(chain.Get_Top_ID(), *miner_address, int(p.Reserve_size))
@@ -98,6 +103,6 @@ func (h GetBlockTemplate_Handler) ServeJSONRPC(c context.Context, params *fastjs
 		Epoch:              uint64(uint64(time.Now().UTC().Unix()) + config.BLOCK_TIME), // expiry time of this block
 		Difficulty:         chain.Get_Difficulty_At_Tips(nil, bl.Tips).Uint64(),
 		Status:             "OK",
-	}, nil
+	}
 
 }
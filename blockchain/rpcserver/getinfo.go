@@ -64,8 +64,18 @@ func (h GetInfo_Handler) ServeJSONRPC(c context.Context, params *fastjson.RawMes
 
 	//result.Target_Height = uint64(chain.Get_Height())
 	result.Tx_pool_size = uint64(len(chain.Mempool.Mempool_List_TX()))
+	pool_stats := chain.Mempool.Mempool_Stats()
+	result.Tx_pool_bytes = pool_stats.Bytes
+	result.Tx_pool_max_bytes = pool_stats.Max_Bytes
+	result.Tx_pool_max_size = pool_stats.Max_TXs
+	result.Tx_pool_evicted = pool_stats.Evicted
+	result.Min_relay_fee_per_kb = pool_stats.Min_Fee_Per_KB
 	// get dynamic fees per kb, used by wallet for tx creation
+	// while pool is full, wallets must pay atleast the pool minimum
 	result.Dynamic_fee_per_kb = config.FEE_PER_KB
+	if result.Dynamic_fee_per_kb < pool_stats.Min_Fee_Per_KB {
+		result.Dynamic_fee_per_kb = pool_stats.Min_Fee_Per_KB
+	}
 	result.Median_Block_Size = config.CRYPTONOTE_MAX_BLOCK_SIZE
 
 	result.Total_Supply = chain.Load_Already_Generated_Coins_for_Topo_Index(nil, result.TopoHeight)
@@ -0,0 +1,68 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package rpcserver
+
+// returns recent topo changes, so as consumers which missed websocket events can catch up
+// if the oldest change returned has seq greater than after+1, changes have been missed and consumer must rescan
+
+import "sync"
+import "context"
+
+import "github.com/intel-go/fastjson"
+import "github.com/osamingo/jsonrpc"
+
+import "github.com/deroproject/derosuite/structures"
+
+// number of topo changes kept in memory
+const TOPO_CHANGES_HISTORY = 100
+
+var topo_changes []structures.Event
+var topo_changes_seq uint64
+var topo_changes_lock sync.Mutex
+
+// number the change and keep it in history
+func record_topo_change(e *structures.Event) {
+	topo_changes_lock.Lock()
+	defer topo_changes_lock.Unlock()
+
+	topo_changes_seq++
+	e.Seq = topo_changes_seq
+	topo_changes = append(topo_changes, *e)
+	if len(topo_changes) > TOPO_CHANGES_HISTORY {
+		topo_changes = append([]structures.Event{}, topo_changes[len(topo_changes)-TOPO_CHANGES_HISTORY:]...)
+	}
+}
+
+type GetTopoChanges_Handler struct{}
+
+func (h GetTopoChanges_Handler) ServeJSONRPC(c context.Context, params *fastjson.RawMessage) (interface{}, *jsonrpc.Error) {
+	var p structures.GetTopoChanges_Params
+	if err := jsonrpc.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	result := structures.GetTopoChanges_Result{Changes: []structures.Event{}, Status: "OK"}
+
+	topo_changes_lock.Lock()
+	defer topo_changes_lock.Unlock()
+	for i := range topo_changes {
+		if topo_changes[i].Seq > p.After {
+			result.Changes = append(result.Changes, topo_changes[i])
+		}
+	}
+	return result, nil
+}
@@ -133,7 +133,12 @@ func gettransactions_fill(p structures.GetTransaction_Params) (result structures
 							related.Output_Indices = append(related.Output_Indices, 0)
 						}
 					}
-					result.Txs_as_hex = append(result.Txs_as_hex, hex.EncodeToString(tx.Serialize()))
+					if tx.RctSignature.IsPruned() { // signatures are no longer available
+						related.Pruned = true
+						result.Txs_as_hex = append(result.Txs_as_hex, hex.EncodeToString(tx.SerializePruned()))
+					} else {
+						result.Txs_as_hex = append(result.Txs_as_hex, hex.EncodeToString(tx.Serialize()))
+					}
 					result.Txs = append(result.Txs, related)
 				}
 				continue
@@ -0,0 +1,101 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package rpcserver
+
+// view key indexer, daemon must be started with --indexer
+// exchanges register keys of view only wallets once and then fetch incoming transfers incrementally
+// transfers are only served along with the view secret key, since they reveal amounts and payment ids
+
+import "fmt"
+import "context"
+import "encoding/hex"
+
+import "github.com/intel-go/fastjson"
+import "github.com/osamingo/jsonrpc"
+
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/address"
+import "github.com/deroproject/derosuite/globals"
+import "github.com/deroproject/derosuite/structures"
+
+type Indexer_Register_Handler struct{}
+
+func (h Indexer_Register_Handler) ServeJSONRPC(c context.Context, params *fastjson.RawMessage) (interface{}, *jsonrpc.Error) {
+	var p structures.Indexer_Register_Params
+	if err := jsonrpc.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	var spend_public, view_secret crypto.Key
+	if err := spend_public.UnmarshalText([]byte(p.Spend_Public_Key)); err != nil {
+		return structures.Indexer_Register_Result{Status: "Spend public key could not be parsed"}, nil
+	}
+	if err := view_secret.UnmarshalText([]byte(p.View_Secret_Key)); err != nil {
+		return structures.Indexer_Register_Result{Status: "View secret key could not be parsed"}, nil
+	}
+
+	if err := chain.Indexer_Register(spend_public, view_secret, p.Start_TopoHeight); err != nil {
+		return structures.Indexer_Register_Result{Status: fmt.Sprintf("%s", err)}, nil
+	}
+
+	addr := address.NewAddressFromKeys(spend_public, *view_secret.PublicKey())
+	addr.Network = globals.Config.Public_Address_Prefix
+
+	return structures.Indexer_Register_Result{Address: addr.String(), Status: "OK"}, nil
+}
+
+type Indexer_Get_Transfers_Handler struct{}
+
+func (h Indexer_Get_Transfers_Handler) ServeJSONRPC(c context.Context, params *fastjson.RawMessage) (interface{}, *jsonrpc.Error) {
+	var p structures.Indexer_Get_Transfers_Params
+	if err := jsonrpc.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	addr, err := address.NewAddress(p.Address)
+	if err != nil {
+		return structures.Indexer_Get_Transfers_Result{Status: "Address could not be parsed"}, nil
+	}
+	var view_secret crypto.Key
+	if err := view_secret.UnmarshalText([]byte(p.View_Secret_Key)); err != nil {
+		return structures.Indexer_Get_Transfers_Result{Status: "View secret key could not be parsed"}, nil
+	}
+
+	transfers, err := chain.Indexer_Get_Transfers(addr.SpendKey, view_secret, p.After, p.Limit)
+	if err != nil {
+		return structures.Indexer_Get_Transfers_Result{Status: fmt.Sprintf("%s", err)}, nil
+	}
+
+	result := structures.Indexer_Get_Transfers_Result{Transfers: []structures.Indexer_Transfer{}, Status: "OK"}
+	for _, t := range transfers {
+		result.Transfers = append(result.Transfers, structures.Indexer_Transfer{
+			Seq:             t.Seq,
+			TXID:            t.TXID.String(),
+			BLID:            t.BLID.String(),
+			Height:          t.Height,
+			TopoHeight:      t.TopoHeight,
+			Block_Time:      t.Block_Time,
+			Index_within_tx: t.Index_within_tx,
+			Index_Global:    t.Index_Global,
+			Amount:          t.Amount,
+			Unlock_Height:   t.Unlock_Height,
+			PaymentID:       hex.EncodeToString(t.PaymentID),
+			Coinbase:        t.Coinbase,
+		})
+	}
+	return result, nil
+}
@@ -47,9 +47,10 @@ var DEBUG_MODE bool
 // all components requiring access to blockchain must use , this struct to communicate
 // this structure must be update while mutex
 type RPCServer struct {
-	srv        *http.Server
-	mux        *http.ServeMux
-	Exit_Event chan bool // blockchain is shutting down and we must quit ASAP
+	srv              *http.Server
+	mux              *http.ServeMux
+	Exit_Event       chan bool // blockchain is shutting down and we must quit ASAP
+	event_handler_id int       // events are pushed to websocket subscribers
 	sync.RWMutex
 }
 
@@ -76,6 +77,8 @@ func RPCServer_Start(params map[string]interface{}) (*RPCServer, error) {
 		}
 	*/
 
+	r.event_handler_id = chain.Add_Event_Handler(broadcast_event)
+
 	go r.Run()
 	logger.Infof("RPC server started")
 	atomic.AddUint32(&globals.Subsystem_Active, 1) // increment subsystem
@@ -89,6 +92,7 @@ func (r *RPCServer) RPCServer_Stop() {
 	defer r.Unlock()
 	Exit_In_Progress = true
 	close(r.Exit_Event) // send signal to all connections to exit
+	chain.Remove_Event_Handler(r.event_handler_id)
 
 	if r.srv != nil {
 		r.srv.Shutdown(context.Background()) // shutdown the server
@@ -156,6 +160,22 @@ func (r *RPCServer) Run() {
 		log.Fatalln(err)
 	}
 
+	if err := mr.RegisterMethod("estimatefee", EstimateFee_Handler{}, structures.EstimateFee_Params{}, structures.EstimateFee_Result{}); err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := mr.RegisterMethod("gettopochanges", GetTopoChanges_Handler{}, structures.GetTopoChanges_Params{}, structures.GetTopoChanges_Result{}); err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := mr.RegisterMethod("indexer_register", Indexer_Register_Handler{}, structures.Indexer_Register_Params{}, structures.Indexer_Register_Result{}); err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := mr.RegisterMethod("indexer_get_transfers", Indexer_Get_Transfers_Handler{}, structures.Indexer_Get_Transfers_Params{}, structures.Indexer_Get_Transfers_Result{}); err != nil {
+		log.Fatalln(err)
+	}
+
 	// create a new mux
 	r.mux = http.NewServeMux()
 
@@ -192,6 +212,8 @@ func (r *RPCServer) Run() {
 	r.mux.HandleFunc("/gettransactions", gettransactions)
 	r.mux.HandleFunc("/sendrawtransaction", SendRawTransaction_Handler)
 	r.mux.HandleFunc("/is_key_image_spent", iskeyimagespent)
+	r.mux.Handle("/ws", r.events_server()) // push events to subscribers
+	r.mux.Handle("/ws/getblocktemplate", r.template_server()) // push block templates to miners
 
 	if DEBUG_MODE {
 		// r.mux.HandleFunc("/debug/pprof/", pprof.Index)
@@ -0,0 +1,110 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package rpcserver
+
+// this file implements /ws/getblocktemplate, which pushes a fresh block template to a miner
+// as soon as a block is added to chain or the epoch of last template passes, so miners do not poll
+// parameters are same as getblocktemplate, /ws/getblocktemplate?wallet_address=dERo...&reserve_size=10
+
+import "time"
+import "strconv"
+import "sync/atomic"
+
+import "golang.org/x/net/websocket"
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/blockchain"
+import "github.com/deroproject/derosuite/structures"
+
+// every subscriber creates a template on every block and epoch, so their count is limited
+var template_subscribers_max int32 = 64
+var template_subscribers int32
+
+// origin is checked same as /ws
+func (r *RPCServer) template_server() websocket.Server {
+	return websocket.Server{Handler: r.template_handler, Handshake: check_origin}
+}
+
+func (r *RPCServer) template_handler(ws *websocket.Conn) {
+	defer atomic.AddInt32(&template_subscribers, -1)
+	if max := atomic.LoadInt32(&template_subscribers_max); atomic.AddInt32(&template_subscribers, 1) > max {
+		logger.Warnf("Rejecting block template subscriber %s, already serving %d", ws.Request().RemoteAddr, max)
+		ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		websocket.JSON.Send(ws, structures.GetBlockTemplate_Result{Status: "Too many block template subscribers"})
+		return
+	}
+
+	query := ws.Request().URL.Query()
+	p := structures.GetBlockTemplate_Params{Wallet_Address: query.Get("wallet_address"), Reserve_size: 1}
+	if reserve_size := query.Get("reserve_size"); reserve_size != "" {
+		p.Reserve_size, _ = strconv.ParseUint(reserve_size, 10, 64)
+	}
+
+	// new blocks are delivered same as /ws, so chain never waits on miners
+	s := &event_subscriber{events: make(chan structures.Event, EVENT_QUEUE_SIZE)}
+	s.set_filter([]string{blockchain.EVENT_NEW_BLOCK})
+
+	subscribers_lock.Lock()
+	subscribers[s] = true
+	subscribers_lock.Unlock()
+
+	defer func() {
+		subscribers_lock.Lock()
+		delete(subscribers, s)
+		subscribers_lock.Unlock()
+	}()
+
+	// miners do not send anything, reading detects a closed connection
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		var discard []byte
+		for {
+			if err := websocket.Message.Receive(ws, &discard); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		template := block_template(p)
+		ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if err := websocket.JSON.Send(ws, template); err != nil || template.Status != "OK" {
+			return
+		}
+
+		expiry := time.Duration(int64(template.Epoch)-time.Now().UTC().Unix()) * time.Second
+		if expiry <= 0 {
+			expiry = time.Duration(config.BLOCK_TIME) * time.Second
+		}
+
+		select {
+		case _, ok := <-s.events:
+			if !ok { // lagging, cannot happen unless chain adds blocks faster than we create templates
+				return
+			}
+			for len(s.events) > 0 { // a burst of blocks needs only a single template
+				<-s.events
+			}
+		case <-time.After(expiry):
+		case <-done:
+			return
+		case <-r.Exit_Event:
+			return
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package rpcserver
+
+import "os"
+import "time"
+import "strings"
+import "testing"
+import "io/ioutil"
+import "sync/atomic"
+import "net/url"
+import "net/http/httptest"
+
+import "golang.org/x/net/websocket"
+import log "github.com/sirupsen/logrus"
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/address"
+import "github.com/deroproject/derosuite/globals"
+import "github.com/deroproject/derosuite/blockchain"
+import "github.com/deroproject/derosuite/structures"
+
+func Test_Template_Push(t *testing.T) {
+	dir, err := ioutil.TempDir("", "derod_rpcserver_test")
+	if err != nil {
+		t.Fatalf("Cannot create temp dir err %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	globals.Logger = log.New()
+	globals.Logger.SetLevel(log.WarnLevel)
+	globals.Config = config.Testnet
+	globals.Arguments = map[string]interface{}{"--data-dir": dir, "--testnet": true, "--debug": false, "--boltdb": false, "--badgerdb": false, "--disable-checkpoints": true}
+	logger = globals.Logger.WithFields(log.Fields{"com": "RPC"})
+
+	chain, err = blockchain.Blockchain_Start(map[string]interface{}{"--simulator": true, "--memdb": true})
+	if err != nil {
+		t.Fatalf("Cannot start chain err %s", err)
+	}
+	defer chain.Shutdown()
+	defer chain.Remove_Event_Handler(chain.Add_Event_Handler(broadcast_event))
+
+	r := &RPCServer{Exit_Event: make(chan bool)}
+	defer close(r.Exit_Event)
+	server := httptest.NewServer(r.template_server())
+	defer server.Close()
+
+	_, spend := crypto.NewKeyPair()
+	_, view := crypto.NewKeyPair()
+	miner_address := address.NewAddressFromKeys(*spend, *view)
+	miner_address.Network = globals.Config.Public_Address_Prefix
+
+	ws_url := strings.Replace(server.URL, "http", "ws", 1) + "/ws/getblocktemplate?reserve_size=10&wallet_address="
+	ws, err := websocket.Dial(ws_url+url.QueryEscape(miner_address.String()), "", "http://localhost/")
+	if err != nil {
+		t.Fatalf("Cannot connect to websocket err %s", err)
+	}
+	defer ws.Close()
+
+	var template structures.GetBlockTemplate_Result
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err = websocket.JSON.Receive(ws, &template); err != nil || template.Status != "OK" || template.Height != uint64(chain.Get_Height()+1) {
+		t.Fatalf("Wrong first template %+v err %v", template, err)
+	}
+
+	// subscribers over the limit are told so and disconnected
+	atomic.StoreInt32(&template_subscribers_max, 1)
+	extra, err := websocket.Dial(ws_url+url.QueryEscape(miner_address.String()), "", "http://localhost/")
+	if err != nil {
+		t.Fatalf("Cannot connect to websocket err %s", err)
+	}
+	extra.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var rejected structures.GetBlockTemplate_Result
+	if err = websocket.JSON.Receive(extra, &rejected); err != nil || rejected.Status == "OK" {
+		t.Fatalf("Subscriber over limit must be rejected %+v err %v", rejected, err)
+	}
+	if err = websocket.JSON.Receive(extra, &rejected); err == nil {
+		t.Fatalf("Connection must be closed after rejection")
+	}
+	extra.Close()
+	atomic.StoreInt32(&template_subscribers_max, 64)
+
+	// websites cannot subscribe
+	if foreign, err := websocket.Dial(ws_url+url.QueryEscape(miner_address.String()), "", "http://example.com/"); err == nil {
+		foreign.Close()
+		t.Fatalf("Subscriber from foreign origin must be rejected")
+	}
+
+	// a new block pushes a new template right away, long before epoch
+	cbl, _ := chain.Create_new_miner_block(*miner_address)
+	if err, ok := chain.Add_Complete_Block(cbl); !ok {
+		t.Fatalf("Cannot add block err %v", err)
+	}
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err = websocket.JSON.Receive(ws, &template); err != nil || template.Height != uint64(chain.Get_Height()+1) {
+		t.Fatalf("Wrong template after new block %+v err %v", template, err)
+	}
+
+	// invalid parameters are reported once, then connection is closed
+	invalid, err := websocket.Dial(ws_url+"invalid", "", "http://localhost/")
+	if err != nil {
+		t.Fatalf("Cannot connect to websocket err %s", err)
+	}
+	defer invalid.Close()
+	invalid.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err = websocket.JSON.Receive(invalid, &template); err != nil || template.Status == "OK" {
+		t.Fatalf("Invalid address must be reported %+v err %v", template, err)
+	}
+	if err = websocket.JSON.Receive(invalid, &template); err == nil {
+		t.Fatalf("Connection must be closed after invalid parameters")
+	}
+
+	// disconnected miners are unsubscribed
+	ws.Close()
+	for i := 0; ; i++ {
+		subscribers_lock.Lock()
+		count := len(subscribers)
+		subscribers_lock.Unlock()
+		if count == 0 {
+			break
+		}
+		if i == 500 {
+			t.Fatalf("Disconnected miner is still subscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package rpcserver
+
+// this file implements /ws, a websocket which pushes chain and mempool events as they happen
+// so as exchanges, explorers etc do not need to poll
+// events can be filtered using /ws?events=new_block,mempool_add or later by sending structures.Event_Subscribe
+
+import "fmt"
+import "net"
+import "sync"
+import "sync/atomic"
+import "time"
+import "strings"
+import "net/http"
+
+import "golang.org/x/net/websocket"
+
+import "github.com/deroproject/derosuite/blockchain"
+import "github.com/deroproject/derosuite/structures"
+
+// subscribers lagging by more than these many events are disconnected, so they can resync
+const EVENT_QUEUE_SIZE = 1024
+
+type event_subscriber struct {
+	events chan structures.Event
+	filter map[string]bool // empty means all events
+	sync.Mutex
+}
+
+var subscribers = map[*event_subscriber]bool{}
+var subscribers_lock sync.Mutex
+
+// every subscriber queues every event, so their count is limited
+var event_subscribers_max int32 = 256
+var event_subscribers int32
+
+func (s *event_subscriber) set_filter(events []string) {
+	s.Lock()
+	defer s.Unlock()
+	s.filter = map[string]bool{}
+	for _, event := range events {
+		if event = strings.TrimSpace(event); event != "" {
+			s.filter[event] = true
+		}
+	}
+}
+
+func (s *event_subscriber) wants(event string) bool {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.filter) == 0 || s.filter[event]
+}
+
+// called by chain while it is locked, so it must never block
+func broadcast_event(event blockchain.Event) {
+	e := structures.Event{Type: event.Type, Height: event.Height, TopoHeight: event.Topoheight}
+	switch event.Type {
+	case blockchain.EVENT_NEW_BLOCK:
+		e.Block = fmt.Sprintf("%s", event.BLID)
+	case blockchain.EVENT_TOPO_CHANGE:
+		e.Changed_TopoHeight = event.Changed
+		for _, b := range event.Blocks {
+			e.Blocks = append(e.Blocks, structures.Topo_Change_Block{BLID: b.BLID.String(), Old_TopoHeight: b.Old_Topoheight, New_TopoHeight: b.New_Topoheight})
+		}
+		for _, tx := range event.TXs {
+			e.TXs = append(e.TXs, structures.Topo_Change_TX{TXID: tx.TXID.String(), BLID: tx.BLID.String(), Valid: tx.Valid})
+		}
+		record_topo_change(&e)
+	case blockchain.EVENT_MEMPOOL_ADD, blockchain.EVENT_MEMPOOL_DELETE:
+		e.TXID = fmt.Sprintf("%s", event.TX.GetHash())
+		e.Fee = event.TX.RctSignature.Get_TX_Fee()
+		e.Size = uint64(len(event.TX.Serialize()))
+	}
+
+	subscribers_lock.Lock()
+	defer subscribers_lock.Unlock()
+	for s := range subscribers {
+		if !s.wants(e.Type) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default: // subscriber is lagging, disconnect it
+			logger.Warnf("Websocket subscriber lagging by %d events, disconnecting", EVENT_QUEUE_SIZE)
+			delete(subscribers, s)
+			close(s.events)
+		}
+	}
+}
+
+// non-browser clients do not send origin, so an empty origin is accepted
+// browsers always send it, only pages served from loopback are allowed, so any website open in the browser
+// of the operator cannot connect to the daemon
+func check_origin(config *websocket.Config, req *http.Request) (err error) {
+	if config.Origin, err = websocket.Origin(config, req); err != nil || config.Origin == nil {
+		return
+	}
+	host := config.Origin.Hostname()
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("Origin %s not allowed", config.Origin)
+}
+
+func (r *RPCServer) events_server() websocket.Server {
+	return websocket.Server{Handler: r.events_handler, Handshake: check_origin}
+}
+
+func (r *RPCServer) events_handler(ws *websocket.Conn) {
+	defer atomic.AddInt32(&event_subscribers, -1)
+	if max := atomic.LoadInt32(&event_subscribers_max); atomic.AddInt32(&event_subscribers, 1) > max {
+		logger.Warnf("Rejecting websocket subscriber %s, already serving %d", ws.Request().RemoteAddr, max)
+		ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		websocket.JSON.Send(ws, structures.Event{Type: "error", Status: "Too many websocket subscribers"})
+		return
+	}
+
+	s := &event_subscriber{events: make(chan structures.Event, EVENT_QUEUE_SIZE)}
+	s.set_filter(strings.Split(ws.Request().URL.Query().Get("events"), ","))
+
+	subscribers_lock.Lock()
+	subscribers[s] = true
+	subscribers_lock.Unlock()
+
+	defer func() {
+		subscribers_lock.Lock()
+		delete(subscribers, s)
+		subscribers_lock.Unlock()
+	}()
+
+	// read subscription changes, this also detects a closed connection
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for {
+			var subscribe structures.Event_Subscribe
+			if err := websocket.JSON.Receive(ws, &subscribe); err != nil {
+				return
+			}
+			s.set_filter(subscribe.Events)
+		}
+	}()
+
+	for {
+		select {
+		case e, ok := <-s.events:
+			if !ok {
+				return
+			}
+			ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := websocket.JSON.Send(ws, e); err != nil {
+				return
+			}
+		case <-done:
+			return
+		case <-r.Exit_Event:
+			return
+		}
+	}
+}
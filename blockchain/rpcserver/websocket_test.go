@@ -0,0 +1,186 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package rpcserver
+
+import "fmt"
+import "time"
+import "sync/atomic"
+import "context"
+import "strings"
+import "testing"
+import "net/http/httptest"
+
+import "golang.org/x/net/websocket"
+import "github.com/intel-go/fastjson"
+import log "github.com/sirupsen/logrus"
+
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/blockchain"
+import "github.com/deroproject/derosuite/structures"
+
+// wait till the only subscriber wants the event
+func wait_subscriber(t *testing.T, event string) {
+	for i := 0; i < 500; i++ {
+		subscribers_lock.Lock()
+		for s := range subscribers {
+			if s.wants(event) {
+				subscribers_lock.Unlock()
+				return
+			}
+		}
+		subscribers_lock.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Subscriber never subscribed to %s", event)
+}
+
+func Test_Websocket_Events(t *testing.T) {
+	logger = log.WithFields(log.Fields{"com": "RPC"}) // rejected subscribers are logged
+	r := &RPCServer{Exit_Event: make(chan bool)}
+	server := httptest.NewServer(r.events_server())
+	defer server.Close()
+
+	ws, err := websocket.Dial(strings.Replace(server.URL, "http", "ws", 1)+"/ws?events=new_block", "", "http://localhost/")
+	if err != nil {
+		t.Fatalf("Cannot connect to websocket err %s", err)
+	}
+	defer ws.Close()
+	wait_subscriber(t, blockchain.EVENT_NEW_BLOCK)
+
+	// subscribers over the limit are told so and disconnected
+	atomic.StoreInt32(&event_subscribers_max, 1)
+	extra, err := websocket.Dial(strings.Replace(server.URL, "http", "ws", 1)+"/ws", "", "http://localhost/")
+	if err != nil {
+		t.Fatalf("Cannot connect to websocket err %s", err)
+	}
+	var rejected structures.Event
+	extra.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err = websocket.JSON.Receive(extra, &rejected); err != nil || rejected.Type != "error" || rejected.Status == "" {
+		t.Fatalf("Subscriber over limit must be rejected %+v err %v", rejected, err)
+	}
+	if err = websocket.JSON.Receive(extra, &rejected); err == nil {
+		t.Fatalf("Connection must be closed after rejection")
+	}
+	extra.Close()
+	atomic.StoreInt32(&event_subscribers_max, 256)
+
+	// filtered events are not delivered
+	blid := crypto.HashHexToHash("e14e318562db8d22f8d00bd41c7938807c7ff70e4380acc6f7f2427cf49f474a")
+	broadcast_event(blockchain.Event{Type: blockchain.EVENT_TOPO_CHANGE, Changed: 5, Height: 7, Topoheight: 9})
+	broadcast_event(blockchain.Event{Type: blockchain.EVENT_NEW_BLOCK, BLID: blid, Height: 8, Topoheight: 10})
+
+	var event structures.Event
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err = websocket.JSON.Receive(ws, &event); err != nil {
+		t.Fatalf("Cannot receive event err %s", err)
+	}
+	if event.Type != blockchain.EVENT_NEW_BLOCK || event.Block != blid.String() || event.Height != 8 || event.TopoHeight != 10 {
+		t.Fatalf("Wrong event %+v", event)
+	}
+
+	// change subscription
+	if err = websocket.JSON.Send(ws, structures.Event_Subscribe{Events: []string{blockchain.EVENT_TOPO_CHANGE}}); err != nil {
+		t.Fatalf("Cannot subscribe err %s", err)
+	}
+	wait_subscriber(t, blockchain.EVENT_TOPO_CHANGE)
+
+	broadcast_event(blockchain.Event{Type: blockchain.EVENT_NEW_BLOCK, BLID: blid, Height: 8, Topoheight: 10})
+	broadcast_event(blockchain.Event{Type: blockchain.EVENT_TOPO_CHANGE, Changed: 5, Height: 7, Topoheight: 9})
+
+	event = structures.Event{}
+	if err = websocket.JSON.Receive(ws, &event); err != nil {
+		t.Fatalf("Cannot receive event err %s", err)
+	}
+	if event.Type != blockchain.EVENT_TOPO_CHANGE || event.Changed_TopoHeight != 5 || event.Block != "" {
+		t.Fatalf("Wrong event %+v", event)
+	}
+
+	// shutting down disconnects subscribers
+	close(r.Exit_Event)
+	if err = websocket.JSON.Receive(ws, &event); err == nil {
+		t.Fatalf("Subscriber must be disconnected on exit")
+	}
+}
+
+func Test_GetTopoChanges(t *testing.T) {
+	blid := crypto.HashHexToHash("e14e318562db8d22f8d00bd41c7938807c7ff70e4380acc6f7f2427cf49f474a")
+	for i := 0; i < TOPO_CHANGES_HISTORY+5; i++ {
+		broadcast_event(blockchain.Event{Type: blockchain.EVENT_TOPO_CHANGE, Changed: int64(i),
+			Blocks: []blockchain.Topo_Change_Block{{BLID: blid, Old_Topoheight: int64(i), New_Topoheight: -1}},
+			TXs:    []blockchain.Topo_Change_TX{{TXID: blid, BLID: blid, Valid: false}}})
+	}
+
+	params := fastjson.RawMessage(`{"after":0}`)
+	result, err := GetTopoChanges_Handler{}.ServeJSONRPC(context.Background(), &params)
+	if err != nil {
+		t.Fatalf("gettopochanges failed err %s", err)
+	}
+	changes := result.(structures.GetTopoChanges_Result).Changes
+	if len(changes) != TOPO_CHANGES_HISTORY || changes[0].Seq+TOPO_CHANGES_HISTORY-1 != changes[len(changes)-1].Seq {
+		t.Fatalf("Wrong history length %d", len(changes))
+	}
+	last := changes[len(changes)-1]
+	if last.Changed_TopoHeight != TOPO_CHANGES_HISTORY+4 || len(last.Blocks) != 1 || last.Blocks[0].BLID != blid.String() || last.Blocks[0].New_TopoHeight != -1 || len(last.TXs) != 1 {
+		t.Fatalf("Wrong change %+v", last)
+	}
+
+	// resume from a seq
+	params = fastjson.RawMessage(fmt.Sprintf(`{"after":%d}`, last.Seq-1))
+	result, _ = GetTopoChanges_Handler{}.ServeJSONRPC(context.Background(), &params)
+	if changes = result.(structures.GetTopoChanges_Result).Changes; len(changes) != 1 || changes[0].Seq != last.Seq {
+		t.Fatalf("Wrong changes after %d %+v", last.Seq-1, changes)
+	}
+}
+
+// only non-browser clients and pages served from loopback may connect
+func Test_Websocket_Origin(t *testing.T) {
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"", true},
+		{"http://localhost/", true},
+		{"http://localhost:20206", true},
+		{"http://127.0.0.1:8080/", true},
+		{"http://[::1]/", true},
+		{"http://example.com/", false},
+		{"http://localhost.example.com/", false},
+		{"http://127.0.0.1.example.com/", false},
+		{"null", false},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", "/ws", nil)
+		if test.origin != "" {
+			req.Header.Set("Origin", test.origin)
+		}
+		config := &websocket.Config{Version: websocket.ProtocolVersionHybi13}
+		if err := check_origin(config, req); (err == nil) != test.allowed {
+			t.Errorf("Origin \"%s\" allowed %t expected %t err %v", test.origin, err == nil, test.allowed, err)
+		}
+	}
+
+	// a browser connection from another site is rejected during handshake
+	r := &RPCServer{Exit_Event: make(chan bool)}
+	defer close(r.Exit_Event)
+	server := httptest.NewServer(r.events_server())
+	defer server.Close()
+	if ws, err := websocket.Dial(strings.Replace(server.URL, "http", "ws", 1)+"/ws", "", "http://example.com/"); err == nil {
+		ws.Close()
+		t.Fatalf("Websocket from foreign origin must be rejected")
+	}
+}
@@ -0,0 +1,525 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+// this file implements export/import of chain snapshots, so as a new node can bootstrap without syncing from genesis
+// a snapshot contains every block in topo order till a topoheight, together with full txs and the state derived by client protocol
+// ( tx validity, rewards, output index, key images ), the state is imported as is and verified later on using Verify_DB
+// every block is verified against checkpoint checksums, the file as a whole is signed by the exporter
+//
+// file is a sequence of chunks, each chunk is
+// uvarint(length of payload) || payload ( msgpack) || keccak256(hash of previous chunk || payload)
+// first chunk is the header, followed by data chunks, the last chunk carries the signature of the hash before it
+
+import "io"
+import "os"
+import "fmt"
+import "bufio"
+import "encoding/binary"
+
+import "github.com/vmihailenco/msgpack"
+
+import "github.com/deroproject/derosuite/block"
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/globals"
+import "github.com/deroproject/derosuite/storage"
+import "github.com/deroproject/derosuite/checkpoints"
+import "github.com/deroproject/derosuite/transaction"
+
+const SNAPSHOT_MAGIC = "DEROSNAPSHOT"
+const SNAPSHOT_VERSION = 1
+
+const SNAPSHOT_BLOCKS_PER_CHUNK = 100
+const SNAPSHOT_KEYIMAGES_PER_CHUNK = 10000
+const SNAPSHOT_MAX_CHUNK_SIZE = 256 * 1024 * 1024 // reject anything larger, before allocating
+
+type Snapshot_Header struct {
+	Magic      string        `msgpack:"MAGIC"`
+	Version    uint64        `msgpack:"V"`
+	Network_ID []byte        `msgpack:"NID"`
+	Topoheight int64         `msgpack:"TOPO"`   // snapshot contains blocks from topoheight 0 till this ( included)
+	Height     int64         `msgpack:"HEIGHT"` // chain height at topoheight
+	Tips       []crypto.Hash `msgpack:"TIPS"`   // tips of chain at topoheight
+	Signer     crypto.Key    `msgpack:"SIGNER"` // public key of exporter
+}
+
+// a block with its txs and everything client protocol derived from it
+type Snapshot_Block struct {
+	Block           []byte   `msgpack:"BL"`  // serialized block, includes miner tx
+	Txs             [][]byte `msgpack:"TXS"` // full txs, in the same order as block
+	Valid           []bool   `msgpack:"VALID"`
+	Base_Reward     uint64   `msgpack:"BREWARD"`
+	Total_Reward    uint64   `msgpack:"REWARD"`
+	Generated_Coins uint64   `msgpack:"CCOINS"`
+	Output_Index    int64    `msgpack:"OINDEX"` // output index of first output
+	Outputs         [][]byte `msgpack:"OUTPUTS"`
+}
+
+type Snapshot_KeyImage struct {
+	Key_Image crypto.Hash `msgpack:"KI"`
+	Height    int64       `msgpack:"H"` // height at which key image was consumed
+}
+
+type Snapshot_Chunk struct {
+	Blocks     []Snapshot_Block    `msgpack:"BLOCKS"`
+	Key_Images []Snapshot_KeyImage `msgpack:"KEYIMAGES"`
+	Signed     bool                `msgpack:"SIGNED"` // this is the last chunk
+	Signature  crypto.Signature    `msgpack:"SIG"`    // signature of the hash of all previous chunks
+}
+
+type snapshot_writer struct {
+	w    *bufio.Writer
+	hash crypto.Hash // running hash of all chunks written so far
+}
+
+func (sw *snapshot_writer) write_chunk(v interface{}) error {
+	payload, err := msgpack.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var buf [binary.MaxVarintLen64]byte
+	sw.w.Write(buf[:binary.PutUvarint(buf[:], uint64(len(payload)))])
+	sw.w.Write(payload)
+	sw.hash = crypto.Keccak256(sw.hash[:], payload)
+	_, err = sw.w.Write(sw.hash[:])
+	return err
+}
+
+type snapshot_reader struct {
+	r    *bufio.Reader
+	hash crypto.Hash // running hash of all chunks read so far
+}
+
+func (sr *snapshot_reader) read_chunk(v interface{}) error {
+	length, err := binary.ReadUvarint(sr.r)
+	if err != nil {
+		return fmt.Errorf("Snapshot truncated err %s", err)
+	}
+	if length > SNAPSHOT_MAX_CHUNK_SIZE {
+		return fmt.Errorf("Snapshot chunk too large %d", length)
+	}
+	payload := make([]byte, length)
+	var stored crypto.Hash
+	if _, err = io.ReadFull(sr.r, payload); err != nil {
+		return fmt.Errorf("Snapshot truncated err %s", err)
+	}
+	if _, err = io.ReadFull(sr.r, stored[:]); err != nil {
+		return fmt.Errorf("Snapshot truncated err %s", err)
+	}
+	sr.hash = crypto.Keccak256(sr.hash[:], payload)
+	if stored != sr.hash {
+		return fmt.Errorf("Snapshot corrupted, chunk hash mismatch")
+	}
+	return msgpack.Unmarshal(payload, v)
+}
+
+// export chain till topoheight to a file, signed using the secret key
+// a consistent view is used, so chain can keep running while exporting
+func (chain *Blockchain) Export_Snapshot(filename string, topoheight int64, secret crypto.Key) (err error) {
+	dbtx, err := chain.store.BeginTX(false)
+	if err != nil {
+		return
+	}
+	defer dbtx.Rollback()
+
+	if top := chain.Load_TOPO_HEIGHT(dbtx); topoheight < 1 || topoheight > top-config.STABLE_LIMIT {
+		return fmt.Errorf("Snapshot topoheight must be stable, between 1 and %d", top-config.STABLE_LIMIT)
+	}
+
+	header := Snapshot_Header{Magic: SNAPSHOT_MAGIC, Version: SNAPSHOT_VERSION, Network_ID: globals.Config.Network_ID[:], Topoheight: topoheight, Signer: *secret.PublicKey()}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	sw := snapshot_writer{w: bufio.NewWriter(f)}
+
+	// find tips and height, every block which is not referenced by a later block is a tip
+	tips := map[crypto.Hash]bool{}
+	for i := int64(0); i <= topoheight; i++ {
+		blid, err := chain.Load_Block_Topological_order_at_index(dbtx, i)
+		if err != nil {
+			return fmt.Errorf("No block at topoheight %d err %s", i, err)
+		}
+		bl, err := chain.Load_BL_FROM_ID(dbtx, blid)
+		if err != nil {
+			return fmt.Errorf("Cannot load block %s err %s", blid, err)
+		}
+		for j := range bl.Tips {
+			delete(tips, bl.Tips[j])
+		}
+		tips[blid] = true
+		if height := chain.Load_Height_for_BL_ID(dbtx, blid); height > header.Height {
+			header.Height = height
+		}
+	}
+	for blid := range tips {
+		header.Tips = append(header.Tips, blid)
+	}
+
+	if err = sw.write_chunk(&header); err != nil {
+		return
+	}
+
+	unknown_checksums := 0
+	spent_keyimages := map[crypto.Hash]bool{}
+
+	var chunk Snapshot_Chunk
+	for i := int64(0); i <= topoheight; i++ {
+		blid, _ := chain.Load_Block_Topological_order_at_index(dbtx, i)
+		bl, _ := chain.Load_BL_FROM_ID(dbtx, blid)
+
+		sbl := Snapshot_Block{Block: bl.Serialize()}
+		cbl := block.Complete_Block{Bl: bl}
+		for _, txid := range bl.Tx_hashes {
+			tx, err := chain.Load_TX_FROM_ID(dbtx, txid)
+			if err != nil {
+				return fmt.Errorf("Cannot load tx %s err %s", txid, err)
+			}
+			if tx.RctSignature.IsPruned() {
+				return fmt.Errorf("tx %s has been pruned, snapshot cannot be exported from a pruned chain", txid)
+			}
+			valid := chain.IS_TX_Valid(dbtx, blid, txid)
+			if valid {
+				for j := range tx.Vin {
+					spent_keyimages[crypto.Hash(tx.Vin[j].(transaction.Txin_to_key).K_image)] = true
+				}
+			}
+			sbl.Txs = append(sbl.Txs, tx.Serialize())
+			sbl.Valid = append(sbl.Valid, valid)
+			cbl.Txs = append(cbl.Txs, tx)
+		}
+		if i > 0 && !checkpoints.IsCheckSumKnown(chain.BlockCheckSum(&cbl)) {
+			unknown_checksums++
+		}
+
+		sbl.Base_Reward, _ = dbtx.LoadUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], PLANET_BASEREWARD)
+		sbl.Total_Reward, _ = dbtx.LoadUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], PLANET_MINERTX_REWARD)
+		sbl.Generated_Coins, _ = dbtx.LoadUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], PLANET_ALREADY_GENERATED_COINS)
+
+		var end int64
+		sbl.Output_Index, end = chain.Get_Block_Output_Index(dbtx, blid)
+		for index := sbl.Output_Index; index < end; index++ {
+			data, err := chain.Read_output_index(dbtx, uint64(index))
+			if err != nil {
+				return fmt.Errorf("Cannot load output index %d err %s", index, err)
+			}
+			sbl.Outputs = append(sbl.Outputs, data)
+		}
+
+		chunk.Blocks = append(chunk.Blocks, sbl)
+		if len(chunk.Blocks) >= SNAPSHOT_BLOCKS_PER_CHUNK || i == topoheight {
+			if err = sw.write_chunk(&chunk); err != nil {
+				return
+			}
+			chunk = Snapshot_Chunk{}
+		}
+	}
+
+	// key images are stored with the height at which they were consumed
+	for k_image := range spent_keyimages {
+		height, ok := chain.Read_KeyImage_Status(dbtx, k_image)
+		if !ok {
+			return fmt.Errorf("Key image %s is not marked as spent, please run --verify-db", k_image)
+		}
+		chunk.Key_Images = append(chunk.Key_Images, Snapshot_KeyImage{Key_Image: k_image, Height: height})
+		if len(chunk.Key_Images) >= SNAPSHOT_KEYIMAGES_PER_CHUNK {
+			if err = sw.write_chunk(&chunk); err != nil {
+				return
+			}
+			chunk = Snapshot_Chunk{}
+		}
+	}
+	if len(chunk.Key_Images) > 0 {
+		if err = sw.write_chunk(&chunk); err != nil {
+			return
+		}
+	}
+
+	if err = sw.write_chunk(&Snapshot_Chunk{Signed: true, Signature: crypto.GenerateSignature(sw.hash, header.Signer, secret)}); err != nil {
+		return
+	}
+	if err = sw.w.Flush(); err != nil {
+		return
+	}
+
+	if unknown_checksums > 0 {
+		logger.Warnf("%d blocks in snapshot are not covered by checkpoints, importers will need --disable-checkpoints", unknown_checksums)
+	}
+	logger.Infof("Snapshot till topoheight %d exported to %s, signed by %s", topoheight, filename, header.Signer)
+	return nil
+}
+
+// import a snapshot into an empty chain, chain will continue syncing from the tip of snapshot
+// if signer is not nil, snapshot must be signed by it
+func (chain *Blockchain) Import_Snapshot(filename string, signer *crypto.Key) (err error) {
+	header, final_hash, err := chain.verify_snapshot(filename, signer)
+	if err != nil {
+		return
+	}
+
+	if err = chain.import_snapshot(filename, header, final_hash); err != nil {
+		return
+	}
+
+	chain.Initialise_Chain_From_DB()
+
+	// derived state has been imported as is, replay it to make sure it is consistent with the blocks
+	mismatches, err := chain.Verify_DB(false)
+	if err != nil {
+		return
+	}
+	if mismatches > 0 {
+		return fmt.Errorf("Imported chain has %d mismatches, snapshot is not trustworthy, please delete the chain", mismatches)
+	}
+
+	if chain.prune_depth > 0 {
+		if err = chain.Prune_Chain(); err != nil {
+			return
+		}
+	}
+
+	logger.Infof("Snapshot imported successfully till topoheight %d height %d", header.Topoheight, header.Height)
+	return nil
+}
+
+// first pass, verify signature, checksums and structure without touching the DB
+func (chain *Blockchain) verify_snapshot(filename string, signer *crypto.Key) (header Snapshot_Header, final_hash crypto.Hash, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	sr := snapshot_reader{r: bufio.NewReader(f)}
+	if err = sr.read_chunk(&header); err != nil {
+		return
+	}
+	if header.Magic != SNAPSHOT_MAGIC || header.Version != SNAPSHOT_VERSION {
+		err = fmt.Errorf("Not a snapshot or unsupported version %d", header.Version)
+		return
+	}
+	if string(header.Network_ID) != string(globals.Config.Network_ID[:]) {
+		err = fmt.Errorf("Snapshot belongs to a different network")
+		return
+	}
+	if signer != nil && *signer != header.Signer {
+		err = fmt.Errorf("Snapshot is signed by %s, expected %s", header.Signer, *signer)
+		return
+	}
+
+	logger.Infof("Verifying snapshot till topoheight %d signed by %s", header.Topoheight, header.Signer)
+
+	seen := map[crypto.Hash]bool{}
+	topoheight := int64(0)
+	for {
+		signed_hash := sr.hash
+
+		var chunk Snapshot_Chunk
+		if err = sr.read_chunk(&chunk); err != nil {
+			return
+		}
+
+		if chunk.Signed {
+			if !crypto.CheckSignature(signed_hash, header.Signer, chunk.Signature) {
+				err = fmt.Errorf("Snapshot signature is invalid")
+				return
+			}
+			if _, err1 := sr.r.ReadByte(); err1 != io.EOF {
+				err = fmt.Errorf("Snapshot contains extra data after signature")
+				return
+			}
+			break
+		}
+
+		for i := range chunk.Blocks {
+			var cbl *block.Complete_Block
+			if cbl, err = chunk.Blocks[i].decode(); err != nil {
+				err = fmt.Errorf("Snapshot block at topoheight %d err %s", topoheight, err)
+				return
+			}
+			blid := cbl.Bl.GetHash()
+
+			if topoheight == 0 {
+				if blid != globals.Config.Genesis_Block_Hash {
+					err = fmt.Errorf("Snapshot does not start from genesis block")
+					return
+				}
+			} else if !chain.checkpints_disabled && !checkpoints.IsCheckSumKnown(chain.BlockCheckSum(cbl)) {
+				err = fmt.Errorf("Block %s at topoheight %d does not match checkpoints", blid, topoheight)
+				return
+			}
+
+			for j := range cbl.Bl.Tips { // topo order guarantees past is already present
+				if !seen[cbl.Bl.Tips[j]] {
+					err = fmt.Errorf("Block %s at topoheight %d refers to unknown tip %s", blid, topoheight, cbl.Bl.Tips[j])
+					return
+				}
+			}
+			seen[blid] = true
+			topoheight++
+		}
+	}
+
+	if topoheight != header.Topoheight+1 {
+		err = fmt.Errorf("Snapshot contains %d blocks, expected %d", topoheight, header.Topoheight+1)
+		return
+	}
+	for i := range header.Tips {
+		if !seen[header.Tips[i]] {
+			err = fmt.Errorf("Snapshot tip %s is not part of snapshot", header.Tips[i])
+			return
+		}
+	}
+
+	if chain.checkpints_disabled {
+		logger.Warnf("Checkpoints are disabled, snapshot blocks are trusted as signed by %s", header.Signer)
+	}
+	return header, sr.hash, nil
+}
+
+// decode block and txs, txs must be the ones referred by block
+func (sbl *Snapshot_Block) decode() (cbl *block.Complete_Block, err error) {
+	var bl block.Block
+	if err = bl.Deserialize(sbl.Block); err != nil {
+		return
+	}
+	if len(sbl.Txs) != len(bl.Tx_hashes) || len(sbl.Valid) != len(bl.Tx_hashes) {
+		return nil, fmt.Errorf("block has %d txs, snapshot contains %d", len(bl.Tx_hashes), len(sbl.Txs))
+	}
+
+	cbl = &block.Complete_Block{Bl: &bl}
+	for i := range sbl.Txs {
+		var tx transaction.Transaction
+		if err = tx.DeserializeHeader(sbl.Txs[i]); err != nil {
+			return
+		}
+		if tx.GetHash() != bl.Tx_hashes[i] {
+			return nil, fmt.Errorf("tx %s does not belong to block", tx.GetHash())
+		}
+		cbl.Txs = append(cbl.Txs, &tx)
+	}
+	return
+}
+
+// second pass, write everything, a chunk per DB tx
+// chain top is only updated at the end, so an interrupted import can be retried
+func (chain *Blockchain) import_snapshot(filename string, header Snapshot_Header, final_hash crypto.Hash) (err error) {
+	chain.Lock()
+	defer chain.Unlock()
+
+	if topo := chain.Load_TOPO_HEIGHT(nil); topo != 0 {
+		return fmt.Errorf("Chain already contains blocks till topoheight %d, snapshot can only be imported into a new chain", topo)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	sr := snapshot_reader{r: bufio.NewReader(f)}
+	var header_again Snapshot_Header
+	if err = sr.read_chunk(&header_again); err != nil {
+		return
+	}
+
+	topoheight := int64(0)
+	for {
+		var chunk Snapshot_Chunk
+		if err = sr.read_chunk(&chunk); err != nil {
+			return
+		}
+		if chunk.Signed {
+			break
+		}
+
+		var dbtx storage.DBTX
+		if dbtx, err = chain.store.BeginTX(true); err != nil {
+			return
+		}
+		for i := range chunk.Blocks {
+			cbl, _ := chunk.Blocks[i].decode() // already verified
+			chain.import_snapshot_block(dbtx, cbl, &chunk.Blocks[i], topoheight)
+			topoheight++
+		}
+		for _, ki := range chunk.Key_Images {
+			chain.Store_KeyImage(dbtx, ki.Key_Image, ki.Height)
+		}
+
+		if err = dbtx.Commit(); err != nil {
+			return
+		}
+		if topoheight%(10*SNAPSHOT_BLOCKS_PER_CHUNK) == 0 && len(chunk.Blocks) > 0 {
+			logger.Infof("Imported snapshot till topoheight %d", topoheight-1)
+		}
+	}
+
+	if sr.hash != final_hash || topoheight != header.Topoheight+1 { // chain top is not moved, if file changed meanwhile
+		return fmt.Errorf("Snapshot changed while importing")
+	}
+
+	dbtx, err := chain.store.BeginTX(true)
+	if err != nil {
+		return
+	}
+	chain.store_TIPS(dbtx, header.Tips)
+	chain.Store_TOP_HEIGHT(dbtx, header.Height)
+	chain.Store_TOPO_HEIGHT(dbtx, header.Topoheight)
+	return dbtx.Commit()
+}
+
+// store block, its txs and state exactly as client protocol would have
+// everything is keyed by hash, so writing it again is harmless
+func (chain *Blockchain) import_snapshot_block(dbtx storage.DBTX, cbl *block.Complete_Block, sbl *Snapshot_Block, topoheight int64) {
+	bl := cbl.Bl
+	blid := bl.GetHash()
+
+	for i := range cbl.Txs {
+		chain.Store_TX(dbtx, cbl.Txs[i])
+	}
+	if !chain.Block_Exists(dbtx, blid) { // genesis is already present
+		chain.Store_BL(dbtx, bl)
+	}
+	chain.Store_Block_Topological_order(dbtx, blid, topoheight)
+
+	chain.Store_TX(dbtx, &bl.Miner_TX)
+	chain.Store_TX_Height(dbtx, bl.Miner_TX.GetHash(), topoheight)
+	chain.store_TX_in_Block(dbtx, blid, bl.Miner_TX.GetHash())
+	chain.mark_TX(dbtx, blid, bl.Miner_TX.GetHash(), true)
+
+	for i := range bl.Tx_hashes {
+		chain.store_TX_in_Block(dbtx, blid, bl.Tx_hashes[i])
+		chain.mark_TX(dbtx, blid, bl.Tx_hashes[i], sbl.Valid[i])
+		if sbl.Valid[i] {
+			chain.Store_TX_Height(dbtx, bl.Tx_hashes[i], topoheight)
+		}
+	}
+
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], PLANET_MINERTX_REWARD, sbl.Total_Reward)
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], PLANET_BASEREWARD, sbl.Base_Reward)
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], PLANET_ALREADY_GENERATED_COINS, sbl.Generated_Coins)
+
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], PLANET_OUTPUT_INDEX, uint64(sbl.Output_Index))
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], PLANET_OUTPUT_INDEX_END, uint64(sbl.Output_Index+int64(len(sbl.Outputs))))
+	for i := range sbl.Outputs {
+		dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_OUTPUT_INDEX, GALAXY_OUTPUT_INDEX, itob(uint64(sbl.Output_Index+int64(i))), sbl.Outputs[i])
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+import "os"
+import "testing"
+import "io/ioutil"
+import "path/filepath"
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/crypto"
+
+func Test_Snapshot(t *testing.T) {
+	chain, cleanup := start_test_chain(t)
+	defer cleanup()
+
+	mine_test_blocks(t, chain, int(3*config.STABLE_LIMIT))
+
+	secret, signer := crypto.NewKeyPair()
+	dir, err := ioutil.TempDir("", "derod_snapshot_test")
+	if err != nil {
+		t.Fatalf("Cannot create temp dir err %s", err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "snapshot.bin")
+	topoheight := chain.Load_TOPO_HEIGHT(nil) - config.STABLE_LIMIT
+
+	if err := chain.Export_Snapshot(filename, chain.Load_TOPO_HEIGHT(nil), *secret); err == nil {
+		t.Fatalf("Unstable topoheight must not be exported")
+	}
+	if err := chain.Export_Snapshot(filename, topoheight, *secret); err != nil {
+		t.Fatalf("Snapshot export failed err %s", err)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Cannot read snapshot err %s", err)
+	}
+
+	imported, cleanup_imported := start_test_chain(t)
+	defer cleanup_imported()
+
+	// wrong signer, tampering and checkpoints are all caught before anything is written
+	_, other := crypto.NewKeyPair()
+	if err := imported.Import_Snapshot(filename, other); err == nil {
+		t.Fatalf("Snapshot signed by a different key must be rejected")
+	}
+
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)/2] ^= 1
+	tampered_filename := filename + ".tampered"
+	ioutil.WriteFile(tampered_filename, tampered, 0600)
+	if err := imported.Import_Snapshot(tampered_filename, nil); err == nil {
+		t.Fatalf("Tampered snapshot must be rejected")
+	}
+
+	ioutil.WriteFile(tampered_filename, data[:len(data)-1], 0600)
+	if err := imported.Import_Snapshot(tampered_filename, nil); err == nil {
+		t.Fatalf("Truncated snapshot must be rejected")
+	}
+
+	imported.checkpints_disabled = false // testnet blocks are not in checkpoints
+	if err := imported.Import_Snapshot(filename, signer); err == nil {
+		t.Fatalf("Blocks unknown to checkpoints must be rejected")
+	}
+	imported.checkpints_disabled = true
+
+	if imported.Load_TOPO_HEIGHT(nil) != 0 {
+		t.Fatalf("Rejected snapshots must not modify chain")
+	}
+
+	if err := imported.Import_Snapshot(filename, signer); err != nil {
+		t.Fatalf("Snapshot import failed err %s", err)
+	}
+
+	if imported.Load_TOPO_HEIGHT(nil) != topoheight {
+		t.Fatalf("Imported topoheight %d expected %d", imported.Load_TOPO_HEIGHT(nil), topoheight)
+	}
+	for i := int64(0); i <= topoheight; i++ {
+		expected, _ := chain.Load_Block_Topological_order_at_index(nil, i)
+		actual, err := imported.Load_Block_Topological_order_at_index(nil, i)
+		if err != nil || actual != expected {
+			t.Fatalf("Topoheight %d has block %s expected %s err %v", i, actual, expected, err)
+		}
+	}
+	if tips := imported.Get_TIPS(); len(tips) != 1 {
+		t.Fatalf("Imported chain has %d tips", len(tips))
+	}
+
+	// snapshot cannot be imported twice, and imported chain continues normally
+	if err := imported.Import_Snapshot(filename, signer); err == nil {
+		t.Fatalf("Snapshot must only be imported into an empty chain")
+	}
+	mine_test_blocks(t, imported, 5)
+	if mismatches, err := imported.Verify_DB(false); err != nil || mismatches != 0 {
+		t.Fatalf("Imported chain has %d mismatches err %v", mismatches, err)
+	}
+}
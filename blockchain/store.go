@@ -104,6 +104,7 @@ var PLANET_TX_BLOB = []byte("BLOB")          // contains serialised  TX , this a
 var PLANET_TX_MINED_IN_BLOCK = []byte("MBL") //[]byte("MINERBLOCK") // which blocks mined this tx, stores topo height of mined block
 var PLANET_TX_MINED = []byte("MIN")          // all blocks where tx is mined in in
 var PLANET_TX_SIZE = []byte("SIZE")
+var PLANET_TX_PRUNED = []byte("PRUNED") // if non zero, BLOB contains a pruned tx, SIZE is still of the full tx
 
 // the universe concept is there, as we bring in smart contracts, we will give each of them a universe to play within
 // while communicating with external universe
@@ -324,11 +325,19 @@ func (chain *Blockchain) Store_TX(dbtx storage.DBTX, tx *transaction.Transaction
 	}
 
 	hash := tx.GetHash()
+	if tx.RctSignature.IsPruned() { // size is left as is, since it is of full tx
+		chain.store_pruned_TX(dbtx, tx)
+		return
+	}
 	serialized := tx.Serialize()
 	err := dbtx.StoreObject(BLOCKCHAIN_UNIVERSE, GALAXY_TRANSACTION, hash[:], PLANET_TX_BLOB, serialized)
 	// store size of tx
 	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_TRANSACTION, hash[:], PLANET_TX_SIZE, uint64(len(serialized)))
 
+	if chain.Is_TX_Pruned(dbtx, hash) { // full tx is available again
+		dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_TRANSACTION, hash[:], PLANET_TX_PRUNED, 0)
+	}
+
 	//dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_TRANSACTION, hash[:], PLANET_TX_MINED_IN_BLOCK, uint64(TopoHeight))
 
 	_ = err
@@ -680,7 +689,11 @@ func (chain *Blockchain) Load_TX_FROM_ID(dbtx storage.DBTX, hash [32]byte) (*tra
 	}
 
 	// we should deserialize the block here
-	err = tx.DeserializeHeader(tx_data)
+	if chain.Is_TX_Pruned(dbtx, hash) {
+		err = tx.DeserializePruned(tx_data)
+	} else {
+		err = tx.DeserializeHeader(tx_data)
+	}
 
 	if err != nil {
 		logger.Printf("fError deserialiing tx, block id %s len(data) %d data %x", hash[:], len(tx_data), tx_data)
@@ -0,0 +1,132 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package stratum
+
+// this file implements share accounting hooks and variable share difficulty (vardiff)
+// pools register a handler to credit miners, derod itself only logs blocks
+
+import "time"
+import "runtime/debug"
+
+import "github.com/deroproject/derosuite/crypto"
+
+const STRATUM_START_DIFFICULTY = 5000              // share difficulty of a freshly logged in miner
+const STRATUM_MIN_DIFFICULTY = 100                 // share difficulty never goes below this, unless network difficulty is lower
+const STRATUM_SHARE_TIME = 10 * time.Second        // vardiff targets a share every these many secs per connection
+const STRATUM_RETARGET_INTERVAL = 60 * time.Second // share difficulty is adjusted every these many secs
+
+// reasons a share is rejected
+const (
+	SHARE_STALE          = "stale"          // job was superseded by a new block
+	SHARE_DUPLICATE      = "duplicate"      // same nonce was already submitted
+	SHARE_LOW_DIFFICULTY = "low difficulty" // PoW does not meet share difficulty
+	SHARE_INVALID        = "invalid"        // nonce could not be parsed
+)
+
+// every submitted share, valid or not, is delivered to share handlers
+type Share struct {
+	Connection string // connection id, which is also its extra nonce in hex
+	Address    string // miner wallet address, which receives block reward
+	Job_ID     string
+	Height     uint64
+	Difficulty uint64 // share difficulty, miner should be credited this much work, 0 if share is not valid
+	Valid      bool
+	Reason     string      // why share was rejected, one of SHARE_*
+	Block      bool        // share met network difficulty and was submitted to chain
+	Accepted   bool        // block was accepted by chain
+	BLID       crypto.Hash // block id, if share was a block
+}
+
+// handlers are called synchronously from connection of the miner, so they must not block for long
+type Share_Handler func(Share)
+
+// register a handler for all shares, returns an id which can be used to remove the handler
+func (s *Server) Add_Share_Handler(handler Share_Handler) (id int) {
+	s.Lock()
+	defer s.Unlock()
+	s.share_handler_id++
+	s.share_handlers[s.share_handler_id] = handler
+	return s.share_handler_id
+}
+
+func (s *Server) Remove_Share_Handler(id int) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.share_handlers, id)
+}
+
+// deliver share to all handlers, a faulty handler cannot bring down the server
+func (s *Server) notify_share(share Share) {
+	s.RLock()
+	defer s.RUnlock()
+
+	for id, handler := range s.share_handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Warnf("Recovered while delivering share to handler %d", id)
+					logger.Warnf("Stack trace  \n%s", debug.Stack())
+				}
+			}()
+			handler(share)
+		}()
+	}
+}
+
+// returns share difficulty which gives a share every STRATUM_SHARE_TIME at the hash rate observed
+// difficulty changes at most 4 times per retarget, so a lucky or unlucky streak does not swing it wildly
+func vardiff_retarget(difficulty uint64, shares uint64, elapsed time.Duration) uint64 {
+	if elapsed <= 0 {
+		return difficulty
+	}
+
+	hash_rate := float64(difficulty) * float64(shares) / elapsed.Seconds()
+	target := hash_rate * STRATUM_SHARE_TIME.Seconds()
+
+	if min := float64(difficulty) / 4; target < min {
+		target = min
+	}
+	if max := float64(difficulty) * 4; target > max {
+		target = max
+	}
+	if target > 1<<62 {
+		target = 1 << 62
+	}
+	if target < STRATUM_MIN_DIFFICULTY {
+		target = STRATUM_MIN_DIFFICULTY
+	}
+	return uint64(target)
+}
+
+// adjusts share difficulty once retarget interval has passed, connection must be locked
+// returns true if difficulty changed
+func (c *connection) retarget(now time.Time) bool {
+	elapsed := now.Sub(c.retarget_time)
+	if elapsed < STRATUM_RETARGET_INTERVAL {
+		return false
+	}
+
+	difficulty := vardiff_retarget(c.difficulty, c.shares, elapsed)
+	c.shares = 0
+	c.retarget_time = now
+	if difficulty == c.difficulty {
+		return false
+	}
+	logger.Debugf("Stratum miner %s share difficulty changed from %d to %d", c.conn.RemoteAddr(), c.difficulty, difficulty)
+	c.difficulty = difficulty
+	return true
+}
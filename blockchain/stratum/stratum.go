@@ -0,0 +1,466 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package stratum
+
+// this file implements a stratum style mining server, so miners and pools do not need to poll getblocktemplate
+// jobs are pushed on every new block and refreshed periodically so new txs are picked up
+// every connection owns the second half of the block extra nonce, so miners mining to the same address never duplicate work
+
+import "fmt"
+import "net"
+import "time"
+import "sync"
+import "bufio"
+import "math/big"
+import "crypto/rand"
+import "sync/atomic"
+import "encoding/hex"
+import "encoding/json"
+import "encoding/binary"
+
+import log "github.com/sirupsen/logrus"
+
+import "github.com/deroproject/derosuite/block"
+import "github.com/deroproject/derosuite/address"
+import "github.com/deroproject/derosuite/globals"
+import "github.com/deroproject/derosuite/blockchain"
+import "github.com/deroproject/derosuite/structures"
+import "github.com/deroproject/derosuite/transaction"
+
+const STRATUM_MAX_JOBS = 4                     // shares for older jobs of a connection are stale
+const STRATUM_JOB_REFRESH = 15 * time.Second   // jobs are refreshed, so new txs are mined
+const STRATUM_IDLE_TIMEOUT = 5 * time.Minute   // miners must send something within this time, keepalived is enough
+const STRATUM_MAX_REQUEST = 16 * 1024          // requests larger than this disconnect the miner
+const STRATUM_MAX_INVALID_SHARES = 16          // each share costs a PoW, so miners sending garbage are disconnected
+const STRATUM_WRITE_TIMEOUT = 10 * time.Second // slow readers are disconnected
+
+type Server struct {
+	listener           net.Listener
+	Exit_Event         chan bool // server is shutting down
+	event_handler_id   int       // new blocks are delivered by chain
+	new_tip            chan bool // chain must never block, so it only signals
+	connection_counter uint64    // used to make extra nonce unique
+	connections        map[*connection]bool
+	share_handler_id   int
+	share_handlers     map[int]Share_Handler
+	sync.RWMutex
+}
+
+// a job is a block template given to a connection
+type job struct {
+	id                 string
+	bl                 block.Block // extra nonce of connection is already set
+	work               [76]byte
+	height             uint64
+	difficulty         uint64 // share difficulty
+	network_difficulty *big.Int
+	submitted          map[[20]byte]bool // nonce + miner extra nonce, to detect duplicate shares
+}
+
+type connection struct {
+	server         *Server
+	conn           net.Conn
+	id             string // extra nonce in hex
+	extra_nonce    [16]byte
+	address        *address.Address // nil till miner logs in
+	difficulty     uint64           // current share difficulty
+	shares         uint64           // valid shares since last retarget
+	retarget_time  time.Time
+	invalid_shares int // consecutive invalid shares
+	job_counter    uint64
+	jobs           map[string]*job
+	job_ids        []string // oldest first
+	write_lock     sync.Mutex
+	sync.Mutex
+}
+
+var chain *blockchain.Blockchain
+var logger *log.Entry
+
+func new_server() *Server {
+	return &Server{
+		Exit_Event:     make(chan bool),
+		new_tip:        make(chan bool, 1),
+		connections:    map[*connection]bool{},
+		share_handlers: map[int]Share_Handler{},
+	}
+}
+
+// starts stratum server on --stratum-bind
+func Stratum_Start(params map[string]interface{}) (*Server, error) {
+	logger = globals.Logger.WithFields(log.Fields{"com": "STRATUM"}) // all components must use this logger
+	chain = params["chain"].(*blockchain.Blockchain)
+
+	bind, ok := globals.Arguments["--stratum-bind"].(string)
+	if !ok {
+		return nil, fmt.Errorf("--stratum-bind address is missing")
+	}
+	addr, err := net.ResolveTCPAddr("tcp", bind)
+	if err != nil {
+		return nil, fmt.Errorf("--stratum-bind address is invalid, err = %s", err)
+	}
+
+	s := new_server()
+	if s.listener, err = net.Listen("tcp", addr.String()); err != nil {
+		return nil, err
+	}
+	s.event_handler_id = chain.Add_Event_Handler(s.chain_event)
+
+	go s.accept_loop()
+	go s.job_loop()
+	logger.Infof("Stratum server listening on %s", s.listener.Addr())
+	atomic.AddUint32(&globals.Subsystem_Active, 1) // increment subsystem
+
+	return s, nil
+}
+
+// shutdown the stratum server, all miners are disconnected
+func (s *Server) Stratum_Stop() {
+	s.Lock()
+	defer s.Unlock()
+	close(s.Exit_Event)
+	chain.Remove_Event_Handler(s.event_handler_id)
+	s.listener.Close()
+	for c := range s.connections {
+		c.conn.Close()
+	}
+	logger.Infof("Stratum Shutdown")
+	atomic.AddUint32(&globals.Subsystem_Active, ^uint32(0)) // this decrement 1 fom subsystem
+}
+
+// called by chain while it is locked, so it must never block
+func (s *Server) chain_event(event blockchain.Event) {
+	if event.Type == blockchain.EVENT_NEW_BLOCK {
+		select {
+		case s.new_tip <- true:
+		default: // a push is already pending
+		}
+	}
+}
+
+func (s *Server) accept_loop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.Exit_Event:
+			default:
+				logger.Warnf("Stratum server stopped accepting connections err %s", err)
+			}
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// pushes new jobs to all miners, old jobs are stale once a new block arrives
+func (s *Server) job_loop() {
+	ticker := time.NewTicker(STRATUM_JOB_REFRESH)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.Exit_Event:
+			return
+		case <-s.new_tip:
+			s.push_jobs(true)
+		case <-ticker.C:
+			s.push_jobs(false)
+		}
+	}
+}
+
+func (s *Server) push_jobs(clean bool) {
+	var connections []*connection
+	s.RLock()
+	for c := range s.connections {
+		connections = append(connections, c)
+	}
+	s.RUnlock()
+
+	now := time.Now()
+	for _, c := range connections {
+		c.Lock()
+		if c.address == nil { // not logged in yet
+			c.Unlock()
+			continue
+		}
+		c.retarget(now)
+		j := c.new_job(clean)
+		c.Unlock()
+		c.notify(structures.STRATUM_JOB, j)
+	}
+}
+
+// serves a single miner till it disconnects
+func (s *Server) handle(conn net.Conn) {
+	c := &connection{server: s, conn: conn, jobs: map[string]*job{}}
+	binary.BigEndian.PutUint64(c.extra_nonce[:], atomic.AddUint64(&s.connection_counter, 1))
+	rand.Read(c.extra_nonce[8:]) // so work differs across restarts
+	c.id = fmt.Sprintf("%x", c.extra_nonce[:])
+
+	s.Lock()
+	s.connections[c] = true
+	s.Unlock()
+
+	defer func() {
+		s.Lock()
+		delete(s.connections, c)
+		s.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), STRATUM_MAX_REQUEST)
+	for {
+		conn.SetReadDeadline(time.Now().Add(STRATUM_IDLE_TIMEOUT))
+		if !scanner.Scan() {
+			return
+		}
+
+		var request structures.Stratum_Message
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			logger.Debugf("Stratum miner %s sent malformed request, disconnecting err %s", conn.RemoteAddr(), err)
+			return
+		}
+
+		result, serr := c.dispatch(request)
+		if len(request.ID) != 0 {
+			if err := c.reply(request.ID, result, serr); err != nil {
+				return
+			}
+		}
+
+		c.Lock()
+		invalid_shares := c.invalid_shares
+		c.Unlock()
+		if invalid_shares >= STRATUM_MAX_INVALID_SHARES {
+			logger.Warnf("Stratum miner %s sent %d invalid shares, disconnecting", conn.RemoteAddr(), invalid_shares)
+			return
+		}
+	}
+}
+
+func (c *connection) dispatch(request structures.Stratum_Message) (interface{}, *structures.Stratum_Error) {
+	switch request.Method {
+	case structures.STRATUM_LOGIN:
+		var p structures.Stratum_Login_Params
+		if err := json.Unmarshal(request.Params, &p); err != nil {
+			return nil, &structures.Stratum_Error{Code: -32602, Message: "Invalid params"}
+		}
+		return c.login(p)
+
+	case structures.STRATUM_SUBMIT:
+		var p structures.Stratum_Submit_Params
+		if err := json.Unmarshal(request.Params, &p); err != nil {
+			return nil, &structures.Stratum_Error{Code: -32602, Message: "Invalid params"}
+		}
+		return c.submit(p)
+
+	case structures.STRATUM_KEEPALIVED:
+		return structures.Stratum_Status_Result{Status: "KEEPALIVED"}, nil
+	}
+	return nil, &structures.Stratum_Error{Code: -32601, Message: "Method not found"}
+}
+
+func (c *connection) login(p structures.Stratum_Login_Params) (interface{}, *structures.Stratum_Error) {
+	miner_address, err := globals.ParseValidateAddress(p.Login)
+	if err != nil {
+		return nil, &structures.Stratum_Error{Code: -1, Message: "Wallet address could not be parsed"}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if c.address != nil {
+		return nil, &structures.Stratum_Error{Code: -1, Message: "Already logged in"}
+	}
+	c.address = miner_address
+	c.difficulty = STRATUM_START_DIFFICULTY
+	c.retarget_time = time.Now()
+
+	logger.Infof("Stratum miner %s logged in, agent \"%s\" mining to %s", c.conn.RemoteAddr(), p.Agent, miner_address)
+	return structures.Stratum_Login_Result{ID: c.id, Job: c.new_job(true), Status: "OK"}, nil
+}
+
+// verifies a share and submits it to chain if it is a block
+func (c *connection) submit(p structures.Stratum_Submit_Params) (result interface{}, serr *structures.Stratum_Error) {
+	c.Lock()
+	if c.address == nil || p.ID != c.id {
+		c.Unlock()
+		return nil, &structures.Stratum_Error{Code: -1, Message: "Unauthenticated"}
+	}
+	share := Share{Connection: c.id, Address: c.address.String(), Job_ID: p.Job_ID}
+	c.Unlock()
+
+	defer func() {
+		c.Lock()
+		if share.Valid {
+			c.invalid_shares = 0
+		} else if share.Reason != SHARE_STALE { // stale shares are not the fault of miner
+			c.invalid_shares++
+		}
+		c.Unlock()
+		c.server.notify_share(share)
+	}()
+
+	nonce, err := hex.DecodeString(p.Nonce)
+	extra_nonce, err1 := hex.DecodeString(p.Extra_Nonce)
+	if err != nil || err1 != nil || len(nonce) != 4 || len(extra_nonce) != 16 {
+		share.Reason = SHARE_INVALID
+		return nil, &structures.Stratum_Error{Code: -1, Message: "Invalid nonce"}
+	}
+
+	var key [20]byte
+	copy(key[:], nonce)
+	copy(key[4:], extra_nonce)
+
+	c.Lock()
+	j, ok := c.jobs[p.Job_ID]
+	if !ok {
+		c.Unlock()
+		share.Reason = SHARE_STALE
+		return nil, &structures.Stratum_Error{Code: -1, Message: structures.STRATUM_STALE_JOB}
+	}
+	if j.submitted[key] {
+		c.Unlock()
+		share.Reason = SHARE_DUPLICATE
+		return nil, &structures.Stratum_Error{Code: -1, Message: "Duplicate share"}
+	}
+	j.submitted[key] = true
+	bl, work := j.bl, j.work
+	share.Height = j.height
+	c.Unlock()
+
+	copy(work[39:43], nonce)
+	copy(work[43:59], extra_nonce)
+	if err = bl.CopyNonceFromBlockWork(work[:]); err != nil {
+		share.Reason = SHARE_INVALID
+		return nil, &structures.Stratum_Error{Code: -1, Message: "Invalid nonce"}
+	}
+
+	pow := bl.GetPoWHash()
+	if !blockchain.CheckPowHash(pow, j.difficulty) {
+		share.Reason = SHARE_LOW_DIFFICULTY
+		return nil, &structures.Stratum_Error{Code: -1, Message: "Low difficulty share"}
+	}
+	share.Valid = true
+	share.Difficulty = j.difficulty
+
+	if blockchain.CheckPowHashBig(pow, j.network_difficulty) {
+		share.Block = true
+		share.BLID, share.Accepted, err = chain.Accept_new_block(bl.Serialize(), work[:])
+		if share.Accepted {
+			logger.Infof("Stratum miner %s found block %s at height %d", c.conn.RemoteAddr(), share.BLID, share.Height)
+		} else {
+			logger.Warnf("Stratum miner %s block %s rejected by chain err %v", c.conn.RemoteAddr(), share.BLID, err)
+		}
+	}
+
+	c.Lock()
+	c.shares++
+	var next *structures.Stratum_Job
+	if c.retarget(time.Now()) { // miner gets work at new difficulty immediately
+		j := c.new_job(false)
+		next = &j
+	}
+	c.Unlock()
+
+	if next != nil {
+		defer c.notify(structures.STRATUM_JOB, *next) // after the reply
+	}
+	return structures.Stratum_Status_Result{Status: "OK"}, nil
+}
+
+// creates a new job for the miner, connection must be locked
+// if clean is set, all older jobs are stale
+func (c *connection) new_job(clean bool) structures.Stratum_Job {
+	bl, _, _, _ := chain.Create_new_block_template_mining(chain.Get_Top_ID(), *c.address, 1)
+
+	var extra_nonce [32]byte
+	copy(extra_nonce[16:], c.extra_nonce[:])
+	bl.SetExtraNonce(extra_nonce[:])
+
+	c.job_counter++
+	j := &job{
+		id:                 fmt.Sprintf("%d", c.job_counter),
+		bl:                 bl,
+		height:             bl.Miner_TX.Vin[0].(transaction.Txin_gen).Height,
+		difficulty:         c.difficulty,
+		network_difficulty: chain.Get_Difficulty_At_Tips(nil, bl.Tips),
+		submitted:          map[[20]byte]bool{},
+	}
+	copy(j.work[:], bl.GetBlockWork())
+
+	// shares harder than a block are pointless
+	if j.network_difficulty.IsUint64() && j.network_difficulty.Uint64() < j.difficulty {
+		j.difficulty = j.network_difficulty.Uint64()
+	}
+
+	if clean {
+		c.jobs = map[string]*job{}
+		c.job_ids = c.job_ids[:0]
+	}
+	c.jobs[j.id] = j
+	c.job_ids = append(c.job_ids, j.id)
+	if len(c.job_ids) > STRATUM_MAX_JOBS {
+		delete(c.jobs, c.job_ids[0])
+		c.job_ids = c.job_ids[1:]
+	}
+
+	return structures.Stratum_Job{
+		Job_ID:             j.id,
+		Blob:               fmt.Sprintf("%x", j.work[:]),
+		Difficulty:         j.difficulty,
+		Network_Difficulty: j.network_difficulty.Uint64(),
+		Height:             j.height,
+	}
+}
+
+func (c *connection) reply(id json.RawMessage, result interface{}, serr *structures.Stratum_Error) error {
+	response := structures.Stratum_Message{ID: id, JSONRPC: "2.0", Error: serr}
+	if serr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		response.Result = data
+	}
+	return c.write(response)
+}
+
+func (c *connection) notify(method string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(structures.Stratum_Message{JSONRPC: "2.0", Method: method, Params: data})
+}
+
+// writes a single line, a failed write closes the connection
+func (c *connection) write(message structures.Stratum_Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	c.write_lock.Lock()
+	defer c.write_lock.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(STRATUM_WRITE_TIMEOUT))
+	if _, err = c.conn.Write(append(data, '\n')); err != nil {
+		c.conn.Close()
+	}
+	return err
+}
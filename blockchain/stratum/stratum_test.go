@@ -0,0 +1,232 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package stratum
+
+import "os"
+import "net"
+import "time"
+import "bufio"
+import "testing"
+import "io/ioutil"
+import "encoding/hex"
+import "encoding/json"
+
+import log "github.com/sirupsen/logrus"
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/address"
+import "github.com/deroproject/derosuite/globals"
+import "github.com/deroproject/derosuite/blockchain"
+import "github.com/deroproject/derosuite/structures"
+
+// talks to the server like a miner would, jobs pushed by server are queued
+type test_client struct {
+	t       *testing.T
+	conn    net.Conn
+	scanner *bufio.Scanner
+	id      uint64
+	jobs    []structures.Stratum_Job
+}
+
+func (c *test_client) read() (message structures.Stratum_Message) {
+	c.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	if !c.scanner.Scan() {
+		c.t.Fatalf("Cannot read from stratum server err %v", c.scanner.Err())
+	}
+	if err := json.Unmarshal(c.scanner.Bytes(), &message); err != nil {
+		c.t.Fatalf("Cannot parse stratum message err %s", err)
+	}
+	if message.Method == structures.STRATUM_JOB {
+		var j structures.Stratum_Job
+		if err := json.Unmarshal(message.Params, &j); err != nil {
+			c.t.Fatalf("Cannot parse job err %s", err)
+		}
+		c.jobs = append(c.jobs, j)
+	}
+	return
+}
+
+func (c *test_client) call(method string, params interface{}, result interface{}) *structures.Stratum_Error {
+	c.id++
+	id, _ := json.Marshal(c.id)
+	data, _ := json.Marshal(params)
+	request, _ := json.Marshal(structures.Stratum_Message{ID: id, JSONRPC: "2.0", Method: method, Params: data})
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := c.conn.Write(append(request, '\n')); err != nil {
+		c.t.Fatalf("Cannot write to stratum server err %s", err)
+	}
+
+	for {
+		if message := c.read(); string(message.ID) == string(id) {
+			if message.Error == nil {
+				json.Unmarshal(message.Result, result)
+			}
+			return message.Error
+		}
+	}
+}
+
+func (c *test_client) next_job() structures.Stratum_Job {
+	for len(c.jobs) == 0 {
+		c.read()
+	}
+	j := c.jobs[0]
+	c.jobs = c.jobs[1:]
+	return j
+}
+
+func Test_Stratum(t *testing.T) {
+	dir, err := ioutil.TempDir("", "derod_stratum_test")
+	if err != nil {
+		t.Fatalf("Cannot create temp dir err %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	globals.Logger = log.New()
+	globals.Logger.SetLevel(log.WarnLevel)
+	globals.Config = config.Testnet
+	globals.Arguments = map[string]interface{}{"--data-dir": dir, "--testnet": true, "--debug": false, "--boltdb": false, "--badgerdb": false,
+		"--disable-checkpoints": true, "--lowcpuram": false, "--sync-node": false}
+	logger = globals.Logger.WithFields(log.Fields{"com": "STRATUM"})
+
+	// simulator difficulty is 1, so every share is a block
+	chain, err = blockchain.Blockchain_Start(map[string]interface{}{"--simulator": true, "--memdb": true})
+	if err != nil {
+		t.Fatalf("Cannot start chain err %s", err)
+	}
+	defer chain.Shutdown()
+
+	s := new_server()
+	s.event_handler_id = chain.Add_Event_Handler(s.chain_event)
+	defer chain.Remove_Event_Handler(s.event_handler_id)
+	go s.job_loop()
+	defer close(s.Exit_Event)
+
+	shares := make(chan Share, 16)
+	s.Add_Share_Handler(func(share Share) { shares <- share })
+
+	client_conn, server_conn := net.Pipe()
+	defer client_conn.Close()
+	go s.handle(server_conn)
+	c := &test_client{t: t, conn: client_conn, scanner: bufio.NewScanner(client_conn)}
+
+	var status structures.Stratum_Status_Result
+	if serr := c.call(structures.STRATUM_SUBMIT, structures.Stratum_Submit_Params{Job_ID: "1"}, &status); serr == nil {
+		t.Fatalf("Submit must fail before login")
+	}
+	var login structures.Stratum_Login_Result
+	if serr := c.call(structures.STRATUM_LOGIN, structures.Stratum_Login_Params{Login: "invalid"}, &login); serr == nil {
+		t.Fatalf("Login must fail with invalid address")
+	}
+
+	_, spend := crypto.NewKeyPair()
+	_, view := crypto.NewKeyPair()
+	miner_address := address.NewAddressFromKeys(*spend, *view)
+	miner_address.Network = globals.Config.Public_Address_Prefix
+	if serr := c.call(structures.STRATUM_LOGIN, structures.Stratum_Login_Params{Login: miner_address.String(), Agent: "test"}, &login); serr != nil {
+		t.Fatalf("Login failed err %+v", serr)
+	}
+
+	blob, err := hex.DecodeString(login.Job.Blob)
+	if err != nil || len(blob) != 76 || hex.EncodeToString(blob[59:75]) != login.ID {
+		t.Fatalf("Job blob does not carry connection extra nonce %+v", login)
+	}
+	if login.Job.Difficulty != 1 || login.Job.Height != uint64(chain.Get_Height()+1) {
+		t.Fatalf("Wrong job %+v at height %d", login.Job, chain.Get_Height())
+	}
+
+	submit := structures.Stratum_Submit_Params{ID: login.ID, Job_ID: login.Job.Job_ID, Nonce: "01020304", Extra_Nonce: "000102030405060708090a0b0c0d0e0f"}
+
+	// make shares harder than possible, share is rejected and cannot be resubmitted
+	s.RLock()
+	for conn := range s.connections {
+		conn.Lock()
+		conn.jobs[login.Job.Job_ID].difficulty = 1 << 62
+		conn.Unlock()
+	}
+	s.RUnlock()
+	if serr := c.call(structures.STRATUM_SUBMIT, submit, &status); serr == nil {
+		t.Fatalf("Low difficulty share must be rejected")
+	}
+	if share := <-shares; share.Valid || share.Reason != SHARE_LOW_DIFFICULTY {
+		t.Fatalf("Wrong share %+v", share)
+	}
+	if serr := c.call(structures.STRATUM_SUBMIT, submit, &status); serr == nil {
+		t.Fatalf("Duplicate share must be rejected")
+	}
+	if share := <-shares; share.Valid || share.Reason != SHARE_DUPLICATE {
+		t.Fatalf("Wrong share %+v", share)
+	}
+
+	// a share meeting network difficulty becomes a block, and a new job follows
+	height := chain.Get_Height()
+	submit.Nonce = "05060708"
+	s.RLock()
+	for conn := range s.connections {
+		conn.Lock()
+		conn.jobs[login.Job.Job_ID].difficulty = 1
+		conn.Unlock()
+	}
+	s.RUnlock()
+	if serr := c.call(structures.STRATUM_SUBMIT, submit, &status); serr != nil || status.Status != "OK" {
+		t.Fatalf("Share rejected err %+v", serr)
+	}
+	if share := <-shares; !share.Valid || !share.Block || !share.Accepted || share.Difficulty != 1 || share.Address != miner_address.String() {
+		t.Fatalf("Wrong share %+v", share)
+	}
+	if chain.Get_Height() != height+1 {
+		t.Fatalf("Block was not added to chain, height %d", chain.Get_Height())
+	}
+	if j := c.next_job(); j.Height != uint64(height+2) || j.Job_ID == login.Job.Job_ID {
+		t.Fatalf("Wrong job after new block %+v", j)
+	}
+
+	// work on older tips is stale
+	submit.Nonce = "090a0b0c"
+	if serr := c.call(structures.STRATUM_SUBMIT, submit, &status); serr == nil {
+		t.Fatalf("Stale share must be rejected")
+	}
+	if share := <-shares; share.Valid || share.Reason != SHARE_STALE {
+		t.Fatalf("Wrong share %+v", share)
+	}
+
+	if serr := c.call(structures.STRATUM_KEEPALIVED, nil, &status); serr != nil || status.Status != "KEEPALIVED" {
+		t.Fatalf("Keepalived failed err %+v", serr)
+	}
+}
+
+func Test_Vardiff(t *testing.T) {
+	// miner finding shares at expected rate keeps its difficulty
+	if d := vardiff_retarget(10000, 6, time.Minute); d != 10000 {
+		t.Fatalf("Steady miner difficulty changed to %d", d)
+	}
+	// twice as fast doubles it
+	if d := vardiff_retarget(10000, 12, time.Minute); d != 20000 {
+		t.Fatalf("Fast miner difficulty %d", d)
+	}
+	// changes are bounded per retarget
+	if d := vardiff_retarget(10000, 600, time.Minute); d != 40000 {
+		t.Fatalf("Very fast miner difficulty %d", d)
+	}
+	if d := vardiff_retarget(10000, 0, time.Minute); d != 2500 {
+		t.Fatalf("Idle miner difficulty %d", d)
+	}
+	if d := vardiff_retarget(STRATUM_MIN_DIFFICULTY, 0, time.Minute); d != STRATUM_MIN_DIFFICULTY {
+		t.Fatalf("Difficulty went below minimum %d", d)
+	}
+}
@@ -66,6 +66,14 @@ func clean_up_valid_cache() {
 			return true
 		})
 
+		// preverified blocks which never got added to chain
+		pow_hash_cache.Range(func(k, value interface{}) bool {
+			if current_time.Sub(value.(pow_hash_entry).first_seen).Round(time.Second).Seconds() > 3600 {
+				pow_hash_cache.Delete(k)
+			}
+			return true
+		})
+
 	}
 }
 
@@ -116,6 +124,56 @@ func (chain *Blockchain) Verify_Transaction_Coinbase(dbtx storage.DBTX, cbl *blo
 // the transaction has already been deserialized thats it
 //
 func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_version int64, tx *transaction.Transaction) (result bool) {
+	return chain.Verify_Transactions_NonCoinbase(dbtx, hf_version, []*transaction.Transaction{tx})[0]
+}
+
+// same as Verify_Transaction_NonCoinbase, but for many transactions at once, results[i] is the result of txs[i]
+// ring signatures of all the txs are verified together, so that their bulletproofs are batch verified
+func (chain *Blockchain) Verify_Transactions_NonCoinbase(dbtx storage.DBTX, hf_version int64, txs []*transaction.Transaction) (results []bool) {
+	results = make([]bool, len(txs))
+	cached := make([]bool, len(txs))
+	special_hashes := make([]crypto.Hash, len(txs))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(txs))
+	for i := range txs {
+		go func(i int) {
+			defer wg.Done()
+			results[i], cached[i], special_hashes[i] = chain.verify_transaction_noncoinbase(dbtx, hf_version, txs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	var pending []int // txs whose ring signature must be verified
+	var sigs []*ringct.RctSig
+	for i := range txs {
+		if results[i] && !cached[i] {
+			pending = append(pending, i)
+			sigs = append(sigs, txs[i].RctSignature)
+		}
+	}
+
+	// check the ring signatures
+	for j, result := range ringct.VerifyBatch(sigs) {
+		i := pending[j]
+		if !result {
+			//logger.Infof("tx expanded %+v\n", txs[i].RctSignature.MixRing)
+			logger.WithFields(log.Fields{"txid": txs[i].GetHash()}).Warnf("TX RCT Signature failed")
+			results[i] = false
+			continue
+		}
+
+		// signature got verified, cache it
+		transaction_valid_cache.Store(special_hashes[i], time.Now())
+		//logger.Infof("TX validity marked in cache %s ",tx_hash)
+	}
+
+	return
+}
+
+// does all the checks except the ring signature, which is left to the caller
+// cached is true if the ring signature was already verified, special_hash is used to cache the result
+func (chain *Blockchain) verify_transaction_noncoinbase(dbtx storage.DBTX, hf_version int64, tx *transaction.Transaction) (result bool, cached bool, special_hash crypto.Hash) {
 	result = false
 
 	var tx_hash crypto.Hash
@@ -131,29 +189,29 @@ func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_ve
 	tx_hash = tx.GetHash()
 
 	if tx.Version != 2 {
-		return false
+		return
 	}
 
 	// make sure atleast 1 vin and 1 vout are there
 	if len(tx.Vin) < 1 || len(tx.Vout) < 1 {
 		logger.WithFields(log.Fields{"txid": tx_hash}).Warnf("Incoming TX does NOT have atleast 1 vin and 1 vout")
-		return false
+		return
 	}
 
 	// this means some other checks have failed somewhere else
 	if tx.IsCoinbase() { // transaction coinbase must never come here
 		logger.WithFields(log.Fields{"txid": tx_hash}).Warnf("Coinbase tx in non coinbase path, Please investigate")
-		return false
+		return
 	}
 
 	// Vin can be only specific type rest all make the fail case
 	for i := 0; i < len(tx.Vin); i++ {
 		switch tx.Vin[i].(type) {
 		case transaction.Txin_gen:
-			return false // this is for coinbase so fail it
+			return // this is for coinbase so fail it
 		case transaction.Txin_to_key: // pass
 		default:
-			return false
+			return
 		}
 	}
 
@@ -180,11 +238,11 @@ func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_ve
 
 			if !public_key.Public_Key_Valid() { // if public_key is not valid ( not a point on the curve reject the TX)
 				logger.WithFields(log.Fields{"txid": tx_hash}).Warnf("TX public is INVALID %s ", public_key)
-				return false
+				return
 
 			}
 		default:
-			return false
+			return
 		}
 	}
 
@@ -192,7 +250,7 @@ func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_ve
 	for i := 0; i < len(tx.Vout); i++ {
 		if tx.Vout[i].Amount != 0 {
 			logger.WithFields(log.Fields{"txid": tx_hash, "Amount": tx.Vout[i].Amount}).Warnf("Amount must be zero in ringCT world")
-			return false
+			return
 		}
 	}
 
@@ -204,17 +262,17 @@ func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_ve
 
 		if mixin < config.MIN_MIXIN {
 			logger.WithFields(log.Fields{"txid": tx_hash, "Mixin": mixin}).Warnf("Mixin cannot be more than %d.", config.MIN_MIXIN)
-			return false
+			return
 		}
 		if mixin >= config.MAX_MIXIN {
 			logger.WithFields(log.Fields{"txid": tx_hash, "Mixin": mixin}).Warnf("Mixin cannot be more than %d.", config.MAX_MIXIN)
-			return false
+			return
 		}
 
 		for i := 0; i < len(tx.Vin); i++ {
 			if mixin != len(tx.Vin[i].(transaction.Txin_to_key).Key_offsets) {
 				logger.WithFields(log.Fields{"txid": tx_hash, "Mixin": mixin}).Warnf("Mixin must be same for entire TX in ringCT world")
-				return false
+				return
 			}
 		}
 	}
@@ -228,7 +286,7 @@ func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_ve
 			ring_member += tx.Vin[i].(transaction.Txin_to_key).Key_offsets[j]
 			if _, ok := ring_members[ring_member]; ok {
 				logger.WithFields(log.Fields{"txid": tx_hash, "input_index": i}).Warnf("Duplicate ring member within the TX")
-				return false
+				return
 			}
 			ring_members[ring_member] = true // add member to ring member
 		}
@@ -246,7 +304,7 @@ func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_ve
 					"txid":   tx_hash,
 					"kimage": tx.Vin[i].(transaction.Txin_to_key).K_image,
 				}).Warnf("TX using duplicate inputs within the TX")
-				return false
+				return
 			}
 			kimages[tx.Vin[i].(transaction.Txin_to_key).K_image] = true // add element to map for next check
 		}
@@ -265,7 +323,7 @@ func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_ve
 				"mult_result": *mult_result,
 				"identity":    crypto.Identity,
 			}).Warnf("TX contains a low order key image attack, but we are already safeguarded")
-			return false
+			return
 		}
 	}
 
@@ -273,16 +331,28 @@ func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_ve
 	if hf_version >= 2 {
 		switch tx.RctSignature.Get_Sig_Type() {
 		case ringct.RCTTypeSimple, ringct.RCTTypeFull:
-			return false
+			return
 		}
 	}
 
+	// aggregated bulletproofs are allowed only after hard fork 6
+	if hf_version < 6 && tx.RctSignature.Get_Sig_Type() == ringct.RCTTypeSimpleBulletproofAggregate {
+		rlog.Warnf("TX %s has aggregated bulletproofs, they are not allowed at hf_version %d", tx_hash, hf_version)
+		return
+	}
+
+	// CLSAG ring signatures are allowed only after hard fork 7
+	if hf_version < 7 && tx.RctSignature.Get_Sig_Type() == ringct.RCTTypeCLSAG {
+		rlog.Warnf("TX %s has CLSAG ring signatures, they are not allowed at hf_version %d", tx_hash, hf_version)
+		return
+	}
+
 	// check whether the TX contains a signature or NOT
 	switch tx.RctSignature.Get_Sig_Type() {
-	case ringct.RCTTypeSimpleBulletproof, ringct.RCTTypeSimple, ringct.RCTTypeFull: // default case, pass through
+	case ringct.RCTTypeSimpleBulletproof, ringct.RCTTypeSimpleBulletproofAggregate, ringct.RCTTypeCLSAG, ringct.RCTTypeSimple, ringct.RCTTypeFull: // default case, pass through
 	default:
 		logger.WithFields(log.Fields{"txid": tx_hash}).Warnf("TX does NOT contain a ringct signature. It is NOT possible")
-		return false
+		return
 	}
 
 	// check tx size for validity
@@ -290,7 +360,7 @@ func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_ve
 		tx_serialized = tx.Serialize()
 		if len(tx_serialized) >= config.CRYPTONOTE_MAX_TX_SIZE {
 			rlog.Warnf("tx %s rejected Size(%d) is more than allowed(%d)", tx_hash, len(tx.Serialize()), config.CRYPTONOTE_MAX_TX_SIZE)
-			return false
+			return
 		}
 	}
 
@@ -300,7 +370,7 @@ func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_ve
 	//rlog.Debugf("txverify tx %s hf_version %d", tx_hash, hf_version )
 	if !chain.Expand_Transaction_v2(dbtx, hf_version, tx) {
 		rlog.Warnf("TX %s inputs could not be expanded or inputs are NOT mature", tx_hash)
-		return false
+		return
 	}
 
 	//logger.Infof("Expanded tx %+v", tx.RctSignature)
@@ -317,8 +387,9 @@ func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_ve
 	// build the buffer for special hash
 	// DO NOT skip anything, use full serialized tx, it is used while building keccak hash
 	// use everything from tx expansion etc
-	for i := 0; i < len(tx.Vin); i++ { // append all mlsag sigs
-		tmp_buffer = append(tmp_buffer, tx.RctSignature.MlsagSigs[i].II[0][:]...)
+	for i := 0; i < len(tx.Vin); i++ { // append all key images, these are same as used by mlsag/clsag sigs
+		k_image := tx.Vin[i].(transaction.Txin_to_key).K_image
+		tmp_buffer = append(tmp_buffer, k_image[:]...)
 	}
 	for i := 0; i < len(tx.RctSignature.MixRing); i++ {
 		for j := 0; j < len(tx.RctSignature.MixRing[i]); j++ {
@@ -328,31 +399,18 @@ func (chain *Blockchain) Verify_Transaction_NonCoinbase(dbtx storage.DBTX, hf_ve
 	}
 
 	// 1 less allocation this way
-	special_hash := crypto.Keccak256(tx_serialized, tmp_buffer)
+	special_hash = crypto.Keccak256(tx_serialized, tmp_buffer)
 
 	if _, ok := transaction_valid_cache.Load(special_hash); ok {
 		//logger.Infof("Found in cache %s ",tx_hash)
-		return true
+		cached = true
 	} else {
 		//logger.Infof("TX not found in cache %s len %d ",tx_hash, len(tmp_buffer))
 	}
 
-	// check the ring signature
-	if !tx.RctSignature.Verify() {
-
-		//logger.Infof("tx expanded %+v\n", tx.RctSignature.MixRing)
-		logger.WithFields(log.Fields{"txid": tx_hash}).Warnf("TX RCT Signature failed")
-		return false
-
-	}
-
-	// signature got verified, cache it
-	transaction_valid_cache.Store(special_hash, time.Now())
-	//logger.Infof("TX validity marked in cache %s ",tx_hash)
-
 	//logger.WithFields(log.Fields{"txid": tx_hash}).Debugf("TX successfully verified")
 
-	return true
+	return true, cached, special_hash
 }
 
 // double spend check is separate from the core checks ( due to softforks )
@@ -0,0 +1,226 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+// this file implements an offline integrity checker for the chain database
+// it replays the client protocol from topoheight 0 till the top, keeping the expected state in memory
+// every derived value stored in the DB is compared with the replayed one
+// a crash in the middle of Add_Complete_Block may leave these inconsistent
+
+import "fmt"
+import "encoding/binary"
+
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/transaction"
+
+// while repairing, changes are committed every so many blocks, so as tx do not grow too large
+const VERIFY_DB_COMMIT_INTERVAL = 1000
+
+// walks the chain in topo order and reports every mismatch
+// if repair is true, the replayed values are written back to the DB
+// returns number of mismatches found
+func (chain *Blockchain) Verify_DB(repair bool) (mismatches int, err error) {
+	chain.Lock()
+	defer chain.Unlock()
+
+	dbtx, err := chain.store.BeginTX(repair)
+	if err != nil {
+		return
+	}
+	defer func() { // commit or discard whatever is pending
+		if dbtx == nil {
+			return
+		}
+		if repair && err == nil {
+			err = dbtx.Commit()
+		} else {
+			dbtx.Rollback()
+		}
+	}()
+
+	report := func(topoheight int64, blid crypto.Hash, format string, args ...interface{}) {
+		mismatches++
+		logger.Warnf("topoheight %d block %s: %s", topoheight, blid, fmt.Sprintf(format, args...))
+	}
+
+	top_topoheight := chain.Load_TOPO_HEIGHT(dbtx)
+	if top_topoheight == 0 { // client protocol runs on genesis block only when the next block is added
+		logger.Infof("Chain database contains only genesis block, nothing to verify")
+		return
+	}
+	logger.Infof("Verifying chain database from topoheight 0 to %d, repair %t", top_topoheight, repair)
+
+	spent_keyimages := map[crypto.Hash]int64{} // keyimages consumed by valid txs, with height
+	past_coins_generated := uint64(0)
+	output_index_start := int64(0)
+
+	for topoheight := int64(0); topoheight <= top_topoheight; topoheight++ {
+		if repair && topoheight > 0 && topoheight%VERIFY_DB_COMMIT_INTERVAL == 0 {
+			if err = dbtx.Commit(); err != nil {
+				dbtx = nil
+				return
+			}
+			if dbtx, err = chain.store.BeginTX(true); err != nil {
+				dbtx = nil
+				return
+			}
+		}
+
+		blid, err1 := chain.Load_Block_Topological_order_at_index(dbtx, topoheight)
+		if err1 != nil { // nothing can be replayed after a hole in topo order
+			return mismatches + 1, fmt.Errorf("No block at topoheight %d err %s, chain cannot be verified further", topoheight, err1)
+		}
+
+		bl, err1 := chain.Load_BL_FROM_ID(dbtx, blid)
+		if err1 != nil {
+			return mismatches + 1, fmt.Errorf("Cannot load block %s at topoheight %d err %s", blid, topoheight, err1)
+		}
+
+		// both directions of the topo order must agree
+		if stored := chain.Load_Block_Topological_order(dbtx, blid); stored != topoheight {
+			report(topoheight, blid, "topo order points to %d", stored)
+			if repair {
+				chain.Store_Block_Topological_order(dbtx, blid, topoheight)
+			}
+		}
+
+		height := chain.Calculate_Height_At_Tips(dbtx, bl.Tips)
+		hard_fork_version_current := chain.Get_Current_Version_at_Height(height)
+
+		// replay client protocol, a tx is valid only if none of its key images was consumed earlier
+		total_fees := uint64(0)
+		output_count := int64(1) // miner tx has a single output
+		for _, txid := range bl.Tx_hashes {
+			tx, err1 := chain.Load_TX_FROM_ID(dbtx, txid)
+			if err1 != nil {
+				return mismatches + 1, fmt.Errorf("Cannot load tx %s of block %s err %s", txid, blid, err1)
+			}
+
+			valid := true
+			for i := range tx.Vin {
+				if _, spent := spent_keyimages[crypto.Hash(tx.Vin[i].(transaction.Txin_to_key).K_image)]; spent {
+					valid = false
+				}
+			}
+
+			if stored := chain.IS_TX_Valid(dbtx, blid, txid); stored != valid {
+				report(topoheight, blid, "tx %s validity stored %t expected %t", txid, stored, valid)
+				if repair {
+					chain.mark_TX(dbtx, blid, txid, valid)
+				}
+			}
+
+			if valid {
+				for i := range tx.Vin {
+					spent_keyimages[crypto.Hash(tx.Vin[i].(transaction.Txin_to_key).K_image)] = height
+				}
+				total_fees += tx.RctSignature.Get_TX_Fee()
+				output_count += int64(len(tx.Vout))
+			}
+		}
+
+		// rewards and already generated coins
+		base_reward, total_reward, already_generated_coins := chain.calculate_block_rewards(dbtx, bl, blid, topoheight, hard_fork_version_current, past_coins_generated, total_fees)
+		for _, r := range []struct {
+			planet   []byte
+			expected uint64
+		}{{PLANET_MINERTX_REWARD, total_reward}, {PLANET_BASEREWARD, base_reward}, {PLANET_ALREADY_GENERATED_COINS, already_generated_coins}} {
+			if stored, err1 := dbtx.LoadUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], r.planet); err1 != nil || stored != r.expected {
+				report(topoheight, blid, "%s stored %d expected %d", r.planet, stored, r.expected)
+				if repair {
+					dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], r.planet, r.expected)
+				}
+			}
+		}
+		past_coins_generated = already_generated_coins
+
+		// output index range must continue from previous block, and the index must point back to this block
+		output_index_end := output_index_start + output_count
+		start, end := chain.Get_Block_Output_Index(dbtx, blid)
+		index_valid := start == output_index_start && end == output_index_end
+		if !index_valid {
+			report(topoheight, blid, "output index range stored [%d,%d) expected [%d,%d)", start, end, output_index_start, output_index_end)
+		} else {
+			first, ok1 := chain.load_output_index(dbtx, uint64(output_index_start))
+			last, ok2 := chain.load_output_index(dbtx, uint64(output_index_end-1))
+			if !ok1 || !ok2 || first.BLID != blid || last.BLID != blid || first.Index_Global != uint64(output_index_start) || last.Index_Global != uint64(output_index_end-1) {
+				report(topoheight, blid, "output index data does not belong to block")
+				index_valid = false
+			}
+		}
+		if !index_valid && repair {
+			chain.write_output_index(dbtx, blid, output_index_start, hard_fork_version_current)
+		}
+		output_index_start = output_index_end
+	}
+
+	// every consumed key image must be stored with its height, and nothing else may be marked consumed
+	wrong_keyimages := map[crypto.Hash]bool{}
+	cursor, err1 := dbtx.Iterate(BLOCKCHAIN_UNIVERSE, GALAXY_KEYIMAGE, GALAXY_KEYIMAGE, nil, nil, false)
+	if err1 == nil {
+		for cursor.Next() {
+			var k_image crypto.Hash
+			copy(k_image[:], cursor.Key())
+			if len(cursor.Value()) != 8 {
+				continue
+			}
+			stored := int64(binary.BigEndian.Uint64(cursor.Value()))
+			if stored < 0 { // not consumed
+				stored = -1
+			}
+			expected, spent := spent_keyimages[k_image]
+			if !spent {
+				expected = -1
+			}
+			if stored != expected {
+				logger.Warnf("keyimage %s spent height stored %d expected %d", k_image, stored, expected)
+				mismatches++
+				wrong_keyimages[k_image] = true
+			}
+		}
+		cursor.Close()
+
+		if repair { // cursor cannot be used while modifying bucket
+			for k_image := range wrong_keyimages {
+				if expected, spent := spent_keyimages[k_image]; spent {
+					chain.Store_KeyImage(dbtx, k_image, expected)
+				} else {
+					chain.Store_KeyImage(dbtx, k_image, -1)
+				}
+			}
+		}
+	}
+
+	for k_image, expected := range spent_keyimages {
+		if _, ok := chain.Read_KeyImage_Status(dbtx, k_image); !ok && !wrong_keyimages[k_image] {
+			logger.Warnf("keyimage %s spent height missing expected %d", k_image, expected)
+			mismatches++
+			if repair {
+				chain.Store_KeyImage(dbtx, k_image, expected)
+			}
+		}
+	}
+
+	if mismatches == 0 {
+		logger.Infof("Chain database verified successfully till topoheight %d", top_topoheight)
+	} else if repair {
+		logger.Infof("Chain database repaired, %d mismatches fixed", mismatches)
+	} else {
+		logger.Warnf("Chain database has %d mismatches, run with --repair-db to fix them", mismatches)
+	}
+	return
+}
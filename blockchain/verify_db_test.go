@@ -0,0 +1,96 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package blockchain
+
+import "os"
+import "testing"
+import "io/ioutil"
+
+import log "github.com/sirupsen/logrus"
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/address"
+import "github.com/deroproject/derosuite/globals"
+
+// starts a simulator chain in memory, difficulty is 1 so blocks can be mined instantly
+func start_test_chain(t *testing.T) (chain *Blockchain, cleanup func()) {
+	dir, err := ioutil.TempDir("", "derod_blockchain_test")
+	if err != nil {
+		t.Fatalf("Cannot create temp dir err %s", err)
+	}
+
+	globals.Logger = log.New()
+	globals.Logger.SetLevel(log.WarnLevel)
+	globals.Config = config.Testnet
+	globals.Arguments = map[string]interface{}{"--data-dir": dir, "--testnet": true, "--debug": false, "--boltdb": false, "--badgerdb": false, "--disable-checkpoints": true}
+	os.MkdirAll(globals.GetDataDirectory(), 0750)
+
+	chain, err = Blockchain_Start(map[string]interface{}{"--simulator": true, "--memdb": true})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Cannot start chain err %s", err)
+	}
+	return chain, func() { chain.Shutdown(); os.RemoveAll(dir) }
+}
+
+// mines blocks on top of the chain
+func mine_test_blocks(t *testing.T, chain *Blockchain, count int) {
+	_, spend := crypto.NewKeyPair()
+	_, view := crypto.NewKeyPair()
+	miner_address := *address.NewAddressFromKeys(*spend, *view)
+	miner_address.Network = globals.Config.Public_Address_Prefix
+
+	for i := 0; i < count; i++ {
+		cbl, _ := chain.Create_new_miner_block(miner_address)
+		if err, ok := chain.Add_Complete_Block(cbl); !ok {
+			t.Fatalf("Cannot add block at height %d err %v", chain.Get_Height(), err)
+		}
+	}
+}
+
+func Test_Verify_DB(t *testing.T) {
+	chain, cleanup := start_test_chain(t)
+	defer cleanup()
+
+	mine_test_blocks(t, chain, 10)
+
+	if mismatches, err := chain.Verify_DB(false); err != nil || mismatches != 0 {
+		t.Fatalf("Fresh chain has %d mismatches err %v", mismatches, err)
+	}
+
+	// corrupt some derived data, similiar to a crash in between
+	dbtx, _ := chain.store.BeginTX(true)
+	blid, _ := chain.Load_Block_Topological_order_at_index(dbtx, 5)
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], PLANET_ALREADY_GENERATED_COINS, 7)
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_BLOCK, blid[:], PLANET_OUTPUT_INDEX_END, 1000)
+	dbtx.StoreUint64(BLOCKCHAIN_UNIVERSE, GALAXY_TOPOLOGICAL_ORDER, GALAXY_TOPOLOGICAL_ORDER, blid[:], 3)
+	chain.Store_KeyImage(dbtx, crypto.Keccak256([]byte("unknown key image")), 4)
+	dbtx.Commit()
+
+	if mismatches, err := chain.Verify_DB(false); err != nil || mismatches != 4 {
+		t.Fatalf("Corruption not detected, mismatches %d err %v", mismatches, err)
+	}
+
+	if mismatches, err := chain.Verify_DB(true); err != nil || mismatches != 4 {
+		t.Fatalf("Repair failed, mismatches %d err %v", mismatches, err)
+	}
+
+	if mismatches, err := chain.Verify_DB(false); err != nil || mismatches != 0 {
+		t.Fatalf("Repaired chain has %d mismatches err %v", mismatches, err)
+	}
+}
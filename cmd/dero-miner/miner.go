@@ -13,6 +13,7 @@ import "math/big"
 import "path/filepath"
 import "encoding/hex"
 import "encoding/binary"
+import "net/url"
 import "os/signal"
 import "sync/atomic"
 import "strings"
@@ -28,6 +29,7 @@ import "github.com/deroproject/derosuite/cryptonight"
 
 import log "github.com/sirupsen/logrus"
 import "github.com/ybbus/jsonrpc"
+import "golang.org/x/net/websocket"
 
 import "github.com/romana/rlog"
 import "github.com/chzyer/readline"
@@ -36,7 +38,7 @@ import "github.com/docopt/docopt-go"
 var rpcClient *jsonrpc.RPCClient
 var netClient *http.Client
 var mutex sync.RWMutex
-var job structures.GetBlockTemplate_Result
+var job miner_job
 var maxdelay int = 10000
 var threads int
 var iterations int = 100
@@ -51,13 +53,30 @@ var our_height int64
 
 var block_counter int
 
+var job_seq uint64   // incremented on every new job, mining threads switch as soon as it changes
+var job_tip uint64   // incremented whenever chain tip changes
+var job_tip_id string
+var job_push int32 // daemon pushes jobs, so they never get too old
+
+var shares_found uint64
+var shares_stale uint64 // found on a job whose tip was already replaced
+
+// work being mined, in stratum mode difficulty is share difficulty
+type miner_job struct {
+	structures.GetBlockTemplate_Result
+	Job_ID             string // stratum job, empty when polling daemon
+	Network_Difficulty uint64
+	Seq                uint64
+	Tip                uint64
+}
+
 var command_line string = `dero-miner
 DERO CPU Miner for AstroBWT.
 ONE CPU, ONE VOTE.
 http://wiki.dero.io
 
 Usage:
-  dero-miner  --wallet-address=<wallet_address> [--daemon-rpc-address=<http://127.0.0.1:20206>] [--mining-threads=<threads>] [--max-pow-size=1120] [--testnet] [--debug]
+  dero-miner  --wallet-address=<wallet_address> [--daemon-rpc-address=<http://127.0.0.1:20206>] [--stratum-address=<127.0.0.1:10100>] [--stats-bind=<127.0.0.1:10101>] [--stats-file=<file>] [--mining-threads=<threads>] [--max-pow-size=1120] [--testnet] [--debug]
   dero-miner --bench [--max-pow-size=1120]
   dero-miner -h | --help
   dero-miner --version
@@ -67,6 +86,9 @@ Options:
   --version     Show version.
   --bench  	    Run benchmark mode.
   --daemon-rpc-address=<http://127.0.0.1:20206>    Miner will connect to daemon RPC on this port.
+  --stratum-address=<127.0.0.1:10100>    Miner will get jobs from this stratum server (derod --stratum-bind or a pool) instead of polling daemon RPC.
+  --stats-bind=<127.0.0.1:10101>    Serve mining stats as json on /stats and for prometheus on /metrics.
+  --stats-file=<file>    Mining totals are kept in this file across restarts [default: dero-miner-stats.json]
   --wallet-address=<wallet_address>    This address is rewarded when a block is mined sucessfully.
   --mining-threads=<threads>         Number of CPU threads for mining [default: ` + fmt.Sprintf("%d", runtime.GOMAXPROCS(0)) + `]
   --max-pow-size=1120          Max amount of PoW size in KiB to mine, some older/newer cpus can increase their work
@@ -141,6 +163,10 @@ func main() {
 		daemon_rpc_address = globals.Arguments["--daemon-rpc-address"].(string)
 	}
 
+	if globals.Arguments["--stratum-address"] != nil {
+		stratum_address = globals.Arguments["--stratum-address"].(string)
+	}
+
 	threads = runtime.GOMAXPROCS(0)
 	if globals.Arguments["--mining-threads"] != nil {
 		if s, err := strconv.Atoi(globals.Arguments["--mining-threads"].(string)); err == nil {
@@ -283,12 +309,20 @@ func main() {
 					hash_rate_string = fmt.Sprintf("%d H/s", hash_rate)
 				}
 
+				shares_string := ""
+				if stratum_address != "" {
+					shares_string = fmt.Sprintf(" SHARES %d/%d", atomic.LoadUint64(&shares_accepted), atomic.LoadUint64(&shares_rejected))
+				}
+				if found := atomic.LoadUint64(&shares_found); found > 0 {
+					shares_string += fmt.Sprintf(" STALE %.1f%%", float64(atomic.LoadUint64(&shares_stale))*100/float64(found))
+				}
+
 				testnet_string := ""
 				if !globals.IsMainnet() {
 					testnet_string = "\033[31m TESTNET"
 				}
 
-				l.SetPrompt(fmt.Sprintf("\033[1m\033[32mDERO Miner: \033[0m"+color+"Height %d "+pcolor+" FOUND_BLOCKS %d \033[32mNW %s %s>%s>>\033[0m ", our_height,  block_counter, hash_rate_string, mining_string, testnet_string))
+				l.SetPrompt(fmt.Sprintf("\033[1m\033[32mDERO Miner: \033[0m"+color+"Height %d "+pcolor+" FOUND_BLOCKS %d%s \033[32mNW %s %s>%s>>\033[0m ", our_height,  block_counter, shares_string, hash_rate_string, mining_string, testnet_string))
 				l.Refresh()
 				last_our_height = our_height
 				last_best_height = best_height
@@ -315,12 +349,17 @@ func main() {
 		}
 	}()
 
+	stats_init(threads)
 	go increase_delay()
 	for i := 0; i < threads; i++ {
-		go mineblock()
+		go mineblock(i)
 	}
 
-	go getwork()
+	if stratum_address != "" {
+		go stratum_getwork()
+	} else {
+		go getwork()
+	}
 
 	for {
 		line, err := l.Readline()
@@ -328,6 +367,7 @@ func main() {
 			if len(line) == 0 {
 				fmt.Print("Ctrl-C received, Exit in progress\n")
 				close(Exit_In_Progress)
+				stats_save()
 				os.Exit(0)
 				break
 			} else {
@@ -356,6 +396,9 @@ func main() {
 				log.Println("say what?")
 				break
 			}
+		case command == "status":
+			print_stats(l.Stderr())
+
 		case command == "version":
 			fmt.Printf("Version %s OS:%s ARCH:%s \n", config.Version.String(), runtime.GOOS, runtime.GOARCH)
 
@@ -365,6 +408,7 @@ func main() {
 			fallthrough
 		case strings.ToLower(line) == "quit":
 			close(Exit_In_Progress)
+			stats_save()
     		os.Exit(0)
 		case line == "":
 		default:
@@ -397,13 +441,51 @@ func random_execution(wg *sync.WaitGroup, iterations int) {
 	runtime.UnlockOSThread()
 }
 
+// jobs which have not been refreshed for long are not mined, unless daemon pushes them
 func increase_delay() {
 	for {
 		time.Sleep(time.Second)
-		maxdelay++
+		if atomic.LoadInt32(&job_push) == 0 {
+			maxdelay++
+		}
 	}
 }
 
+// installs new work for mining threads, which switch to it immediately
+// tip identifies the chain tip the work builds on, work found on an older tip is stale
+func set_job(newjob miner_job, tip string) {
+	mutex.Lock()
+	if tip != job_tip_id {
+		job_tip_id = tip
+		job_tip++
+	}
+	newjob.Tip = job_tip
+	newjob.Seq = job.Seq
+	if newjob.Blockhashing_blob != job.Blockhashing_blob || newjob.Difficulty != job.Difficulty {
+		newjob.Seq = atomic.AddUint64(&job_seq, 1)
+	}
+	job = newjob
+	maxdelay = 0
+	mutex.Unlock()
+
+	hash_rate = newjob.Network_Difficulty / config.BLOCK_TIME_hf4
+	our_height = int64(newjob.Height)
+	Difficulty = newjob.Network_Difficulty
+}
+
+// counts found work, and whether it was found on a replaced tip
+func found_work(myjob miner_job) (stale bool) {
+	atomic.AddUint64(&shares_found, 1)
+	mutex.RLock()
+	stale = myjob.Tip != job.Tip
+	mutex.RUnlock()
+	if stale {
+		atomic.AddUint64(&shares_stale, 1)
+		globals.Logger.Warnf("Found work on stale job at height %d", myjob.Height)
+	}
+	return
+}
+
 // continuously get work
 func getwork() {
 
@@ -423,7 +505,7 @@ func getwork() {
 	}
 
 	// execute rpc to service
-	response, err := rpcClient.Call("get_info")
+	_, err := rpcClient.Call("get_info")
 	if err == nil {
 		globals.Logger.Infof("Connection to RPC server successful \"%s\"", daemon_rpc_address)
 	} else {
@@ -433,33 +515,63 @@ func getwork() {
 		return
 	}
 
+	// daemon pushes jobs as soon as chain moves, older daemons are polled
+	push_failed := false
 	for {
+		if err = getwork_push(); err != nil && !push_failed {
+			globals.Logger.Infof("Daemon does not push jobs, polling for them err %s", err)
+			push_failed = true
+		}
 
-		response, err = rpcClient.CallNamed("getblocktemplate", map[string]interface{}{"wallet_address": fmt.Sprintf("%s", wallet_address), "reserve_size": 10})
-		if err == nil {
-			var block_template structures.GetBlockTemplate_Result
-			err = response.GetObject(&block_template)
-			if err == nil {
-				mutex.Lock()
-				job = block_template
-				maxdelay = 0
-				mutex.Unlock()
-				hash_rate = job.Difficulty / config.BLOCK_TIME_hf4
-				our_height = int64(job.Height)
-				Difficulty = job.Difficulty
-				//fmt.Printf("block_template %+v\n", block_template)
-			}
+		for i := 0; i < 100; i++ { // try push again after some time
+			getwork_poll()
+			time.Sleep(300 * time.Millisecond)
+		}
+	}
+}
 
-		} else {
-			globals.Logger.Errorf("Error receiving block template  Failed err %s", err)
+func getwork_poll() {
+	response, err := rpcClient.CallNamed("getblocktemplate", map[string]interface{}{"wallet_address": fmt.Sprintf("%s", wallet_address), "reserve_size": 10})
+	if err == nil {
+		var block_template structures.GetBlockTemplate_Result
+		err = response.GetObject(&block_template)
+		if err == nil {
+			set_job(miner_job{GetBlockTemplate_Result: block_template, Network_Difficulty: block_template.Difficulty}, fmt.Sprintf("%d%s", block_template.Height, block_template.Prev_Hash))
+			//fmt.Printf("block_template %+v\n", block_template)
 		}
 
-		time.Sleep(300 * time.Millisecond)
+	} else {
+		globals.Logger.Errorf("Error receiving block template  Failed err %s", err)
+	}
+}
+
+// receives jobs over websocket till connection fails
+func getwork_push() error {
+	ws_url := strings.Replace(daemon_rpc_address, "http", "ws", 1) + "/ws/getblocktemplate?reserve_size=10&wallet_address=" + url.QueryEscape(wallet_address)
+	ws, err := websocket.Dial(ws_url, "", daemon_rpc_address)
+	if err != nil {
+		return err
 	}
+	defer ws.Close()
 
+	atomic.StoreInt32(&job_push, 1)
+	defer atomic.StoreInt32(&job_push, 0)
+	globals.Logger.Infof("Daemon pushes new jobs, mining switches to them immediately")
+
+	for {
+		var block_template structures.GetBlockTemplate_Result
+		ws.SetReadDeadline(time.Now().Add(3 * time.Duration(config.BLOCK_TIME) * time.Second)) // daemon pushes at least every epoch
+		if err = websocket.JSON.Receive(ws, &block_template); err != nil {
+			return err
+		}
+		if block_template.Status != "OK" {
+			return fmt.Errorf("%s", block_template.Status)
+		}
+		set_job(miner_job{GetBlockTemplate_Result: block_template, Network_Difficulty: block_template.Difficulty}, fmt.Sprintf("%d%s", block_template.Height, block_template.Prev_Hash))
+	}
 }
 
-func mineblock() {
+func mineblock(thread int) {
 	var diff big.Int
 	var powhash crypto.Hash
 	var work [76]byte
@@ -494,20 +606,25 @@ func mineblock() {
 		diff.SetUint64(myjob.Difficulty)
 
 		if work[0] <= 3 { // check major version
-			for i := uint32(0); i < 20; i++ {
+			for i := uint32(0); i < 20 && atomic.LoadUint64(&job_seq) == myjob.Seq; i++ {
 				atomic.AddUint64(&counter, 1)
+				atomic.AddUint64(&thread_hashes[thread], 1)
 				binary.BigEndian.PutUint32(nonce_buf, i)
 				pow := cryptonight.SlowHash(work[:])
 				copy(powhash[:], pow[:])
 
 				if CheckPowHashBig(powhash, &diff) == true {
+					if stratum_address != "" {
+						stratum_submit(myjob, work[:], powhash)
+						continue
+					}
+					found_work(myjob)
 					globals.Logger.Infof("Successfully found DERO block at difficulty:%d", myjob.Difficulty)
 					maxdelay = 200
 					block_counter++
 
 					response, err := rpcClient.Call("submitblock", myjob.Blocktemplate_blob, fmt.Sprintf("%x", work[:]))
-					_ = response
-					_ = err
+					stats_submit_result(response, err)
 					/*fmt.Printf("submitting %+v\n", []string{myjob.Blocktemplate_blob, fmt.Sprintf("%x", work[:])})
 					fmt.Printf("submit err %s\n", err)
 					fmt.Printf("submit response %s\n", response)
@@ -517,7 +634,7 @@ func mineblock() {
 				}
 			}
 		} else {
-			for i := uint32(0); i < iterations_per_loop; i++ {
+			for i := uint32(0); i < iterations_per_loop && atomic.LoadUint64(&job_seq) == myjob.Seq; i++ {
 				binary.BigEndian.PutUint32(nonce_buf, i)
 				//pow := astrobwt.POW_0alloc(work[:])
 				pow, success := astrobwt.POW_optimized_v2(work[:],max_pow_size,&data)
@@ -525,17 +642,22 @@ func mineblock() {
                     continue
                 }
                 atomic.AddUint64(&counter, 1)
+				atomic.AddUint64(&thread_hashes[thread], 1)
 				copy(powhash[:], pow[:])
 
 				if CheckPowHashBig(powhash, &diff) == true {
+					if stratum_address != "" {
+						stratum_submit(myjob, work[:], powhash)
+						continue
+					}
+					found_work(myjob)
 					globals.Logger.Infof("Successfully found DERO block astroblock at difficulty:%d  at height %d", myjob.Difficulty, myjob.Height)
 					maxdelay = 200
 
 					block_counter++
 
 					response, err := rpcClient.Call("submitblock", myjob.Blocktemplate_blob, fmt.Sprintf("%x", work[:]))
-					_ = response
-					_ = err
+					stats_submit_result(response, err)
 					/*fmt.Printf("submitting %+v\n", []string{myjob.Blocktemplate_blob, fmt.Sprintf("%x", work[:])})
 					fmt.Printf("submit err %s\n", err)
 					fmt.Printf("submit response %s\n", response)
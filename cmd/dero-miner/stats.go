@@ -0,0 +1,303 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+// this file implements mining statistics, so farm dashboards can scrape every rig
+// totals survive restarts in --stats-file, hash rates are sampled every STATS_SAMPLE
+// with --stats-bind, stats are served as json on /stats and for prometheus on /metrics
+
+import "io"
+import "os"
+import "fmt"
+import "sync"
+import "time"
+import "net/http"
+import "io/ioutil"
+import "sync/atomic"
+import "encoding/json"
+
+import "github.com/ybbus/jsonrpc"
+import "github.com/prometheus/client_golang/prometheus"
+import "github.com/prometheus/client_golang/prometheus/promhttp"
+
+import "github.com/deroproject/derosuite/globals"
+import "github.com/deroproject/derosuite/structures"
+
+const STATS_SAMPLE = 10 * time.Second // hash rate is averaged over this window
+const STATS_SAVE = time.Minute        // totals are saved this often, and on exit
+
+type Stats struct {
+	Since           int64  `json:"since"` // unix time stats were first recorded
+	Hashes          uint64 `json:"hashes"`
+	Found           uint64 `json:"found"`           // blocks found while solo mining, shares while using stratum
+	Stale           uint64 `json:"stale"`           // found on a job whose tip was already replaced
+	Blocks_Accepted uint64 `json:"blocks_accepted"` // as reported by submitblock
+	Blocks_Rejected uint64 `json:"blocks_rejected"`
+	Shares_Accepted uint64 `json:"shares_accepted"` // as reported by stratum server
+	Shares_Rejected uint64 `json:"shares_rejected"`
+	Last_Block      int64  `json:"last_block"` // unix time of last accepted block
+
+	// live values, these are not persisted
+	Uptime           int64     `json:"uptime,omitempty"`
+	Height           int64     `json:"height,omitempty"`
+	Difficulty       uint64    `json:"difficulty,omitempty"` // network difficulty
+	Hash_Rate        float64   `json:"hash_rate,omitempty"`
+	Thread_Hash_Rate []float64 `json:"thread_hash_rate,omitempty"`
+	Time_To_Block    float64   `json:"time_to_block,omitempty"` // expected secs to find a block at current hash rate
+}
+
+var stats_file string
+var stats_base Stats // totals loaded from stats file
+var stats_started = time.Now()
+var stats_lock sync.Mutex // protects thread_hash_rate
+var stats_save_lock sync.Mutex
+
+var thread_hashes []uint64 // hashes done by each thread
+var thread_hash_rate []float64
+
+var blocks_accepted uint64
+var blocks_rejected uint64
+var last_block int64
+
+var thread_hash_rate_metric = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "miner_thread_hash_rate",
+	Help: "Hashes per sec of each mining thread",
+}, []string{"thread"})
+
+// loads totals and starts sampling, must be called before mining threads start
+func stats_init(threads int) {
+	thread_hashes = make([]uint64, threads)
+	thread_hash_rate = make([]float64, threads)
+
+	stats_file = globals.Arguments["--stats-file"].(string)
+	if data, err := ioutil.ReadFile(stats_file); err == nil {
+		if err = json.Unmarshal(data, &stats_base); err != nil {
+			globals.Logger.Warnf("Stats file \"%s\" is corrupted, starting afresh err %s", stats_file, err)
+			stats_base = Stats{}
+		}
+	}
+
+	go stats_loop()
+
+	if globals.Arguments["--stats-bind"] != nil {
+		go stats_server(globals.Arguments["--stats-bind"].(string))
+	}
+}
+
+// totals including this session
+func stats_totals() (s Stats) {
+	s = Stats{
+		Since:           stats_base.Since,
+		Hashes:          stats_base.Hashes + atomic.LoadUint64(&counter),
+		Found:           stats_base.Found + atomic.LoadUint64(&shares_found),
+		Stale:           stats_base.Stale + atomic.LoadUint64(&shares_stale),
+		Blocks_Accepted: stats_base.Blocks_Accepted + atomic.LoadUint64(&blocks_accepted),
+		Blocks_Rejected: stats_base.Blocks_Rejected + atomic.LoadUint64(&blocks_rejected),
+		Shares_Accepted: stats_base.Shares_Accepted + atomic.LoadUint64(&shares_accepted),
+		Shares_Rejected: stats_base.Shares_Rejected + atomic.LoadUint64(&shares_rejected),
+		Last_Block:      stats_base.Last_Block,
+	}
+	if s.Since == 0 {
+		s.Since = stats_started.Unix()
+	}
+	if t := atomic.LoadInt64(&last_block); t != 0 {
+		s.Last_Block = t
+	}
+	return
+}
+
+// totals and live values
+func stats_snapshot() (s Stats) {
+	s = stats_totals()
+	s.Uptime = int64(time.Since(stats_started).Seconds())
+	s.Height = our_height
+	s.Difficulty = Difficulty
+
+	stats_lock.Lock()
+	s.Thread_Hash_Rate = append([]float64{}, thread_hash_rate...)
+	stats_lock.Unlock()
+	for _, rate := range s.Thread_Hash_Rate {
+		s.Hash_Rate += rate
+	}
+
+	if s.Hash_Rate > 0 && s.Difficulty > 0 {
+		s.Time_To_Block = float64(s.Difficulty) / s.Hash_Rate
+	}
+	return
+}
+
+// file is replaced atomically, so a crash never loses totals
+func stats_save() {
+	stats_save_lock.Lock()
+	defer stats_save_lock.Unlock()
+
+	data, err := json.MarshalIndent(stats_totals(), "", "  ")
+	if err == nil {
+		err = stats_write_synced(stats_file+".tmp", data)
+	}
+	if err == nil {
+		err = os.Rename(stats_file+".tmp", stats_file)
+	}
+	if err != nil {
+		globals.Logger.Warnf("Stats could not be saved to \"%s\" err %s", stats_file, err)
+	}
+}
+
+// data must be on disk before rename, otherwise a crash may leave an empty file in place of the totals
+func stats_write_synced(filename string, data []byte) error {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if close_err := f.Close(); err == nil {
+		err = close_err
+	}
+	return err
+}
+
+// updates hash rate of each thread from hashes done since last sample
+func stats_sample(last []uint64, elapsed float64) {
+	stats_lock.Lock()
+	defer stats_lock.Unlock()
+	for i := range thread_hashes {
+		hashes := atomic.LoadUint64(&thread_hashes[i])
+		thread_hash_rate[i] = float64(hashes-last[i]) / elapsed
+		last[i] = hashes
+		thread_hash_rate_metric.WithLabelValues(fmt.Sprintf("%d", i)).Set(thread_hash_rate[i])
+	}
+}
+
+func stats_loop() {
+	last := make([]uint64, len(thread_hashes))
+	last_time := time.Now()
+	last_save := time.Now()
+
+	for {
+		select {
+		case <-Exit_In_Progress:
+			stats_save()
+			return
+		case <-time.After(STATS_SAMPLE):
+		}
+
+		now := time.Now()
+		elapsed := now.Sub(last_time).Seconds()
+		last_time = now
+
+		stats_sample(last, elapsed)
+
+		if now.Sub(last_save) >= STATS_SAVE {
+			stats_save()
+			last_save = now
+		}
+	}
+}
+
+// counts result of submitblock
+func stats_submit_result(response *jsonrpc.RPCResponse, err error) {
+	var result structures.SubmitBlock_Result
+	if err == nil && response.Error != nil {
+		err = fmt.Errorf("%s", response.Error.Message)
+	}
+	if err == nil {
+		err = response.GetObject(&result)
+	}
+	if err == nil && result.Status == "OK" {
+		atomic.AddUint64(&blocks_accepted, 1)
+		atomic.StoreInt64(&last_block, time.Now().Unix())
+		globals.Logger.Infof("Block %s accepted by daemon", result.BLID)
+		return
+	}
+
+	atomic.AddUint64(&blocks_rejected, 1)
+	if err == nil {
+		err = fmt.Errorf("%s", result.Status)
+	}
+	globals.Logger.Warnf("Block rejected by daemon err %s", err)
+}
+
+func stats_metrics() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(thread_hash_rate_metric)
+
+	counters := []struct {
+		name, help string
+		value      func(Stats) uint64
+	}{
+		{"miner_hashes_counter", "Hashes done", func(s Stats) uint64 { return s.Hashes }},
+		{"miner_found_counter", "Blocks found while solo mining, shares while using stratum", func(s Stats) uint64 { return s.Found }},
+		{"miner_stale_counter", "Work found on a job whose tip was already replaced", func(s Stats) uint64 { return s.Stale }},
+		{"miner_blocks_accepted_counter", "Blocks accepted by daemon", func(s Stats) uint64 { return s.Blocks_Accepted }},
+		{"miner_blocks_rejected_counter", "Blocks rejected by daemon", func(s Stats) uint64 { return s.Blocks_Rejected }},
+		{"miner_shares_accepted_counter", "Shares accepted by stratum server", func(s Stats) uint64 { return s.Shares_Accepted }},
+		{"miner_shares_rejected_counter", "Shares rejected by stratum server", func(s Stats) uint64 { return s.Shares_Rejected }},
+	}
+	for _, c := range counters {
+		value := c.value
+		registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{Name: c.name, Help: c.help}, func() float64 { return float64(value(stats_totals())) }))
+	}
+
+	gauges := []struct {
+		name, help string
+		value      func(Stats) float64
+	}{
+		{"miner_hash_rate", "Hashes per sec of all threads", func(s Stats) float64 { return s.Hash_Rate }},
+		{"miner_time_to_block_seconds", "Expected secs to find a block at current hash rate and difficulty", func(s Stats) float64 { return s.Time_To_Block }},
+		{"miner_difficulty", "Network difficulty of current job", func(s Stats) float64 { return float64(s.Difficulty) }},
+		{"miner_height", "Height of current job", func(s Stats) float64 { return float64(s.Height) }},
+		{"miner_last_block_timestamp", "Unix time of last accepted block", func(s Stats) float64 { return float64(s.Last_Block) }},
+	}
+	for _, g := range gauges {
+		value := g.value
+		registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: g.name, Help: g.help}, func() float64 { return value(stats_snapshot()) }))
+	}
+	return registry
+}
+
+func stats_server(bind string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats_snapshot())
+	})
+	mux.Handle("/metrics", promhttp.HandlerFor(stats_metrics(), promhttp.HandlerOpts{}))
+
+	globals.Logger.Infof("Stats available at http://%s/stats and http://%s/metrics", bind, bind)
+	if err := http.ListenAndServe(bind, mux); err != nil {
+		globals.Logger.Errorf("Stats server failed err %s", err)
+	}
+}
+
+func print_stats(w io.Writer) {
+	s := stats_snapshot()
+	fmt.Fprintf(w, "Mining since %s, uptime %s\n", time.Unix(s.Since, 0).Format(time.RFC1123), time.Duration(s.Uptime)*time.Second)
+	fmt.Fprintf(w, "Height %d Difficulty %d Hash rate %.1f H/s\n", s.Height, s.Difficulty, s.Hash_Rate)
+	for i, rate := range s.Thread_Hash_Rate {
+		fmt.Fprintf(w, "\tThread %d %.1f H/s\n", i, rate)
+	}
+	if s.Time_To_Block > 0 {
+		fmt.Fprintf(w, "Expected time to block %s\n", time.Duration(s.Time_To_Block)*time.Second)
+	}
+	fmt.Fprintf(w, "Found %d Stale %d Blocks accepted %d rejected %d Shares accepted %d rejected %d\n",
+		s.Found, s.Stale, s.Blocks_Accepted, s.Blocks_Rejected, s.Shares_Accepted, s.Shares_Rejected)
+	if s.Last_Block != 0 {
+		fmt.Fprintf(w, "Last block accepted at %s\n", time.Unix(s.Last_Block, 0).Format(time.RFC1123))
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import "os"
+import "fmt"
+import "reflect"
+import "testing"
+import "io/ioutil"
+import "path/filepath"
+
+import "github.com/ybbus/jsonrpc"
+import log "github.com/sirupsen/logrus"
+
+import "github.com/deroproject/derosuite/globals"
+
+// clears totals of this session and those loaded from stats file
+func stats_reset() {
+	counter, shares_found, shares_stale = 0, 0, 0
+	blocks_accepted, blocks_rejected, last_block = 0, 0, 0
+	shares_accepted, shares_rejected = 0, 0
+	stats_base = Stats{}
+}
+
+// totals saved by one session are continued by the next one
+func Test_Stats_Save_Init(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dero_miner_stats_test")
+	if err != nil {
+		t.Fatalf("Cannot create temp dir err %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	globals.Logger = log.New()
+	stats_file = filepath.Join(dir, "stats.json")
+	globals.Arguments = map[string]interface{}{"--stats-file": stats_file, "--stats-bind": nil}
+	defer stats_reset()
+
+	stats_reset()
+	counter, shares_found, shares_stale = 1000, 5, 1
+	blocks_accepted, blocks_rejected, last_block = 3, 2, 1500000000
+	shares_accepted, shares_rejected = 7, 4
+	saved := stats_totals()
+	stats_save()
+
+	if _, err := os.Stat(stats_file + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("Temporary stats file must be renamed err %v", err)
+	}
+
+	stats_reset()
+	stats_init(2)
+	if loaded := stats_totals(); !reflect.DeepEqual(loaded, saved) {
+		t.Fatalf("Stats not restored, saved %+v loaded %+v", saved, loaded)
+	}
+
+	// this session adds to restored totals
+	counter = 10
+	if loaded := stats_totals(); loaded.Hashes != saved.Hashes+10 {
+		t.Fatalf("Session hashes not added to totals %d", loaded.Hashes)
+	}
+
+	// corrupted file starts afresh instead of failing
+	if err = ioutil.WriteFile(stats_file, []byte("{corrupted"), 0600); err != nil {
+		t.Fatalf("Cannot write stats file err %s", err)
+	}
+	stats_reset()
+	stats_init(2)
+	if loaded := stats_totals(); loaded.Hashes != 0 || loaded.Blocks_Accepted != 0 {
+		t.Fatalf("Corrupted stats file must be ignored %+v", loaded)
+	}
+}
+
+func Test_Stats_Submit_Result(t *testing.T) {
+	globals.Logger = log.New()
+	globals.Logger.SetLevel(log.ErrorLevel)
+	defer stats_reset()
+	stats_reset()
+
+	stats_submit_result(&jsonrpc.RPCResponse{Result: map[string]interface{}{"blid": "abcd", "status": "OK"}}, nil)
+	if blocks_accepted != 1 || blocks_rejected != 0 || last_block == 0 {
+		t.Fatalf("Accepted block not counted accepted %d rejected %d", blocks_accepted, blocks_rejected)
+	}
+
+	rejected := []struct {
+		response *jsonrpc.RPCResponse
+		err      error
+	}{
+		{&jsonrpc.RPCResponse{Result: map[string]interface{}{"status": "REJECTED"}}, nil},   // daemon rejected block
+		{&jsonrpc.RPCResponse{Error: &jsonrpc.RPCError{Code: -1, Message: "invalid"}}, nil}, // rpc error
+		{nil, fmt.Errorf("connection refused")},                                             // daemon unreachable
+	}
+	for i, r := range rejected {
+		stats_submit_result(r.response, r.err)
+		if blocks_accepted != 1 || blocks_rejected != uint64(i+1) {
+			t.Fatalf("Rejection %d not counted accepted %d rejected %d", i, blocks_accepted, blocks_rejected)
+		}
+	}
+	if s := stats_totals(); s.Blocks_Accepted != 1 || s.Blocks_Rejected != uint64(len(rejected)) {
+		t.Fatalf("Totals do not include submit results %+v", s)
+	}
+}
+
+// hash rate of each thread is hashes since last sample over elapsed secs
+func Test_Stats_Sample(t *testing.T) {
+	thread_hashes = make([]uint64, 2)
+	thread_hash_rate = make([]float64, 2)
+	last := make([]uint64, 2)
+	Difficulty = 1000
+	defer func() { Difficulty = 0 }()
+
+	thread_hashes[0] = 100
+	stats_sample(last, 10)
+	if thread_hash_rate[0] != 10 || thread_hash_rate[1] != 0 || last[0] != 100 {
+		t.Fatalf("Wrong hash rates %v last %v", thread_hash_rate, last)
+	}
+
+	// only hashes since last sample are counted
+	thread_hashes[0] = 150
+	thread_hashes[1] = 100
+	stats_sample(last, 5)
+	if thread_hash_rate[0] != 10 || thread_hash_rate[1] != 20 {
+		t.Fatalf("Wrong hash rates %v", thread_hash_rate)
+	}
+
+	s := stats_snapshot()
+	if s.Hash_Rate != 30 || len(s.Thread_Hash_Rate) != 2 || s.Time_To_Block != float64(1000)/30 {
+		t.Fatalf("Wrong snapshot hash rate %f time to block %f", s.Hash_Rate, s.Time_To_Block)
+	}
+
+	// idle threads report zero
+	stats_sample(last, 10)
+	if s = stats_snapshot(); s.Hash_Rate != 0 || s.Time_To_Block != 0 {
+		t.Fatalf("Idle miner must report zero hash rate %f time to block %f", s.Hash_Rate, s.Time_To_Block)
+	}
+}
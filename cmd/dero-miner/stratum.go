@@ -0,0 +1,217 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+// stratum client, used instead of polling getblocktemplate when --stratum-address is given
+// server pushes jobs, shares found at share difficulty are submitted on the same connection
+
+import "fmt"
+import "net"
+import "sync"
+import "time"
+import "bufio"
+import "math/big"
+import "sync/atomic"
+import "encoding/json"
+
+import "github.com/deroproject/derosuite/config"
+import "github.com/deroproject/derosuite/crypto"
+import "github.com/deroproject/derosuite/globals"
+import "github.com/deroproject/derosuite/structures"
+
+const STRATUM_KEEPALIVE = 5 * time.Second // keeps connection alive, replies prove server is still serving
+
+var stratum_address string
+var stratum_lock sync.Mutex // protects connection state below
+var stratum_conn net.Conn
+var stratum_id string // connection id given by server at login
+var stratum_request_id uint64
+var stratum_requests = map[uint64]string{} // pending requests, id to method
+
+var shares_accepted uint64
+var shares_rejected uint64
+
+// continuously get work from stratum server, reconnect if connection fails
+func stratum_getwork() {
+	for {
+		err := stratum_session()
+
+		mutex.Lock()
+		maxdelay = 200 // do not mine stale work while disconnected
+		mutex.Unlock()
+
+		select {
+		case <-Exit_In_Progress:
+			return
+		default:
+		}
+		globals.Logger.Errorf("Stratum connection to \"%s\" failed err %s, reconnecting", stratum_address, err)
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func stratum_session() (err error) {
+	conn, err := net.DialTimeout("tcp", stratum_address, 10*time.Second)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	stratum_lock.Lock()
+	stratum_conn = conn
+	stratum_requests = map[uint64]string{}
+	stratum_lock.Unlock()
+
+	defer func() {
+		stratum_lock.Lock()
+		stratum_conn = nil
+		stratum_id = ""
+		stratum_lock.Unlock()
+		atomic.StoreInt32(&job_push, 0)
+	}()
+
+	login := structures.Stratum_Login_Params{Login: wallet_address, Pass: "x", Agent: "dero-miner/" + config.Version.String()}
+	if err = stratum_send(structures.STRATUM_LOGIN, login); err != nil {
+		return
+	}
+
+	done := make(chan bool)
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(STRATUM_KEEPALIVE):
+				stratum_lock.Lock()
+				keepalive := map[string]string{"id": stratum_id}
+				stratum_lock.Unlock()
+				stratum_send(structures.STRATUM_KEEPALIVED, keepalive)
+			}
+		}
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(12 * STRATUM_KEEPALIVE))
+		if !scanner.Scan() {
+			if err = scanner.Err(); err == nil {
+				err = fmt.Errorf("connection closed by server")
+			}
+			return
+		}
+
+		var message structures.Stratum_Message
+		if err = json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			return
+		}
+
+		if message.Method == structures.STRATUM_JOB {
+			var j structures.Stratum_Job
+			if err = json.Unmarshal(message.Params, &j); err != nil {
+				return
+			}
+			stratum_set_job(j)
+			continue
+		}
+
+		var id uint64
+		json.Unmarshal(message.ID, &id)
+		stratum_lock.Lock()
+		method := stratum_requests[id]
+		delete(stratum_requests, id)
+		stratum_lock.Unlock()
+
+		switch method {
+		case structures.STRATUM_LOGIN:
+			var result structures.Stratum_Login_Result
+			if message.Error != nil {
+				return fmt.Errorf("login failed: %s", message.Error.Message)
+			}
+			if err = json.Unmarshal(message.Result, &result); err != nil {
+				return
+			}
+			stratum_lock.Lock()
+			stratum_id = result.ID
+			stratum_lock.Unlock()
+			atomic.StoreInt32(&job_push, 1) // server pushes new jobs as chain moves
+			globals.Logger.Infof("Connection to stratum server successful \"%s\"", stratum_address)
+			stratum_set_job(result.Job)
+
+		case structures.STRATUM_SUBMIT:
+			if message.Error != nil {
+				if message.Error.Message == structures.STRATUM_STALE_JOB { // server knows best whether a share is stale
+					atomic.AddUint64(&shares_stale, 1)
+				}
+				atomic.AddUint64(&shares_rejected, 1)
+				globals.Logger.Warnf("Share rejected by stratum server: %s", message.Error.Message)
+			} else {
+				atomic.AddUint64(&shares_accepted, 1)
+			}
+		}
+	}
+}
+
+func stratum_set_job(j structures.Stratum_Job) {
+	set_job(miner_job{
+		GetBlockTemplate_Result: structures.GetBlockTemplate_Result{Blockhashing_blob: j.Blob, Difficulty: j.Difficulty, Height: j.Height},
+		Job_ID:                  j.Job_ID,
+		Network_Difficulty:      j.Network_Difficulty,
+	}, fmt.Sprintf("%d", j.Height))
+}
+
+// submits a share, server submits it to chain if it is a block
+func stratum_submit(myjob miner_job, work []byte, powhash crypto.Hash) {
+	atomic.AddUint64(&shares_found, 1)
+	if myjob.Network_Difficulty > 0 && CheckPowHashBig(powhash, new(big.Int).SetUint64(myjob.Network_Difficulty)) {
+		globals.Logger.Infof("Successfully found DERO block at difficulty:%d  at height %d", myjob.Network_Difficulty, myjob.Height)
+		block_counter++
+	}
+
+	stratum_lock.Lock()
+	share := structures.Stratum_Submit_Params{ID: stratum_id, Job_ID: myjob.Job_ID, Nonce: fmt.Sprintf("%x", work[39:43]), Extra_Nonce: fmt.Sprintf("%x", work[43:59])}
+	stratum_lock.Unlock()
+
+	if err := stratum_send(structures.STRATUM_SUBMIT, share); err != nil {
+		globals.Logger.Warnf("Share could not be submitted err %s", err)
+	}
+}
+
+func stratum_send(method string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	stratum_lock.Lock()
+	defer stratum_lock.Unlock()
+	if stratum_conn == nil {
+		return fmt.Errorf("not connected to stratum server")
+	}
+
+	stratum_request_id++
+	id, _ := json.Marshal(stratum_request_id)
+	request, err := json.Marshal(structures.Stratum_Message{ID: id, JSONRPC: "2.0", Method: method, Params: data})
+	if err != nil {
+		return err
+	}
+	stratum_requests[stratum_request_id] = method
+
+	stratum_conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err = stratum_conn.Write(append(request, '\n'))
+	return err
+}
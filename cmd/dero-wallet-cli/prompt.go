@@ -56,7 +56,7 @@ func handle_prompt_command(l *readline.Instance, line string) {
 
 	// handled closed wallet commands
 	switch command {
-	case "address", "rescan_bc", "rescan_spent", "seed", "set", "password", "get_tx_key", "i8", "i32", "payment_id":
+	case "address", "rescan_bc", "rescan_spent", "seed", "set", "password", "get_tx_key", "bump_fee", "i8", "i32", "payment_id":
 		fallthrough
 	case "spendkey", "viewkey", "transfer", "locked_transfer", "close":
 		fallthrough
@@ -152,6 +152,32 @@ func handle_prompt_command(l *readline.Instance, line string) {
 			globals.Logger.Warnf("get_tx_key needs transaction hash as input parameter")
 			globals.Logger.Warnf("eg. get_tx_key ea551b02b9f1e8aebe4d7b1b7f6bf173d76ae614cb9a066800773fee9e226fd7")
 		}
+	case "bump_fee": // replace a pending tx with a higher fee one
+		if len(line_parts) == 2 && len(line_parts[1]) == 64 {
+			_, err := hex.DecodeString(line_parts[1])
+			if err != nil {
+				globals.Logger.Warnf("Error parsing txhash")
+				break
+			}
+			old_fees := wallet.GetTXOutDetails(crypto.HexToHash(line_parts[1])).Fees
+			tx, err := wallet.Bump_Fee(crypto.HexToHash(line_parts[1]))
+			if err != nil {
+				globals.Logger.Warnf("Error while building Transaction err %s\n", err)
+				break
+			}
+			globals.Logger.Infof("fees %s DERO (previous fees %s DERO)", globals.FormatMoneyPrecision(tx.RctSignature.Get_TX_Fee(), 12), globals.FormatMoneyPrecision(old_fees, 12))
+			if ConfirmYesNoDefaultNo(l, "Confirm Transaction (y/N)") {
+				err = wallet.SendTransaction(tx) // relay tx to daemon/network
+				if err == nil {
+					globals.Logger.Infof("Transaction sent successfully. txid = %s", tx.GetHash())
+				} else {
+					globals.Logger.Warnf("Transaction sending failed txid = %s, err %s", tx.GetHash(), err)
+				}
+			}
+		} else {
+			globals.Logger.Warnf("bump_fee needs transaction hash as input parameter")
+			globals.Logger.Warnf("eg. bump_fee ea551b02b9f1e8aebe4d7b1b7f6bf173d76ae614cb9a066800773fee9e226fd7")
+		}
 	case "sweep_all", "transfer_all": // transfer everything
 		Transfer_Everything(l)
 
@@ -317,11 +343,16 @@ func handle_set_command(l *readline.Instance, line string) {
 			help = true
 			break
 		}
+		if err := wallet.SetFeePriority(line_parts[2]); err == nil { // low, normal, high use fees estimated by daemon
+			globals.Logger.Infof("Transaction priority =  %s", wallet.GetFeePriority())
+			break
+		}
 		s, err := strconv.ParseFloat(line_parts[2], 64)
 		if err != nil {
 			globals.Logger.Warnf("Error parsing priority")
 			return
 		}
+		wallet.SetFeePriority("")
 		wallet.SetFeeMultiplier(float32(s))
 		globals.Logger.Infof("Transaction priority =  %.02f", wallet.GetFeeMultiplier())
 
@@ -340,6 +371,10 @@ func handle_set_command(l *readline.Instance, line string) {
 		fmt.Fprintf(l.Stderr(), color_normal+"Mixin: "+color_extra_white+"%d\t"+color_normal+"eg. "+color_extra_white+"set mixin 13\n"+color_normal, wallet.GetMixin())
 		fmt.Fprintf(l.Stderr(), color_normal+"Priority: "+color_extra_white+"%0.2f\t"+color_normal+"eg. "+color_extra_white+"set priority 4.0\t"+color_normal+"Transaction priority on DERO network \n", wallet.GetFeeMultiplier())
 		fmt.Fprintf(l.Stderr(), "\t\tMinimum priority is 1.00. High priority = high fees\n")
+		fmt.Fprintf(l.Stderr(), "\t\tPriority can also be low, normal or high, fees are then estimated by daemon\n")
+		if wallet.GetFeePriority() != "" {
+			fmt.Fprintf(l.Stderr(), "\t\tCurrent priority is %s, fee multiplier is not used\n", wallet.GetFeePriority())
+		}
 
 	}
 }
@@ -713,6 +748,7 @@ var completer = readline.NewPrefixCompleter(
 	readline.PcItem("balance"),
 	readline.PcItem("integrated_address"),
 	readline.PcItem("get_tx_key"),
+	readline.PcItem("bump_fee"),
 	readline.PcItem("menu"),
 	readline.PcItem("rescan_bc"),
 	readline.PcItem("rescan_spent"),
@@ -745,6 +781,7 @@ func usage(w io.Writer) {
 	io.WriteString(w, "\t\033[1maddress\033[0m\t\tDisplay user address\n")
 	io.WriteString(w, "\t\033[1mbalance\033[0m\t\tDisplay user balance\n")
 	io.WriteString(w, "\t\033[1mget_tx_key\033[0m\tDisplay tx secret key for specific transaction\n")
+	io.WriteString(w, "\t\033[1mbump_fee\033[0m\tReplace a pending transaction with higher fees, eg. bump_fee <txid>\n")
 	io.WriteString(w, "\t\033[1mintegrated_address\033[0m\tDisplay random integrated address (with encrypted payment ID)\n")
 	io.WriteString(w, "\t\033[1mmenu\033[0m\t\tEnable menu mode\n")
 	io.WriteString(w, "\t\033[1mrescan_bc\033[0m\tRescan blockchain again from 0 height\n")
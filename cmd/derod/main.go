@@ -55,7 +55,7 @@ import "github.com/deroproject/derosuite/cryptonight"
 
 //import "github.com/deroproject/derosuite/crypto/ringct"
 import "github.com/deroproject/derosuite/blockchain/rpcserver"
-import "github.com/deroproject/derosuite/walletapi"
+import "github.com/deroproject/derosuite/blockchain/stratum"
 
 //import "github.com/deroproject/derosuite/address"
 
@@ -63,7 +63,7 @@ var command_line string = `derod
 DERO : A secure, private blockchain with smart-contracts
 
 Usage:
-  derod [--help] [--version] [--testnet] [--debug]  [--sync-node] [--boltdb | --badgerdb] [--disable-checkpoints] [--socks-proxy=<socks_ip:port>] [--data-dir=<directory>] [--p2p-bind=<0.0.0.0:18089>] [--add-exclusive-node=<ip:port>]... [--add-priority-node=<ip:port>]... 	 [--min-peers=<11>] [--rpc-bind=<127.0.0.1:9999>] [--lowcpuram] [--mining-address=<wallet_address>] [--mining-threads=<cpu_num>] [--node-tag=<unique name>]
+  derod [--help] [--version] [--testnet] [--debug]  [--sync-node] [--boltdb | --badgerdb | --memdb] [--migrate-db=<boltdb|badgerdb>] [--verify-db] [--repair-db] [--prune=<depth>] [--indexer] [--mempool-max-bytes=<bytes>] [--mempool-max-txs=<count>] [--import-snapshot=<file>] [--snapshot-signer=<public key>] [--disable-checkpoints] [--socks-proxy=<socks_ip:port>] [--data-dir=<directory>] [--p2p-bind=<0.0.0.0:18089>] [--add-exclusive-node=<ip:port>]... [--add-priority-node=<ip:port>]... 	 [--min-peers=<11>] [--rpc-bind=<127.0.0.1:9999>] [--stratum-bind=<127.0.0.1:10100>] [--lowcpuram] [--mining-address=<wallet_address>] [--mining-threads=<cpu_num>] [--node-tag=<unique name>]
   derod -h | --help
   derod --version
 
@@ -74,10 +74,21 @@ Options:
   --debug       Debug mode enabled, print log messages
   --boltdb      Use boltdb as backend  (default on 64 bit systems)
   --badgerdb    Use Badgerdb as backend (default on 32 bit systems)
+  --memdb       Use in memory backend, nothing is persisted (useful for testing)
+  --migrate-db=<boltdb|badgerdb>  Copy chain from current backend to the given backend and exit, interrupted migrations resume
+  --verify-db   Verify chain database by replaying it from genesis, report mismatches and exit
+  --repair-db   Verify chain database, repair mismatches and exit
+  --prune=<depth>   Discard ring signatures and range proofs of transactions deeper than this many blocks
+  --indexer     Index incoming transfers of registered view keys, see indexer_register and indexer_get_transfers RPCs
+  --mempool-max-bytes=<bytes>   Limit mempool size, lowest fee per byte txs are evicted once full (default 64 MiB)
+  --mempool-max-txs=<count>   Limit number of txs in mempool (default 10000)
+  --import-snapshot=<file>   Bootstrap an empty chain from a snapshot, every block is verified against checkpoints
+  --snapshot-signer=<public key>   Only accept snapshot signed by this key
   --disable-checkpoints  Disable checkpoints, work in truly async, slow mode 1 block at a time
   --socks-proxy=<socks_ip:port>  Use a proxy to connect to network.
   --data-dir=<directory>    Store blockchain data at this location
   --rpc-bind=<127.0.0.1:9999>    RPC listens on this ip:port
+  --stratum-bind=<127.0.0.1:10100>    Stratum mining server listens on this ip:port, disabled by default
   --p2p-bind=<0.0.0.0:18089>    p2p server listens on this ip:port, specify port 0 to disable listening server
   --add-exclusive-node=<ip:port>	Connect to specific peer only 
   --add-priority-node=<ip:port>	Maintain persistant connection to specified peer
@@ -141,6 +152,38 @@ func main() {
 
 	params := map[string]interface{}{}
 
+	// migrate and exit, derod needs to be restarted with the new backend
+	if globals.Arguments["--migrate-db"] != nil {
+		if err = blockchain.Migrate_Chain(params, globals.Arguments["--migrate-db"].(string)); err != nil {
+			globals.Logger.Warnf("Error migrating chain err '%s'", err)
+		}
+		return
+	}
+
+	if globals.Arguments["--prune"] != nil {
+		depth, err := strconv.ParseInt(globals.Arguments["--prune"].(string), 10, 64)
+		if err != nil || depth < blockchain.PRUNE_MIN_DEPTH {
+			globals.Logger.Warnf("Invalid prune depth '%s', it must be at least %d", globals.Arguments["--prune"].(string), blockchain.PRUNE_MIN_DEPTH)
+			return
+		}
+		params["--prune"] = depth
+	}
+
+	if globals.Arguments["--indexer"] == true {
+		params["--indexer"] = true
+	}
+
+	for _, limit := range []string{"--mempool-max-bytes", "--mempool-max-txs"} {
+		if globals.Arguments[limit] != nil {
+			value, err := strconv.ParseUint(globals.Arguments[limit].(string), 10, 64)
+			if err != nil || value == 0 {
+				globals.Logger.Warnf("Invalid %s '%s'", limit, globals.Arguments[limit].(string))
+				return
+			}
+			params[limit] = value
+		}
+	}
+
 	//params["--disable-checkpoints"] = globals.Arguments["--disable-checkpoints"].(bool)
 	chain, err := blockchain.Blockchain_Start(params)
 
@@ -149,6 +192,33 @@ func main() {
 		return
 	}
 
+	// verify/repair the database and exit, before anything else can modify it
+	if globals.Arguments["--verify-db"].(bool) || globals.Arguments["--repair-db"].(bool) {
+		if _, err = chain.Verify_DB(globals.Arguments["--repair-db"].(bool)); err != nil {
+			globals.Logger.Warnf("Error verifying chain database err '%s'", err)
+		}
+		chain.Shutdown()
+		return
+	}
+
+	if globals.Arguments["--import-snapshot"] != nil {
+		var signer *crypto.Key
+		if globals.Arguments["--snapshot-signer"] != nil {
+			var key crypto.Key
+			if err = key.UnmarshalText([]byte(globals.Arguments["--snapshot-signer"].(string))); err != nil || !key.Public_Key_Valid() {
+				globals.Logger.Warnf("Invalid snapshot signer err '%s'", err)
+				chain.Shutdown()
+				return
+			}
+			signer = &key
+		}
+		if err = chain.Import_Snapshot(globals.Arguments["--import-snapshot"].(string), signer); err != nil {
+			globals.Logger.Warnf("Error importing snapshot err '%s'", err)
+			chain.Shutdown()
+			return
+		}
+	}
+
 	params["chain"] = chain
 
 	// setup miner flag, before starting p2p
@@ -174,6 +244,15 @@ func main() {
 	//rpcserver.DEBUG_MODE = true
 	rpc, _ := rpcserver.RPCServer_Start(params)
 
+	var stratum_server *stratum.Server
+	if globals.Arguments["--stratum-bind"] != nil {
+		if globals.Arguments["--lowcpuram"].(bool) || globals.Arguments["--sync-node"].(bool) {
+			globals.Logger.Warnf("Stratum server is disabled since mining is deactivated by --lowcpuram or --sync-node")
+		} else if stratum_server, err = stratum.Stratum_Start(params); err != nil {
+			globals.Logger.Warnf("Stratum server could not be started err %s", err)
+		}
+	}
+
 	// setup function pointers
 	// these pointers need to fixed
 	chain.Mempool.P2P_TX_Relayer = func(tx *transaction.Transaction, peerid uint64) (count int) {
@@ -372,80 +451,30 @@ func main() {
 				break
 			}
 		//
-		case command == "import_chain": // this migrates existing chain from DERO to DERO atlantis
-			f, err := os.Open("/tmp/raw_export.txt")
+		case command == "export_snapshot": // exports a signed snapshot, which new nodes can import using --import-snapshot
+			if len(line_parts) < 3 || len(line_parts) > 4 {
+				globals.Logger.Warnf("export_snapshot needs <file> <topoheight> [secret key hex]")
+				continue
+			}
+			topoheight, err := strconv.ParseInt(line_parts[2], 10, 64)
 			if err != nil {
-				globals.Logger.Warnf("error opening  file  /tmp/raw_export.txt %s", err)
+				globals.Logger.Warnf("Invalid topoheight '%s' err %s", line_parts[2], err)
 				continue
 			}
-			reader := bufio.NewReader(f)
-
-			account, _ := walletapi.Generate_Keys_From_Random() // create a random address
-
-			for {
-				line, err = reader.ReadString('\n')
 
-				if err != nil || len(line) < 10 {
-					break
-				}
-
-				var txs []string
-
-				err = json.Unmarshal([]byte(line), &txs)
-
-				if err != nil {
-					fmt.Printf("err while unmarshalling json err %s", err)
+			var secret crypto.Key
+			if len(line_parts) == 4 {
+				if err = secret.UnmarshalText([]byte(line_parts[3])); err != nil || !secret.Private_Key_Valid() {
+					globals.Logger.Warnf("Invalid secret key err %s", err)
 					continue
 				}
-
-				if len(txs) < 1 {
-					panic("TX cannot be zero")
-				}
-
-				cbl, bl := chain.Create_new_miner_block(account.GetAddress())
-
-				for i := range txs {
-
-					var tx transaction.Transaction
-
-					tx_bytes, err := hex.DecodeString(txs[i])
-					if err != nil {
-						globals.Logger.Warnf("TX could not be decoded")
-					}
-
-					err = tx.DeserializeHeader(tx_bytes)
-					if err != nil {
-						globals.Logger.Warnf("TX could not be Deserialized")
-					}
-
-					globals.Logger.Infof(" txhash  %s", tx.GetHash())
-					if i == 0 {
-						bl.Miner_TX = tx
-						cbl.Bl.Miner_TX = tx
-
-						if bl.Miner_TX.GetHash() != tx.GetHash() || cbl.Bl.Miner_TX.GetHash() != tx.GetHash() {
-							panic("miner TX hash mismatch")
-						}
-
-					} else {
-						bl.Tx_hashes = append(bl.Tx_hashes, tx.GetHash())
-						cbl.Bl.Tx_hashes = append(cbl.Bl.Tx_hashes, tx.GetHash())
-						cbl.Txs = append(cbl.Txs, &tx)
-					}
-				}
-
-				if err, ok := chain.Add_Complete_Block(cbl); ok {
-					globals.Logger.Warnf("Block Successfully accepted by chain at height %d", cbl.Bl.Miner_TX.Vin[0].(transaction.Txin_gen).Height)
-				} else {
-					globals.Logger.Warnf("Block rejected by chain at height %d, please investigate, err %s", cbl.Bl.Miner_TX.Vin[0].(transaction.Txin_gen).Height,err)
-					globals.Logger.Warnf("Stopping import")
-
-				}
-
+			} else { // sign with a random key, importers can pin it using --snapshot-signer
+				secret = *crypto.RandomScalar()
 			}
 
-			globals.Logger.Infof("File imported Successfully")
-			f.Close()
+			if err = chain.Export_Snapshot(line_parts[1], topoheight, secret); err != nil {
+				globals.Logger.Warnf("Error exporting snapshot err %s", err)
+			}
 
 		case command == "profile": // writes cpu and memory profile
 			// TODO enable profile over http rpc to enable better testing/tracking
@@ -950,6 +979,9 @@ exit:
 	time.Sleep(100 * time.Millisecond) // give prompt update time to finish
 
 	rpc.RPCServer_Stop()
+	if stratum_server != nil {
+		stratum_server.Stratum_Stop()
+	}
 	p2p.P2P_Shutdown() // shutdown p2p subsystem
 	chain.Shutdown()   // shutdown chain subsysem
 
@@ -975,6 +1007,7 @@ func usage(w io.Writer) {
 	io.WriteString(w, "\t\033[1mprint_height\033[0m\tPrint local blockchain height\n")
 	io.WriteString(w, "\t\033[1mprint_tx\033[0m\tPrint transaction, print_tx <transaction_hash>\n")
 	io.WriteString(w, "\t\033[1mstatus\033[0m\t\tShow general information\n")
+	io.WriteString(w, "\t\033[1mexport_snapshot\033[0m\tExport signed chain snapshot, export_snapshot <file> <topoheight> [secret key hex]\n")
 //	io.WriteString(w, "\t\033[1mstart_mining\033[0m\tStart mining <dero address> <number of threads>\n")
 	io.WriteString(w, "\t\033[1mstop_mining\033[0m\tStop daemon mining\n")
 	io.WriteString(w, "\t\033[1mpeer_list\033[0m\tPrint peer list\n")
@@ -1016,6 +1049,7 @@ var completer = readline.NewPrefixCompleter(
 		readline.PcItem("sleep"),
 	*/
 	readline.PcItem("diff"),
+	readline.PcItem("export_snapshot"),
 //	readline.PcItem("dev_verify_pool"),
 //	readline.PcItem("dev_verify_chain_doublespend"),
 	readline.PcItem("mempool_flush"),
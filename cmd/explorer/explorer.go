@@ -379,6 +379,10 @@ func load_tx_info_from_tx(info *txinfo, tx *transaction.Transaction) (err error)
 		info.Type = "RingCT/3 Full bulletproof"
 	case 4:
 		info.Type = "RingCT/4 Simple Bulletproof"
+	case 5:
+		info.Type = "RingCT/5 Simple Aggregated Bulletproof"
+	case 6:
+		info.Type = "RingCT/6 CLSAG"
 	}
 
 	if !info.In_Pool { // find the age of block and other meta
@@ -442,7 +446,14 @@ func load_tx_from_rpc(info *txinfo, txhash string) (err error) {
 	info.Hex =  tx_result.Txs_as_hex[0]
 
 	tx_bin, _ := hex.DecodeString(tx_result.Txs_as_hex[0])
-	tx.DeserializeHeader(tx_bin)
+	if tx_result.Txs[0].Pruned { // pruned nodes only serve the prunable hash instead of signatures
+		err = tx.DeserializePruned(tx_bin)
+	} else {
+		err = tx.DeserializeHeader(tx_bin)
+	}
+	if err != nil {
+		return fmt.Errorf("Cannot deserialize tx err %s", err)
+	}
 
 	// fill as much info required from headers
 	if tx_result.Txs[0].In_pool {
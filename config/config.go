@@ -95,6 +95,14 @@ const TESTNET_MINIMUM_DIFFICULTY = uint64(800 * BLOCK_TIME_hf4) // 800 H
 
 const HF5_HEIGHT = 6713000 // hf applies at this height 
 
+// hf6 enables aggregated bulletproofs, height will be fixed once the release is out
+const HF6_HEIGHT = 0x7fffffffffffffff // hf applies at this height
+const TESTNET_HF6_HEIGHT = 100 // testnet gets it early, so wallets and miners can be tried out
+
+// hf7 enables CLSAG ring signatures, height will be fixed once the release is out
+const HF7_HEIGHT = 0x7fffffffffffffff // hf applies at this height
+const TESTNET_HF7_HEIGHT = 200 // testnet gets it early, so wallets and miners can be tried out
+
 
 // this single parameter controls lots of various parameters
 // within the consensus, it should never go below 7
@@ -181,9 +181,21 @@ func Test_Crypto(t *testing.T) {
 				t.Fatalf("%s: Expected %s, got %s.", words[0], expected, actual)
 			}
 
-		// these are ignored because they are not required DERO project is based on ringct+
-		case "generate_signature":
 		case "check_signature":
+			prefix_hash := HexToHash(words[1])
+			public_key := HexToKey(words[2])
+			var sig Signature
+			sig.C = HexToKey(words[3][:64])
+			sig.R = HexToKey(words[3][64:])
+			expected := words[4] == "true"
+
+			actual := CheckSignature(prefix_hash, public_key, sig)
+			if actual != expected {
+				t.Fatalf("%s: Expected %t, got %t.", words[0], expected, actual)
+			}
+
+		// these are ignored because they are not required DERO project is based on ringct+
+		case "generate_signature": // uses deterministic random numbers
 		case "generate_ring_signature":
 		case "check_ring_signature":
 
@@ -205,3 +217,25 @@ func TestH(t *testing.T) {
 		t.Fatalf("H generation failed Actual %s expected %s", actual, H)
 	}
 }
+
+// signatures must verify against the signing key only
+func Test_Signature(t *testing.T) {
+	secret, public := NewKeyPair()
+	_, other := NewKeyPair()
+	prefix_hash := Keccak256([]byte("snapshot"))
+
+	sig := GenerateSignature(prefix_hash, *public, *secret)
+	if !CheckSignature(prefix_hash, *public, sig) {
+		t.Fatalf("Valid signature failed verification")
+	}
+	if CheckSignature(Keccak256([]byte("tampered")), *public, sig) {
+		t.Fatalf("Signature verified against a different hash")
+	}
+	if CheckSignature(prefix_hash, *other, sig) {
+		t.Fatalf("Signature verified against a different key")
+	}
+	sig.R[0] ^= 1
+	if CheckSignature(prefix_hash, *public, sig) {
+		t.Fatalf("Tampered signature verified")
+	}
+}
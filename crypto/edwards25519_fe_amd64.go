@@ -80,17 +80,18 @@ func FeSquare2(out, a *FieldElement) {
 //
 // Preconditions: b in {0,1}.
 // it is a condition move like cmov instruction in assembly
-// all the 3 implementations are equal, but some are optmised
-/*func FeCMove(f, g *FieldElement, b int32) {
+// all the 3 implementations are equal, but only the masked one below runs in constant time
+// it is used with secret scalars, so it must not branch on b
+func FeCMove(f, g *FieldElement, b int32) {
 	negate := (1<<64 - 1) * uint64(b)
 	f[0] ^= negate & (f[0] ^ g[0])
 	f[1] ^= negate & (f[1] ^ g[1])
 	f[2] ^= negate & (f[2] ^ g[2])
 	f[3] ^= negate & (f[3] ^ g[3])
 	f[4] ^= negate & (f[4] ^ g[4])
-}*/
+}
 
-func FeCMove(f, g *FieldElement, b int32) {
+/*func FeCMove(f, g *FieldElement, b int32) {
 	if b == 0 { // do nothing
 
 	} else {
@@ -100,7 +101,7 @@ func FeCMove(f, g *FieldElement, b int32) {
 		f[3] = g[3]
 		f[4] = g[4]
 	}
-}
+}*/
 
 /*
 func FeCMove(f, g *FieldElement, b int32) {
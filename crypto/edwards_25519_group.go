@@ -658,10 +658,8 @@ func GeDoubleScalarMultPrecompVartime(r *ProjectiveGroupElement, a *Key, A *Exte
 	GeDoubleScalarMultPrecompVartime2(r, a, &Ai, b, Bi)
 }
 
+// constant time, b is derived from secret scalars
 func CachedGroupElementCMove(t, u *CachedGroupElement, b int32) {
-	if b == 0 {
-		return
-	}
 	FeCMove(&t.yPlusX, &u.yPlusX, b)
 	FeCMove(&t.yMinusX, &u.yMinusX, b)
 	FeCMove(&t.Z, &u.Z, b)
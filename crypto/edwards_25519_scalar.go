@@ -1005,10 +1005,8 @@ func GeScalarMult(r *ProjectiveGroupElement, a *Key, A *ExtendedGroupElement) {
 		t.ToExtended(u)
 
 		cur.Zero()
-		for j := int32(0); j < 8; j++ {
-			if equal(int32(bAbs), j+1) == 1 { // optimisation
-				CachedGroupElementCMove(cur, &Ai[j], equal(int32(bAbs), j+1))
-			}
+		for j := int32(0); j < 8; j++ { // every entry is touched, so the selected one does not leak
+			CachedGroupElementCMove(cur, &Ai[j], equal(int32(bAbs), j+1))
 		}
 
 		FeCopy(&minusCur.yPlusX, &cur.yMinusX)
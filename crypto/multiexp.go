@@ -0,0 +1,193 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package crypto
+
+// multi scalar multiplication, computes sum(scalars[i] * points[i]) much faster than one point at a time
+// Straus shares the doublings between all the points and works best for small inputs
+// Pippenger sorts the points into buckets per window and wins for large inputs
+// all of these run in variable time and must only be used with public data such as while verifying
+// scalars must be reduced, as the signed windows cannot represent values above 2^255
+
+// below this number of points Straus is used, see BenchmarkMultiScalarMult
+const STRAUS_LIMIT = 128
+
+// MultiScalarMult sets output = sum(scalars[i] * points[i])
+func MultiScalarMult(output *ExtendedGroupElement, scalars []Key, points []ExtendedGroupElement) {
+	if len(scalars) != len(points) {
+		panic("MultiScalarMult requires same number of scalars and points")
+	}
+	if len(points) < STRAUS_LIMIT {
+		straus(output, scalars, points)
+	} else {
+		pippenger(output, scalars, points)
+	}
+}
+
+// MultiScalarMultKeys is same as MultiScalarMult, except points are provided and result returned in compressed form
+func MultiScalarMultKeys(scalars []Key, points []Key) (result Key) {
+	extended := make([]ExtendedGroupElement, len(points))
+	for i := range points {
+		extended[i].FromBytes(&points[i])
+	}
+	var output ExtendedGroupElement
+	MultiScalarMult(&output, scalars, extended)
+	output.ToBytes(&result)
+	return
+}
+
+// MultiScalarMultPrecomputed sets output = sum(scalars[i] * points[i])
+// where each point is given as table of A,3A,5A,7A,9A,11A,13A,15A prepared using GePrecompute
+// this is Straus algorithm, use it when the tables are used again and again such as for fixed bases
+func MultiScalarMultPrecomputed(output *ExtendedGroupElement, scalars []Key, tables []*[8]CachedGroupElement) {
+	if len(scalars) != len(tables) {
+		panic("MultiScalarMultPrecomputed requires same number of scalars and tables")
+	}
+
+	var t CompletedGroupElement
+	var r ProjectiveGroupElement
+
+	slides := make([][256]int8, len(scalars))
+	top := -1 // highest non zero position of all scalars
+	for j := range scalars {
+		slide(&slides[j], &scalars[j])
+		for i := 255; i > top; i-- {
+			if slides[j][i] != 0 {
+				top = i
+				break
+			}
+		}
+	}
+
+	output.Zero()
+	r.Zero()
+	for i := top; i >= 0; i-- {
+		r.Double(&t)
+		t.ToExtended(output)
+		for j := range slides {
+			if slides[j][i] > 0 {
+				geAdd(&t, output, &tables[j][slides[j][i]/2])
+				t.ToExtended(output)
+			} else if slides[j][i] < 0 {
+				geSub(&t, output, &tables[j][(-slides[j][i])/2])
+				t.ToExtended(output)
+			}
+		}
+		output.ToProjective(&r)
+	}
+}
+
+// Straus aka interleaved sliding window, tables are generated for every point
+func straus(output *ExtendedGroupElement, scalars []Key, points []ExtendedGroupElement) {
+	tables := make([][8]CachedGroupElement, len(points))
+	table_pointers := make([]*[8]CachedGroupElement, len(points))
+	for j := range points {
+		GePrecompute(&tables[j], &points[j])
+		table_pointers[j] = &tables[j]
+	}
+	MultiScalarMultPrecomputed(output, scalars, table_pointers)
+}
+
+// chooses window width, minimising number of additions, which are roughly (256/c) * (n + 2^(c+1))
+func pippenger_window(n int) (c uint) {
+	best := -1
+	for w := uint(1); w <= 16; w++ {
+		cost := (256 + int(w) - 1) / int(w) * (n + (2 << w))
+		if best < 0 || cost < best {
+			best, c = cost, w
+		}
+	}
+	return
+}
+
+// extracts width bits starting at pos from the little endian scalar
+func scalar_window(s *Key, pos, width uint) (digit uint) {
+	for i := uint(0); i < width && pos+i < 256; i++ {
+		digit |= uint((s[(pos+i)>>3]>>((pos+i)&7))&1) << i
+	}
+	return
+}
+
+// Pippenger aka bucket method
+func pippenger(output *ExtendedGroupElement, scalars []Key, points []ExtendedGroupElement) {
+	c := pippenger_window(len(points))
+	windows := (256 + c - 1) / c
+
+	cached := make([]CachedGroupElement, len(points))
+	for j := range points {
+		points[j].ToCached(&cached[j])
+	}
+
+	buckets := make([]ExtendedGroupElement, 1<<c)
+	used := make([]bool, 1<<c)
+
+	var t CompletedGroupElement
+	var r ProjectiveGroupElement
+	var tmp CachedGroupElement
+	var running, sum ExtendedGroupElement
+
+	output.Zero()
+	for w := int(windows) - 1; w >= 0; w-- {
+
+		// output = 2^c * output
+		output.ToProjective(&r)
+		for i := uint(0); i < c; i++ {
+			r.Double(&t)
+			t.ToProjective(&r)
+		}
+		t.ToExtended(output)
+
+		// drop every point in its bucket
+		for k := range used {
+			used[k] = false
+		}
+		for j := range points {
+			digit := scalar_window(&scalars[j], uint(w)*c, c)
+			if digit == 0 {
+				continue
+			}
+			if !used[digit] {
+				buckets[digit] = points[j]
+				used[digit] = true
+			} else {
+				geAdd(&t, &buckets[digit], &cached[j])
+				t.ToExtended(&buckets[digit])
+			}
+		}
+
+		// sum(k * bucket[k]) using running sums, from the highest bucket
+		running.Zero()
+		sum.Zero()
+		started := false
+		for k := len(buckets) - 1; k >= 1; k-- {
+			if used[k] {
+				buckets[k].ToCached(&tmp)
+				geAdd(&t, &running, &tmp)
+				t.ToExtended(&running)
+				started = true
+			}
+			if started {
+				running.ToCached(&tmp)
+				geAdd(&t, &sum, &tmp)
+				t.ToExtended(&sum)
+			}
+		}
+
+		sum.ToCached(&tmp)
+		geAdd(&t, output, &tmp)
+		t.ToExtended(output)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package crypto
+
+import "fmt"
+import "testing"
+
+// computes the sum one point at a time
+func multiexp_naive(scalars []Key, points []Key) (result Key) {
+	result = Identity
+	for i := range points {
+		AddKeys(&result, &result, ScalarMultKey(&points[i], &scalars[i]))
+	}
+	return
+}
+
+func multiexp_test_data(n int) (scalars []Key, points []Key) {
+	var one, max Key
+	one[0] = 1
+	ScSub(&max, &Zero, &one) // l - 1
+
+	for i := 0; i < n; i++ {
+		switch i % 7 {
+		case 0:
+			scalars = append(scalars, Zero)
+		case 1:
+			scalars = append(scalars, one)
+		case 2:
+			scalars = append(scalars, max)
+		default:
+			scalars = append(scalars, *RandomScalar())
+		}
+
+		if i%5 == 4 {
+			points = append(points, Identity)
+		} else {
+			points = append(points, *RandomPubKey())
+		}
+	}
+	return
+}
+
+func Test_MultiScalarMult(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 17, 64, STRAUS_LIMIT - 1, STRAUS_LIMIT, 300} {
+		scalars, points := multiexp_test_data(n)
+		expected := multiexp_naive(scalars, points)
+
+		if result := MultiScalarMultKeys(scalars, points); result != expected {
+			t.Fatalf("MultiScalarMult mismatch for %d points", n)
+		}
+
+		extended := make([]ExtendedGroupElement, n)
+		for i := range points {
+			extended[i].FromBytes(&points[i])
+		}
+
+		var output ExtendedGroupElement
+		var result Key
+		straus(&output, scalars, extended)
+		if output.ToBytes(&result); result != expected {
+			t.Fatalf("Straus mismatch for %d points", n)
+		}
+		pippenger(&output, scalars, extended)
+		if output.ToBytes(&result); result != expected {
+			t.Fatalf("Pippenger mismatch for %d points", n)
+		}
+	}
+}
+
+func Test_MultiScalarMultPrecomputed(t *testing.T) {
+	scalars, points := multiexp_test_data(20)
+	scalars = append(scalars, *RandomScalar())
+	points = append(points, ScalarmultBase(*RandomScalar()))
+
+	tables := make([]*[8]CachedGroupElement, len(points))
+	for i := range points {
+		tables[i] = new([8]CachedGroupElement)
+		GePrecompute(tables[i], points[i].ToExtended())
+	}
+	tables[0] = &GBASE_Cached
+	points[0] = ScalarmultBase(Identity) // base point G, Identity also encodes scalar 1
+
+	var output ExtendedGroupElement
+	var result Key
+	MultiScalarMultPrecomputed(&output, scalars, tables)
+	if output.ToBytes(&result); result != multiexp_naive(scalars, points) {
+		t.Fatalf("MultiScalarMultPrecomputed mismatch")
+	}
+}
+
+// go test -run XXX -bench MultiScalarMult ./crypto/
+func BenchmarkMultiScalarMult(b *testing.B) {
+	for _, n := range []int{2, 16, 64, 128, 256, 512, 1024} {
+		scalars, keys := multiexp_test_data(n)
+		points := make([]ExtendedGroupElement, n)
+		for i := range keys {
+			points[i].FromBytes(&keys[i])
+		}
+		var output ExtendedGroupElement
+
+		b.Run(fmt.Sprintf("naive/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				multiexp_naive(scalars, keys)
+			}
+		})
+		b.Run(fmt.Sprintf("straus/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				straus(&output, scalars, points)
+			}
+		})
+		b.Run(fmt.Sprintf("pippenger/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				pippenger(&output, scalars, points)
+			}
+		})
+	}
+}
@@ -97,12 +97,17 @@ func vector_exponent(a []crypto.Key, b []crypto.Key) (result crypto.Key) {
 		panic("Incompatible sizes of a and maxN")
 	}
 
-	result = crypto.Identity
+	// all 2*maxN terms are computed together, tables for Gi, Hi are already available
+	scalars := make([]crypto.Key, 0, 2*len(a))
+	tables := make([]*[8]crypto.CachedGroupElement, 0, 2*len(a))
 	for i := range a {
-		var term crypto.Key
-		crypto.AddKeys3_3(&term, &a[i], &Gi_Precomputed[i], &b[i], &Hi_Precomputed[i])
-		crypto.AddKeys(&result, &result, &term)
+		scalars = append(scalars, a[i], b[i])
+		tables = append(tables, &Gi_Precomputed[i], &Hi_Precomputed[i])
 	}
+
+	var output crypto.ExtendedGroupElement
+	crypto.MultiScalarMultPrecomputed(&output, scalars, tables)
+	output.ToBytes(&result)
 	return
 }
 
@@ -120,23 +125,17 @@ func vector_exponent_custom(A []crypto.Key, B []crypto.Key, a []crypto.Key, b []
 		panic("Incompatible sizes of a and A")
 	}
 
-	if !(len(a) <= maxN) {
-		panic("Incompatible sizes of a and maxN")
+	if !(len(a) <= maxMN) {
+		panic("Incompatible sizes of a and maxMN")
 	}
 
-	result = crypto.Identity
+	scalars := make([]crypto.Key, 0, 2*len(a))
+	points := make([]crypto.Key, 0, 2*len(a))
 	for i := range a {
-		var term crypto.Key
-
-		var B_Precomputed [8]crypto.CachedGroupElement
-		Be := new(crypto.ExtendedGroupElement)
-		Be.FromBytes(&B[i])
-		crypto.GePrecompute(&B_Precomputed, Be)
-
-		crypto.AddKeys3(&term, &a[i], &A[i], &b[i], &B_Precomputed)
-		crypto.AddKeys(&result, &result, &term)
+		scalars = append(scalars, a[i], b[i])
+		points = append(points, A[i], B[i])
 	}
-	return result
+	return crypto.MultiScalarMultKeys(scalars, points)
 
 }
 
@@ -0,0 +1,414 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package ringct
+
+import "fmt"
+import "sync"
+
+import "github.com/deroproject/derosuite/crypto"
+
+// aggregated bulletproofs, a single proof covers the amounts of all the outputs of a tx
+// M amounts are proved using M*64 generators in log2(M*64) rounds, so proof size grows logarithmically
+// M is padded to the next power of 2 using zero amounts with zero masks, these are not part of V
+// a proof for a single amount is exactly same as the non-aggregate proof
+// see section 4.3 of https://eprint.iacr.org/2017/1066.pdf
+
+// maximum amounts in a single proof, tx cannot have more outputs, see config.MAX_VOUT
+const maxM = 8
+const maxMN = maxM * maxN
+
+// generators for aggregated proofs, first maxN are same as Gi, Hi
+var Gi_aggregate, Hi_aggregate [maxMN]crypto.Key
+var Gi_aggregate_Precomputed, Hi_aggregate_Precomputed [maxMN][8]crypto.CachedGroupElement
+var aggregateonce sync.Once
+
+// this should be called after Hi, Gi are setup
+func precompute_tables_aggregate() {
+	for i := 0; i < maxMN; i++ {
+		if i < maxN {
+			Gi_aggregate[i], Hi_aggregate[i] = Gi[i], Hi[i]
+			Gi_aggregate_Precomputed[i], Hi_aggregate_Precomputed[i] = Gi_Precomputed[i], Hi_Precomputed[i]
+			continue
+		}
+
+		Hi_aggregate[i] = get_exponent(crypto.H, uint64(i)*2)
+		Gi_aggregate[i] = get_exponent(crypto.H, uint64(i)*2+1)
+
+		var He, Ge crypto.ExtendedGroupElement
+		He.FromBytes(&Hi_aggregate[i])
+		crypto.GePrecompute(&Hi_aggregate_Precomputed[i], &He)
+		Ge.FromBytes(&Gi_aggregate[i])
+		crypto.GePrecompute(&Gi_aggregate_Precomputed[i], &Ge)
+	}
+}
+
+// returns log2 of the number of amounts, a proof covers, after padding
+func aggregate_logM(count int) (logM int) {
+	for (1 << uint(logM)) < count {
+		logM++
+	}
+	return
+}
+
+// same as vector_exponent, but for upto maxMN terms
+func vector_exponent_aggregate(a []crypto.Key, b []crypto.Key) (result crypto.Key) {
+	if len(a) != len(b) {
+		panic("Incompatible sizes of a and b")
+	}
+
+	if len(a) > maxMN {
+		panic("Incompatible sizes of a and maxMN")
+	}
+
+	scalars := make([]crypto.Key, 0, 2*len(a))
+	tables := make([]*[8]crypto.CachedGroupElement, 0, 2*len(a))
+	for i := range a {
+		scalars = append(scalars, a[i], b[i])
+		tables = append(tables, &Gi_aggregate_Precomputed[i], &Hi_aggregate_Precomputed[i])
+	}
+
+	var output crypto.ExtendedGroupElement
+	crypto.MultiScalarMultPrecomputed(&output, scalars, tables)
+	output.ToBytes(&result)
+	return
+}
+
+// Given values sv[j] (0..2^64-1) and masks gamma[j], construct a single range proof for all of them
+func BULLETPROOF_Prove_Aggregate(sv []crypto.Key, gamma []crypto.Key) *BulletProof {
+	if len(sv) != len(gamma) {
+		panic("Incompatible sizes of sv and gamma")
+	}
+	if len(sv) < 1 || len(sv) > maxM {
+		panic(fmt.Sprintf("aggregate bulletproof can prove 1 to %d amounts, not %d", maxM, len(sv)))
+	}
+
+	aggregateonce.Do(precompute_tables_aggregate) // generate pre compute tables
+
+	const logN = int(6) // log2(64)
+	const N = int(64)   // 1 << logN
+	logM := aggregate_logM(len(sv))
+	M := 1 << uint(logM)
+	logMN := logM + logN
+	MN := M * N
+
+	// prove V
+	V := make([]crypto.Key, len(sv), len(sv))
+	var V_bytes []byte
+	for j := range sv {
+		crypto.AddKeys2(&V[j], &gamma[j], &sv[j], &crypto.H)
+		V_bytes = append(V_bytes, V[j][:]...)
+	}
+
+	// prove aL,aR
+	// padded amounts are zero
+	aL := make([]crypto.Key, MN, MN)
+	aR := make([]crypto.Key, MN, MN)
+	for j := 0; j < M; j++ {
+		for i := N - 1; i >= 0; i-- {
+			aL[j*N+i] = crypto.Zero
+			if j < len(sv) && (sv[j][i/8]&(1<<(uint64(i)%8))) >= 1 {
+				aL[j*N+i] = crypto.Identity
+			}
+			crypto.ScSub(&aR[j*N+i], &aL[j*N+i], &crypto.Identity)
+		}
+	}
+
+	hashcache := *(crypto.HashToScalar(V_bytes))
+
+	// PAPER LINES 38-39
+	alpha := crypto.SkGen()
+	ve := vector_exponent_aggregate(aL, aR)
+	alpha_base_tmp := crypto.ScalarmultBase(alpha)
+	A := AddKeys_return(&ve, &alpha_base_tmp)
+
+	// PAPER LINES 40-42
+	sL := make([]crypto.Key, MN, MN)
+	sR := make([]crypto.Key, MN, MN)
+	for i := range sL {
+		sL[i] = crypto.SkGen()
+		sR[i] = crypto.SkGen()
+	}
+	rho := crypto.SkGen()
+	ve = vector_exponent_aggregate(sL, sR)
+	rho_base_tmp := crypto.ScalarmultBase(rho)
+	S := AddKeys_return(&ve, &rho_base_tmp)
+
+	// PAPER LINES 43-45
+	y := hash_cache_mash2(&hashcache, A, S)
+	hashcache = *(crypto.HashToScalar(y[:]))
+	z := hashcache
+
+	// Polynomial construction by coefficients
+	// l(x) = l0 + l1*x,  r(x) = r0 + r1*x
+	zpow := vector_powers(z, int64(M+3))
+	yMN := vector_powers(y, int64(MN))
+
+	zero_twos := make([]crypto.Key, MN, MN) // z^(j+2) * 2^i for amount j
+	vpIz := make([]crypto.Key, MN, MN)
+	for j := 0; j < M; j++ {
+		for i := 0; i < N; i++ {
+			crypto.ScMul(&zero_twos[j*N+i], &zpow[j+2], &twoN[i])
+			vpIz[j*N+i] = z
+		}
+	}
+
+	l0 := vector_subtract(aL, vpIz)
+	r0 := vector_add(hadamard(vector_add(aR, vpIz), yMN), zero_twos)
+	r1 := hadamard(yMN, sR)
+
+	// PAPER LINE 46
+	var t1, t2 crypto.Key
+	ip1 := inner_product(l0, r1)
+	ip2 := inner_product(sL, r0)
+	crypto.ScAdd(&t1, &ip1, &ip2)
+	t2 = inner_product(sL, r1)
+
+	// PAPER LINES 47-48
+	tau1 := crypto.SkGen()
+	tau2 := crypto.SkGen()
+
+	tau1_base := crypto.ScalarmultBase(tau1)
+	T1 := AddKeys_return(crypto.ScalarMultKey(&crypto.H, &t1), &tau1_base)
+
+	tau2_base := crypto.ScalarmultBase(tau2)
+	T2 := AddKeys_return(crypto.ScalarMultKey(&crypto.H, &t2), &tau2_base)
+
+	// PAPER LINES 49-51
+	x := hash_cache_mash3(&hashcache, z, T1, T2)
+
+	// PAPER LINES 52-53
+	var taux, xsq crypto.Key
+	crypto.ScMul(&taux, &tau1, &x)
+	crypto.ScMul(&xsq, &x, &x)
+	crypto.ScMulAdd(&taux, &tau2, &xsq, &taux)
+	for j := range gamma {
+		crypto.ScMulAdd(&taux, &zpow[j+2], &gamma[j], &taux)
+	}
+
+	var mu crypto.Key
+	crypto.ScMulAdd(&mu, &x, &rho, &alpha)
+
+	// PAPER LINES 54-57
+	l := vector_add(l0, vector_scalar(sL, x))
+	r := vector_add(r0, vector_scalar(r1, x))
+
+	t := inner_product(l, r)
+
+	// PAPER LINES 32-33
+	x_ip := hash_cache_mash4(&hashcache, x, taux, mu, t)
+
+	// These are used in the inner product rounds
+	Gprime := make([]crypto.Key, MN, MN)
+	Hprime := make([]crypto.Key, MN, MN)
+	aprime := l
+	bprime := r
+
+	yinv := invert_scalar(y)
+	yinvpow := crypto.Identity
+	for i := 0; i < MN; i++ {
+		Gprime[i] = Gi_aggregate[i]
+		Hprime[i] = *(crypto.ScalarMultKey(&Hi_aggregate[i], &yinvpow))
+		crypto.ScMul(&yinvpow, &yinvpow, &yinv)
+	}
+
+	// PAPER LINE 13
+	round := 0
+	nprime := MN
+	L := make([]crypto.Key, logMN, logMN)
+	R := make([]crypto.Key, logMN, logMN)
+	w := make([]crypto.Key, logMN, logMN)
+	var tmp crypto.Key
+
+	for nprime > 1 {
+		// PAPER LINE 15
+		nprime /= 2
+
+		// PAPER LINES 16-17
+		cL := inner_product(slice_vector(aprime, 0, nprime), slice_vector(bprime, nprime, len(bprime)))
+		cR := inner_product(slice_vector(aprime, nprime, len(aprime)), slice_vector(bprime, 0, nprime))
+
+		// PAPER LINES 18-19
+		L[round] = vector_exponent_custom(slice_vector(Gprime, nprime, len(Gprime)), slice_vector(Hprime, 0, nprime), slice_vector(aprime, 0, nprime), slice_vector(bprime, nprime, len(bprime)))
+		crypto.ScMul(&tmp, &cL, &x_ip)
+		crypto.AddKeys(&L[round], &L[round], crypto.ScalarMultKey(&crypto.H, &tmp))
+		R[round] = vector_exponent_custom(slice_vector(Gprime, 0, nprime), slice_vector(Hprime, nprime, len(Hprime)), slice_vector(aprime, nprime, len(aprime)), slice_vector(bprime, 0, nprime))
+		crypto.ScMul(&tmp, &cR, &x_ip)
+		crypto.AddKeys(&R[round], &R[round], crypto.ScalarMultKey(&crypto.H, &tmp))
+
+		// PAPER LINES 21-22
+		w[round] = hash_cache_mash2(&hashcache, L[round], R[round])
+
+		// PAPER LINES 24-25
+		winv := invert_scalar(w[round])
+		Gprime = hadamard2(vector_scalar2(slice_vector(Gprime, 0, nprime), winv), vector_scalar2(slice_vector(Gprime, nprime, len(Gprime)), w[round]))
+		Hprime = hadamard2(vector_scalar2(slice_vector(Hprime, 0, nprime), w[round]), vector_scalar2(slice_vector(Hprime, nprime, len(Hprime)), winv))
+
+		// PAPER LINES 28-29
+		aprime = vector_add(vector_scalar(slice_vector(aprime, 0, nprime), w[round]), vector_scalar(slice_vector(aprime, nprime, len(aprime)), winv))
+		bprime = vector_add(vector_scalar(slice_vector(bprime, 0, nprime), winv), vector_scalar(slice_vector(bprime, nprime, len(bprime)), w[round]))
+
+		round++
+	}
+
+	return &BulletProof{
+		V:    V,
+		A:    A,
+		S:    S,
+		T1:   T1,
+		T2:   T2,
+		taux: taux,
+		mu:   mu,
+		L:    L,
+		R:    R,
+		a:    aprime[0],
+		b:    bprime[0],
+		t:    t,
+	}
+}
+
+// prove amounts in a single proof
+func BULLETPROOF_Prove_Amounts(v []uint64, gamma []crypto.Key) *BulletProof {
+	sv := make([]crypto.Key, len(v), len(v))
+	for j := range v {
+		sv[j] = *d2h(v[j])
+	}
+	return BULLETPROOF_Prove_Aggregate(sv, gamma)
+}
+
+// checks which do not need any curve arithmetic except cofactor checks
+// M is derived from number of V, so a proof cannot be padded more than necessary
+func (proof *BulletProof) bulletproof_aggregate_structure_valid() bool {
+	if len(proof.V) < 1 || len(proof.V) > maxM {
+		return false
+	}
+
+	if len(proof.L) != len(proof.R) {
+		//Mismatched L and R sizes
+		return false
+	}
+
+	if len(proof.L) != aggregate_logM(len(proof.V))+6 {
+		//Proof is not for 64 bits of every amount
+		return false
+	}
+
+	// all scalars must be reduced, so they are used as is in multi scalar multiplication
+	if !crypto.ScValid(&proof.taux) || !crypto.ScValid(&proof.mu) || !crypto.ScValid(&proof.a) ||
+		!crypto.ScValid(&proof.b) || !crypto.ScValid(&proof.t) {
+		return false
+	}
+
+	// checks V[0] and all other points
+	if !proof.BULLETPROOF_BasicChecks() {
+		return false
+	}
+
+	curve_order := crypto.CurveOrder()
+	for _, V := range proof.V[1:] {
+		if V == crypto.Zero || V == crypto.Identity {
+			return false
+		}
+		if *crypto.ScalarMultKey(&V, &curve_order) != crypto.Identity {
+			return false
+		}
+	}
+	return true
+}
+
+// verify an aggregated proof, V must be setup by the caller
+func (proof *BulletProof) BULLETPROOF_Verify_Aggregate() (result bool) {
+
+	defer func() { // safety so if anything wrong happens, verification fails
+		if r := recover(); r != nil {
+			result = false
+		}
+	}()
+
+	if !proof.bulletproof_aggregate_structure_valid() {
+		return false
+	}
+
+	aggregateonce.Do(precompute_tables_aggregate) // generate pre compute tables
+
+	c := proof.bulletproof_challenges()
+
+	var tmp crypto.Key
+	scalars := make([]crypto.Key, 0, 3+len(proof.V))
+	points := make([]crypto.Key, 0, 3+len(proof.V))
+
+	// PAPER LINE 61
+	// taux*G + (t - delta)*H - sum(z^(j+2)*V[j]) - x*T1 - xsq*T2 == identity
+	crypto.ScSub(&tmp, &proof.t, &c.delta)
+	scalars = append(scalars, tmp)
+	points = append(points, crypto.H)
+	for j := range proof.V {
+		crypto.ScSub(&tmp, &crypto.Zero, &c.zpow[j+2])
+		scalars = append(scalars, tmp)
+		points = append(points, proof.V[j])
+	}
+	crypto.ScSub(&tmp, &crypto.Zero, &c.x)
+	scalars = append(scalars, tmp)
+	points = append(points, proof.T1)
+	crypto.ScSub(&tmp, &crypto.Zero, &c.xsq)
+	scalars = append(scalars, tmp)
+	points = append(points, proof.T2)
+
+	L61 := crypto.MultiScalarMultKeys(scalars, points)
+	taux_base := crypto.ScalarmultBase(proof.taux)
+	crypto.AddKeys(&L61, &L61, &taux_base)
+	if L61 != crypto.Identity {
+		return false
+	}
+
+	// PAPER LINES 24-25, all M*64 generators are computed together
+	MN := len(c.s1)
+	gh_scalars := make([]crypto.Key, 0, 2*MN)
+	tables := make([]*[8]crypto.CachedGroupElement, 0, 2*MN)
+	for i := 0; i < MN; i++ {
+		gh_scalars = append(gh_scalars, c.s1[i], c.s2[i])
+		tables = append(tables, &Gi_aggregate_Precomputed[i], &Hi_aggregate_Precomputed[i])
+	}
+	var inner_prod_point crypto.ExtendedGroupElement
+	var inner_prod crypto.Key
+	crypto.MultiScalarMultPrecomputed(&inner_prod_point, gh_scalars, tables)
+	inner_prod_point.ToBytes(&inner_prod)
+
+	// PAPER LINES 26 and 62
+	// A + x*S - mu*G + sum(w^2*L + winv^2*R) + (t - a*b)*x_ip*H == inner_prod
+	scalars = scalars[:0]
+	points = points[:0]
+
+	crypto.ScMulSub(&tmp, &proof.a, &proof.b, &proof.t)
+	crypto.ScMul(&tmp, &tmp, &c.x_ip)
+	scalars = append(scalars, crypto.Identity, c.x, tmp) // Identity is also scalar 1
+	points = append(points, proof.A, proof.S, crypto.H)
+
+	for i := range c.w {
+		var wsq, winvsq crypto.Key
+		crypto.ScMul(&wsq, &c.w[i], &c.w[i])
+		crypto.ScMul(&winvsq, &c.winv[i], &c.winv[i])
+		scalars = append(scalars, wsq, winvsq)
+		points = append(points, proof.L[i], proof.R[i])
+	}
+
+	pprime := crypto.MultiScalarMultKeys(scalars, points)
+	crypto.ScSub(&tmp, &crypto.Zero, &proof.mu)
+	tmp_base := crypto.ScalarmultBase(tmp)
+	crypto.AddKeys(&pprime, &pprime, &tmp_base)
+
+	return pprime == inner_prod
+}
@@ -0,0 +1,153 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package ringct
+
+import "testing"
+import "math"
+import "math/rand"
+
+import "github.com/deroproject/derosuite/crypto"
+
+func aggregate_test_proof(count int) (proof *BulletProof, amounts []uint64, gamma []crypto.Key) {
+	for i := 0; i < count; i++ {
+		amounts = append(amounts, rand.Uint64())
+		gamma = append(gamma, crypto.SkGen())
+	}
+	proof = BULLETPROOF_Prove_Amounts(amounts, gamma)
+	return
+}
+
+func TestBulletProofAggregate(t *testing.T) {
+	for m := 1; m <= maxM; m++ {
+		proof, _, _ := aggregate_test_proof(m)
+		if len(proof.V) != m || len(proof.L) != aggregate_logM(m)+6 {
+			t.Fatalf("Aggregate proof for %d amounts has wrong shape V %d L %d", m, len(proof.V), len(proof.L))
+		}
+		if !proof.BULLETPROOF_Verify_Aggregate() {
+			t.Fatalf("Aggregate proof for %d amounts failed", m)
+		}
+	}
+
+	// edge amounts, together with padding
+	edge := []uint64{0, 1, math.MaxUint64}
+	gamma := []crypto.Key{crypto.SkGen(), crypto.SkGen(), crypto.SkGen()}
+	if !BULLETPROOF_Prove_Amounts(edge, gamma).BULLETPROOF_Verify_Aggregate() {
+		t.Fatalf("Aggregate proof for edge amounts failed")
+	}
+
+	// single amount proof is same as the non-aggregate proof
+	proof, _, _ := aggregate_test_proof(1)
+	if !proof.BULLETPROOF_Verify_ultrafast() {
+		t.Fatalf("Aggregate proof for 1 amount failed as single proof")
+	}
+	single := batch_test_proofs(1)[0]
+	if !single.BULLETPROOF_Verify_Aggregate() {
+		t.Fatalf("Single proof failed as aggregate proof")
+	}
+
+	proof, _, _ = aggregate_test_proof(5)
+
+	// commitments are bound to their positions
+	proof.V[1], proof.V[3] = proof.V[3], proof.V[1]
+	if proof.BULLETPROOF_Verify_Aggregate() {
+		t.Fatalf("Aggregate proof with swapped commitments verified")
+	}
+	proof.V[1], proof.V[3] = proof.V[3], proof.V[1]
+
+	// a commitment cannot be dropped or added
+	all_V := proof.V
+	proof.V = all_V[:4]
+	if proof.BULLETPROOF_Verify_Aggregate() {
+		t.Fatalf("Aggregate proof with missing commitment verified")
+	}
+	proof.V = append(append([]crypto.Key{}, all_V...), all_V[0])
+	if proof.BULLETPROOF_Verify_Aggregate() {
+		t.Fatalf("Aggregate proof with extra commitment verified")
+	}
+	proof.V = all_V
+
+	// tamper with a scalar
+	proof.t[0] ^= 1
+	if proof.BULLETPROOF_Verify_Aggregate() {
+		t.Fatalf("Aggregate proof with tampered t verified")
+	}
+	proof.t[0] ^= 1
+
+	// scalars must be reduced
+	curve_order := crypto.CurveOrder()
+	original_a := proof.a
+	var carry uint16
+	for i := range proof.a { // a + l, same scalar but not reduced
+		carry += uint16(proof.a[i]) + uint16(curve_order[i])
+		proof.a[i] = byte(carry)
+		carry >>= 8
+	}
+	if proof.BULLETPROOF_Verify_Aggregate() {
+		t.Fatalf("Aggregate proof with non reduced scalar verified")
+	}
+	proof.a = original_a
+
+	// out of range amount
+	sv := []crypto.Key{*d2h(5), *d2h(7)}
+	sv[1][8] = 1 // 2^64 + 7
+	bad := BULLETPROOF_Prove_Aggregate(sv, []crypto.Key{crypto.SkGen(), crypto.SkGen()})
+	if bad.BULLETPROOF_Verify_Aggregate() {
+		t.Fatalf("Aggregate proof for out of range amount verified")
+	}
+
+	if !proof.BULLETPROOF_Verify_Aggregate() {
+		t.Fatalf("Aggregate proof failed after restoring")
+	}
+}
+
+// aggregate proof for 8 outputs is much smaller than 8 single proofs
+func TestBulletProofAggregateSize(t *testing.T) {
+	proof, _, _ := aggregate_test_proof(maxM)
+	aggregate_size := len(proof.Serialize())
+
+	single_size := 0
+	for _, p := range batch_test_proofs(maxM) {
+		single_size += len(p.Serialize())
+	}
+
+	if aggregate_size*4 > single_size {
+		t.Fatalf("Aggregate proof size %d single proofs size %d", aggregate_size, single_size)
+	}
+}
+
+func BenchmarkBulletproofAggregateProve8(b *testing.B) {
+	amounts := make([]uint64, maxM)
+	gamma := make([]crypto.Key, maxM)
+	for i := range amounts {
+		amounts[i] = rand.Uint64()
+		gamma[i] = crypto.SkGen()
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		BULLETPROOF_Prove_Amounts(amounts, gamma)
+	}
+}
+
+func BenchmarkBulletproofAggregateVerify8(b *testing.B) {
+	proof, _, _ := aggregate_test_proof(maxM)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if !proof.BULLETPROOF_Verify_Aggregate() {
+			b.Fatalf("Aggregate verification failed")
+		}
+	}
+}
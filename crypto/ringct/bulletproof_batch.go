@@ -0,0 +1,210 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package ringct
+
+import "sync"
+import "runtime"
+
+import "github.com/deroproject/derosuite/crypto"
+
+// batch verification of bulletproofs
+// every proof gives 2 equations (PAPER LINE 61 and PAPER LINE 62), each of which must sum to identity
+// each equation is multiplied by a random weight and equations of all proofs are summed together
+// terms on G, H, Gi and Hi are merged, so the 64 base multiplication is done only once per batch
+// a bad proof can pass only if the random weights are guessed
+// cofactor tricks are not possible as BasicChecks confirm that all points are in the prime order subgroup
+
+// batches are split into chunks of at least this many proofs, which are verified on all cpus
+const batch_min_chunk = 4
+
+// BULLETPROOF_BatchVerify verifies all the proofs together, result is true only if every proof is valid
+// if the batch fails, proofs are verified one by one to find the bad one, its index is returned
+// failed is -1 if all the proofs are valid
+func BULLETPROOF_BatchVerify(proofs []*BulletProof) (result bool, failed int) {
+	valid := bulletproof_batch_parallel(proofs)
+	for i := range valid {
+		if !valid[i] {
+			return false, i
+		}
+	}
+	return true, -1
+}
+
+// VerifyBatch verifies many signatures, results[i] is the result of sigs[i]
+// bulletproofs of RCTTypeSimpleBulletproof signatures are collected and verified as a single batch
+// transactions must be expanded before verification
+func VerifyBatch(sigs []*RctSig) (results []bool) {
+	results = make([]bool, len(sigs))
+	sig_proofs := make([][]*BulletProof, len(sigs))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(sigs))
+	for i := range sigs {
+		go func(index int) {
+			defer wg.Done()
+			results[index] = sigs[index].verify(&sig_proofs[index])
+		}(i)
+	}
+	wg.Wait()
+
+	var proofs []*BulletProof
+	var owners []int // owners[i] is the index of the signature of proofs[i]
+	for i := range sigs {
+		if results[i] {
+			proofs = append(proofs, sig_proofs[i]...)
+			for range sig_proofs[i] {
+				owners = append(owners, i)
+			}
+		}
+	}
+
+	valid := bulletproof_batch_parallel(proofs)
+	for i := range valid {
+		if !valid[i] {
+			results[owners[i]] = false
+		}
+	}
+	return
+}
+
+// splits proofs into chunks and verifies each chunk as a batch in parallel
+// proofs of a failed chunk are verified one by one, valid[i] is the result of proofs[i]
+func bulletproof_batch_parallel(proofs []*BulletProof) (valid []bool) {
+	valid = make([]bool, len(proofs))
+
+	chunk := (len(proofs) + runtime.NumCPU() - 1) / runtime.NumCPU()
+	if chunk < batch_min_chunk {
+		chunk = batch_min_chunk
+	}
+
+	wg := sync.WaitGroup{}
+	for start := 0; start < len(proofs); start += chunk {
+		end := start + chunk
+		if end > len(proofs) {
+			end = len(proofs)
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			if bulletproof_batch(proofs[start:end]) {
+				for i := start; i < end; i++ {
+					valid[i] = true
+				}
+				return
+			}
+
+			// batch failed, single verification has the final say
+			for i := start; i < end; i++ {
+				valid[i] = proofs[i].BULLETPROOF_Verify_ultrafast()
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	return
+}
+
+// verifies all proofs as a single multi exponentiation
+func bulletproof_batch(proofs []*BulletProof) (result bool) {
+
+	defer func() { // safety so if anything wrong happens, verification fails
+		if r := recover(); r != nil {
+			result = false
+		}
+	}()
+
+	ultraonce.Do(precompute_tables_ultra) // generate pre compute tables
+
+	var G_scalar, H_scalar crypto.Key
+	var Gi_scalars, Hi_scalars [maxN]crypto.Key
+	scalars := make([]crypto.Key, 0, 1+len(proofs)*(5+2*6))
+	points := make([]crypto.Key, 0, 1+len(proofs)*(5+2*6))
+
+	var tmp crypto.Key
+	for _, proof := range proofs {
+		if !proof.bulletproof_structure_valid() {
+			return false
+		}
+
+		// single verification multiplies taux and t without reducing them, which differs above 2^255
+		// such proofs are left for single verification to decide
+		if proof.taux[31] > 127 || proof.t[31] > 127 {
+			return false
+		}
+
+		c := proof.bulletproof_challenges()
+
+		weight_y := *crypto.RandomScalar() // weight for PAPER LINE 61
+		weight_z := *crypto.RandomScalar() // weight for PAPER LINE 62
+
+		// PAPER LINE 61
+		// taux*G + (t - delta)*H - zsq*V - x*T1 - xsq*T2 == identity
+		crypto.ScMulAdd(&G_scalar, &weight_y, &proof.taux, &G_scalar)
+		crypto.ScSub(&tmp, &proof.t, &c.delta)
+		crypto.ScMulAdd(&H_scalar, &weight_y, &tmp, &H_scalar)
+
+		var V_scalar, T1_scalar, T2_scalar crypto.Key
+		crypto.ScMulSub(&V_scalar, &weight_y, &c.zsq, &crypto.Zero)
+		crypto.ScMulSub(&T1_scalar, &weight_y, &c.x, &crypto.Zero)
+		crypto.ScMulSub(&T2_scalar, &weight_y, &c.xsq, &crypto.Zero)
+		scalars = append(scalars, V_scalar, T1_scalar, T2_scalar)
+		points = append(points, proof.V[0], proof.T1, proof.T2)
+
+		// PAPER LINE 62
+		// A + x*S - mu*G + sum(w^2*L + winv^2*R) + (t - a*b)*x_ip*H - sum(s1*Gi + s2*Hi) == identity
+		var S_scalar crypto.Key
+		crypto.ScMul(&S_scalar, &weight_z, &c.x)
+		scalars = append(scalars, weight_z, S_scalar)
+		points = append(points, proof.A, proof.S)
+
+		for i := range c.w {
+			var L_scalar, R_scalar crypto.Key
+			crypto.ScMul(&L_scalar, &c.w[i], &c.w[i])
+			crypto.ScMul(&L_scalar, &L_scalar, &weight_z)
+			crypto.ScMul(&R_scalar, &c.winv[i], &c.winv[i])
+			crypto.ScMul(&R_scalar, &R_scalar, &weight_z)
+			scalars = append(scalars, L_scalar, R_scalar)
+			points = append(points, proof.L[i], proof.R[i])
+		}
+
+		crypto.ScMulSub(&G_scalar, &weight_z, &proof.mu, &G_scalar)
+
+		crypto.ScMulSub(&tmp, &proof.a, &proof.b, &proof.t)
+		crypto.ScMul(&tmp, &tmp, &c.x_ip)
+		crypto.ScMulAdd(&H_scalar, &weight_z, &tmp, &H_scalar)
+
+		for i := range Gi_scalars {
+			crypto.ScMulSub(&Gi_scalars[i], &weight_z, &c.s1[i], &Gi_scalars[i])
+			crypto.ScMulSub(&Hi_scalars[i], &weight_z, &c.s2[i], &Hi_scalars[i])
+		}
+	}
+
+	var sum crypto.Key
+	var Gi_Hi_sum crypto.ExtendedGroupElement
+	crypto.DoubleScalarDoubleBaseMulPrecomputed64(&Gi_Hi_sum, Gi_scalars[:], Hi_scalars[:], Gi_Hi[:])
+	Gi_Hi_sum.ToBytes(&sum)
+
+	G_sum := crypto.ScalarmultBase(G_scalar)
+	crypto.AddKeys(&sum, &sum, &G_sum)
+
+	scalars = append(scalars, H_scalar)
+	points = append(points, crypto.H)
+	terms_sum := crypto.MultiScalarMultKeys(scalars, points)
+	crypto.AddKeys(&sum, &sum, &terms_sum)
+
+	return sum == crypto.Identity
+}
@@ -0,0 +1,160 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package ringct
+
+import "testing"
+import "math/rand"
+
+import "github.com/deroproject/derosuite/crypto"
+
+func batch_test_proofs(count int) (proofs []*BulletProof) {
+	for i := 0; i < count; i++ {
+		gamma := crypto.SkGen()
+		proofs = append(proofs, BULLETPROOF_Prove_Amount(rand.Uint64(), &gamma))
+	}
+	return
+}
+
+func TestBulletProofBatch(t *testing.T) {
+	if result, failed := BULLETPROOF_BatchVerify(nil); !result || failed != -1 {
+		t.Fatalf("Empty batch must verify")
+	}
+
+	proofs := batch_test_proofs(8)
+	if !bulletproof_batch(proofs) {
+		t.Fatalf("Batch of valid proofs failed")
+	}
+	if result, failed := BULLETPROOF_BatchVerify(proofs); !result || failed != -1 {
+		t.Fatalf("Batch of valid proofs failed, index %d", failed)
+	}
+
+	for i := range proofs { // batch of 1 must be same as single verification
+		if !bulletproof_batch(proofs[i : i+1]) {
+			t.Fatalf("Single proof batch failed")
+		}
+	}
+
+	// swapping commitments between proofs must fail, although each proof is well formed
+	proofs[2].V, proofs[5].V = proofs[5].V, proofs[2].V
+	if result, failed := BULLETPROOF_BatchVerify(proofs); result || failed != 2 {
+		t.Fatalf("Batch with swapped commitments verified, index %d", failed)
+	}
+	proofs[2].V, proofs[5].V = proofs[5].V, proofs[2].V
+
+	// tamper with a scalar
+	proofs[6].t[0] ^= 1
+	if result, failed := BULLETPROOF_BatchVerify(proofs); result || failed != 6 {
+		t.Fatalf("Batch with tampered proof verified, index %d", failed)
+	}
+	proofs[6].t[0] ^= 1
+
+	// t above 2^255 must be left to single verification
+	proofs[3].t[31] |= 0x80
+	if bulletproof_batch(proofs) {
+		t.Fatalf("Batch must not decide proofs with non canonical scalars")
+	}
+	proofs[3].t[31] &^= 0x80
+
+	// out of range amount
+	random_gamma := crypto.SkGen()
+	invalid_8 := crypto.Zero
+	invalid_8[8] = 1
+	proofs = append(proofs, BULLETPROOF_Prove(&invalid_8, &random_gamma))
+	if result, failed := BULLETPROOF_BatchVerify(proofs); result || failed != 8 {
+		t.Fatalf("Batch with out of range proof verified, index %d", failed)
+	}
+
+	// malformed proof
+	proofs[8] = batch_test_proofs(1)[0]
+	proofs[8].L = proofs[8].L[:5]
+	if result, failed := BULLETPROOF_BatchVerify(proofs); result || failed != 8 {
+		t.Fatalf("Batch with malformed proof verified, index %d", failed)
+	}
+}
+
+// signature spending a single input of 1000 into 2 outputs with fees of 100
+func batch_test_sig(sigType uint8) *RctSig {
+	var sk, pub CtKey
+	sk.Destination, sk.Mask = crypto.SkGen(), crypto.SkGen()
+	pub.Destination = crypto.ScalarmultBase(sk.Destination)
+	genC(&pub.Mask, &sk.Mask, 1000)
+
+	input := Input_info{Amount: 1000, Key_image: crypto.Hash(crypto.GenerateKeyImage(pub.Destination, sk.Destination)), Sk: sk, Pubs: []CtKey{pub}}
+	for i := 0; i < 4; i++ {
+		input.Pubs = append(input.Pubs, CtKey{Destination: crypto.ScalarmultBase(crypto.SkGen()), Mask: crypto.ScalarmultBase(crypto.SkGen())})
+	}
+	outputs := []Output_info{{Amount: 600, Scalar_Key: crypto.SkGen()}, {Amount: 300, Scalar_Key: crypto.SkGen()}}
+
+	var r RctSig
+	message := crypto.Hash(crypto.SkGen())
+	switch sigType {
+	case RCTTypeSimpleBulletproof:
+		r.Gen_RingCT_Simple_BulletProof(message, []Input_info{input}, outputs, 100)
+	case RCTTypeSimpleBulletproofAggregate:
+		r.Gen_RingCT_Simple_BulletProof_Aggregate(message, []Input_info{input}, outputs, 100)
+	case RCTTypeCLSAG:
+		r.Gen_RingCT_CLSAG(message, []Input_info{input}, outputs, 100)
+	}
+	return &r
+}
+
+func TestVerifyBatch(t *testing.T) {
+	if results := VerifyBatch(nil); len(results) != 0 {
+		t.Fatalf("Empty batch must have no results")
+	}
+
+	sigs := []*RctSig{batch_test_sig(RCTTypeSimpleBulletproof), batch_test_sig(RCTTypeSimpleBulletproofAggregate),
+		batch_test_sig(RCTTypeSimpleBulletproof), batch_test_sig(RCTTypeCLSAG), batch_test_sig(RCTTypeSimpleBulletproof)}
+	for i, result := range VerifyBatch(sigs) {
+		if !result || !sigs[i].Verify() {
+			t.Fatalf("Valid signature %d failed", i)
+		}
+	}
+
+	// proofs swapped between outputs, only the range proofs of this signature are wrong
+	sigs[2].BulletSigs[0], sigs[2].BulletSigs[1] = sigs[2].BulletSigs[1], sigs[2].BulletSigs[0]
+	sigs[3].Message[0] ^= 1 // signature of inputs is wrong
+	expected := []bool{true, true, false, false, true}
+	results := VerifyBatch(sigs)
+	for i := range sigs {
+		if results[i] != expected[i] || sigs[i].Verify() != expected[i] {
+			t.Fatalf("Signature %d result %v expected %v", i, results[i], expected[i])
+		}
+	}
+}
+
+func BenchmarkBulletproofBatchVerify16(b *testing.B) {
+	proofs := batch_test_proofs(16)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if result, _ := BULLETPROOF_BatchVerify(proofs); !result {
+			b.Fatalf("Batch verification failed")
+		}
+	}
+}
+
+func BenchmarkBulletproofSingleVerify16(b *testing.B) {
+	proofs := batch_test_proofs(16)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := range proofs {
+			if !proofs[i].BULLETPROOF_Verify_ultrafast() {
+				b.Fatalf("Verification failed")
+			}
+		}
+	}
+}
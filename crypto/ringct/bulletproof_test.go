@@ -738,3 +738,12 @@ func BenchmarkBulletproofVerifyultrafast(b *testing.B) {
 	}
 
 }
+
+func BenchmarkBulletproofProve(b *testing.B) {
+	s1 := *(crypto.RandomScalar())
+	for n := 0; n < b.N; n++ {
+		if BULLETPROOF_Prove_Amount(uint64(n), &s1) == nil {
+			b.Fatalf("BulletProof proving failed")
+		}
+	}
+}
@@ -50,6 +50,70 @@ func (proof *BulletProof) BULLETPROOF_Verify_ultrafast() (result bool) {
 
 	ultraonce.Do(precompute_tables_ultra) // generate pre compute tables
 
+	if !proof.bulletproof_structure_valid() {
+		return false
+	}
+
+	c := proof.bulletproof_challenges()
+
+	var tmp crypto.Key
+
+	// PAPER LINE 61
+	// t*H is kept as a separate multiplication, since proof.t is not reduced
+	taux_base := crypto.ScalarmultBase(proof.taux)
+	L61Left := AddKeys_return(&taux_base, crypto.ScalarMultKey(&crypto.H, &proof.t))
+
+	// delta*H + zsq*V + x*T1 + xsq*T2
+	L61Right := crypto.MultiScalarMultKeys([]crypto.Key{c.delta, c.zsq, c.x, c.xsq}, []crypto.Key{crypto.H, proof.V[0], proof.T1, proof.T2})
+
+	if !(L61Right == L61Left) {
+		//MERROR("Verification failure at step 1");
+		return false
+	}
+
+	// Basically PAPER LINES 24-25
+	// Compute the curvepoints from G[i] and H[i]
+	inner_prod := crypto.Identity // rct::key inner_prod = rct::identity();
+
+	var intermediate_inner_prod crypto.ExtendedGroupElement
+	crypto.DoubleScalarDoubleBaseMulPrecomputed64(&intermediate_inner_prod, c.s1[:], c.s2[:], Gi_Hi[:])
+	intermediate_inner_prod.ToBytes(&inner_prod)
+
+	// PAPER LINES 26 and 62
+	// A + x*S - mu*G + sum(w^2*L + winv^2*R) + (t - a*b)*x_ip*H == inner_prod
+	scalars := make([]crypto.Key, 0, 3+2*len(c.w))
+	points := make([]crypto.Key, 0, 3+2*len(c.w))
+
+	crypto.ScMulSub(&tmp, &proof.a, &proof.b, &proof.t)
+	crypto.ScMul(&tmp, &tmp, &c.x_ip)
+	scalars = append(scalars, crypto.Identity, c.x, tmp) // Identity is also scalar 1
+	points = append(points, proof.A, proof.S, crypto.H)
+
+	for i := range c.w {
+		var wsq, winvsq crypto.Key
+		crypto.ScMul(&wsq, &c.w[i], &c.w[i])          //sc_mul(tmp.bytes, w[i].bytes, w[i].bytes);
+		crypto.ScMul(&winvsq, &c.winv[i], &c.winv[i]) //sc_mul(tmp2.bytes, winv[i].bytes, winv[i].bytes);
+		scalars = append(scalars, wsq, winvsq)
+		points = append(points, proof.L[i], proof.R[i])
+	}
+
+	pprime := crypto.MultiScalarMultKeys(scalars, points)
+
+	crypto.ScSub(&tmp, &crypto.Zero, &proof.mu) //sc_sub(tmp.bytes, rct::zero().bytes, proof.mu.bytes);
+	tmp_base := crypto.ScalarmultBase(tmp)
+	crypto.AddKeys(&pprime, &pprime, &tmp_base) //rct::addKeys(pprime, P, rct::scalarmultBase(tmp));
+
+	if !(pprime == inner_prod) {
+		// MERROR("Verification failure at step 2");
+		return false
+	}
+
+	return true
+}
+
+// checks which do not need any curve arithmetic except cofactor checks
+// these must pass before a proof is verified singly or in a batch
+func (proof *BulletProof) bulletproof_structure_valid() bool {
 	if !(len(proof.V) == 1) {
 		//V does not have exactly one element
 		return false
@@ -70,194 +134,117 @@ func (proof *BulletProof) BULLETPROOF_Verify_ultrafast() (result bool) {
 	}
 
 	// these checks try to filter out rogue inputs
-	if proof.BULLETPROOF_BasicChecks() == false{
-            return false
-        }
-    
+	return proof.BULLETPROOF_BasicChecks()
+}
+
+// challenges and scalars reconstructed from a proof
+// these are same whether the proof is verified singly or in a batch
+// a proof for M amounts has log2(M*64) rounds, single proofs have M = 1
+type bulletproof_challenges struct {
+	x, xsq, x_ip crypto.Key
+	zsq          crypto.Key
+	zpow         []crypto.Key // z^0 .. z^(M+2), V[j] is multiplied by z^(j+2) in PAPER LINE 61
+	delta        crypto.Key   // coefficient of H on right side of PAPER LINE 61
+	w, winv      []crypto.Key
+	s1, s2       []crypto.Key // scalars for Gi and Hi in PAPER LINES 24-25, M*64 of them
+}
+
+// proof must have passed bulletproof_structure_valid or bulletproof_aggregate_structure_valid
+func (proof *BulletProof) bulletproof_challenges() (c *bulletproof_challenges) {
+	c = new(bulletproof_challenges)
 
-	logN := len(proof.L)
-	N := int(1 << uint(logN))
+	logMN := len(proof.L)
+	MN := int(1 << uint(logMN))
+	M := MN / maxN
 
 	// reconstruct the challenges
-	hashcache := *(crypto.HashToScalar(proof.V[0][:]))  //rct::key hash_cache = rct::hash_to_scalar(proof.V[0]);
+	var V_bytes []byte
+	for i := range proof.V {
+		V_bytes = append(V_bytes, proof.V[i][:]...)
+	}
+	hashcache := *(crypto.HashToScalar(V_bytes))        //rct::key hash_cache = rct::hash_to_scalar(proof.V);
 	y := hash_cache_mash2(&hashcache, proof.A, proof.S) //  rct::key y = hash_cache_mash(hash_cache, proof.A, proof.S);
 
 	hashcache = *(crypto.HashToScalar(y[:])) // rct::key z = hash_cache = rct::hash_to_scalar(y);
 	z := hashcache
-	x := hash_cache_mash3(&hashcache, z, proof.T1, proof.T2) //rct::key x = hash_cache_mash(hash_cache, z, proof.T1, proof.T2);
+	c.x = hash_cache_mash3(&hashcache, z, proof.T1, proof.T2) //rct::key x = hash_cache_mash(hash_cache, z, proof.T1, proof.T2);
 
-	x_ip := hash_cache_mash4(&hashcache, x, proof.taux, proof.mu, proof.t) //rct::key x_ip = hash_cache_mash(hash_cache, x, proof.taux, proof.mu, proof.t);
+	c.x_ip = hash_cache_mash4(&hashcache, c.x, proof.taux, proof.mu, proof.t) //rct::key x_ip = hash_cache_mash(hash_cache, x, proof.taux, proof.mu, proof.t);
 
-	// PAPER LINE 61
-	//rct::key L61Left = rct::addKeys(rct::scalarmultBase(proof.taux), rct::scalarmultKey(rct::H, proof.t));
-	taux_base := crypto.ScalarmultBase(proof.taux)
-	L61Left := AddKeys_return(&taux_base, crypto.ScalarMultKey(&crypto.H, &proof.t))
-
-	k := crypto.Zero                 //rct::key k = rct::zero();
-	yN := vector_powers(y, int64(N)) //const auto yN = vector_powers(y, N);
-	ip1y := inner_product(oneN, yN)  //rct::key ip1y = inner_product(oneN, yN);
-	zsq := crypto.Zero               //rct::key zsq;
-	crypto.ScMul(&zsq, &z, &z)       //sc_mul(zsq.bytes, z.bytes, z.bytes);
-
-	var tmp, tmp2 crypto.Key             //rct::key tmp, tmp2;
-	crypto.ScMulSub(&k, &zsq, &ip1y, &k) //  sc_mulsub(k.bytes, zsq.bytes, ip1y.bytes, k.bytes);
-	var zcu crypto.Key                   //rct::key zcu;
-	crypto.ScMul(&zcu, &zsq, &z)         //sc_mul(zcu.bytes, zsq.bytes, z.bytes);
-	crypto.ScMulSub(&k, &zcu, &ip12, &k) //sc_mulsub(k.bytes, zcu.bytes, ip12.bytes, k.bytes);
-
-	crypto.ScMulAdd(&tmp, &z, &ip1y, &k)                 // sc_muladd(tmp.bytes, z.bytes, ip1y.bytes, k.bytes);
-	L61Right := *(crypto.ScalarMultKey(&crypto.H, &tmp)) //rct::key L61Right = rct::scalarmultKey(rct::H, tmp);
-
-	tmp = *(crypto.ScalarMultKey(&proof.V[0], &zsq)) //tmp = rct::scalarmultKey(proof.V[0], zsq);
-	crypto.AddKeys(&L61Right, &L61Right, &tmp)       //rct::addKeys(L61Right, L61Right, tmp);
-
-	tmp = *(crypto.ScalarMultKey(&proof.T1, &x)) // tmp = rct::scalarmultKey(proof.T1, x);
-	crypto.AddKeys(&L61Right, &L61Right, &tmp)   //ct::addKeys(L61Right, L61Right, tmp);
-
-	var xsq crypto.Key                             //rct::key xsq;
-	crypto.ScMul(&xsq, &x, &x)                     // sc_mul(xsq.bytes, x.bytes, x.bytes);
-	tmp = *(crypto.ScalarMultKey(&proof.T2, &xsq)) //tmp = rct::scalarmultKey(proof.T2, xsq);
-	crypto.AddKeys(&L61Right, &L61Right, &tmp)     //rct::addKeys(L61Right, L61Right, tmp);
+	k := crypto.Zero                   //rct::key k = rct::zero();
+	yMN := vector_powers(y, int64(MN)) //const auto yMN = vector_powers(y, MN);
+	ip1y := crypto.Zero                //rct::key ip1y = vector_sum(yMN);
+	for i := range yMN {
+		crypto.ScAdd(&ip1y, &ip1y, &yMN[i])
+	}
+	c.zpow = vector_powers(z, int64(M+3)) //const auto zpow = vector_powers(z, M+3);
+	c.zsq = c.zpow[2]
 
-	if !(L61Right == L61Left) {
-		//MERROR("Verification failure at step 1");
-		// fmt.Printf("erification failure at step 1")
-		return false
+	var tmp crypto.Key
+	crypto.ScMulSub(&k, &c.zsq, &ip1y, &k) //  sc_mulsub(k.bytes, zsq.bytes, ip1y.bytes, k.bytes);
+	for j := 1; j <= M; j++ {
+		crypto.ScMulSub(&k, &c.zpow[j+2], &ip12, &k) //sc_mulsub(k.bytes, zpow[j+2].bytes, ip12.bytes, k.bytes);
 	}
 
-	//fmt.Printf("Verification passed at step 1")
+	crypto.ScMulAdd(&c.delta, &z, &ip1y, &k) // sc_muladd(tmp.bytes, z.bytes, ip1y.bytes, k.bytes);
 
-	// PAPER LINE 62
-	P := AddKeys_return(&proof.A, crypto.ScalarMultKey(&proof.S, &x)) //rct::key P = rct::addKeys(proof.A, rct::scalarmultKey(proof.S, x));
+	crypto.ScMul(&c.xsq, &c.x, &c.x) // sc_mul(xsq.bytes, x.bytes, x.bytes);
 
 	// Compute the number of rounds for the inner product
 	rounds := len(proof.L)
 
 	// PAPER LINES 21-22
 	// The inner product challenges are computed per round
-	w := make([]crypto.Key, rounds, rounds) //  rct::keyV w(rounds);
-	for i := 0; i < rounds; i++ {           ///for (size_t i = 0; i < rounds; ++i)
-		w[i] = hash_cache_mash2(&hashcache, proof.L[i], proof.R[i]) //w[i] = hash_cache_mash(hash_cache, proof.L[i], proof.R[i]);
+	c.w = make([]crypto.Key, rounds, rounds) //  rct::keyV w(rounds);
+	for i := 0; i < rounds; i++ {            ///for (size_t i = 0; i < rounds; ++i)
+		c.w[i] = hash_cache_mash2(&hashcache, proof.L[i], proof.R[i]) //w[i] = hash_cache_mash(hash_cache, proof.L[i], proof.R[i]);
 	}
 
 	// Basically PAPER LINES 24-25
-	// Compute the curvepoints from G[i] and H[i]
-	inner_prod := crypto.Identity // rct::key inner_prod = rct::identity();
-	yinvpow := crypto.Identity    // rct::key yinvpow = rct::identity();
-	ypow := crypto.Identity       // rct::key ypow = rct::identity();
+	yinvpow := crypto.Identity // rct::key yinvpow = rct::identity();
+	ypow := crypto.Identity    // rct::key ypow = rct::identity();
 
-	yinv := invert_scalar(y)                   //const rct::key yinv = invert(y);
-	winv := make([]crypto.Key, rounds, rounds) //rct::keyV winv(rounds);
-	for i := 0; i < rounds; i++ {              //for (size_t i = 0; i < rounds; ++i)
-		winv[i] = invert_scalar(w[i]) //	winv[i] = invert(w[i]);
+	yinv := invert_scalar(y)                    //const rct::key yinv = invert(y);
+	c.winv = make([]crypto.Key, rounds, rounds) //rct::keyV winv(rounds);
+	for i := 0; i < rounds; i++ {               //for (size_t i = 0; i < rounds; ++i)
+		c.winv[i] = invert_scalar(c.w[i]) //	winv[i] = invert(w[i]);
 	}
 
-	var s1, s2 [maxN]crypto.Key // collect scalars for deferred computation
-
-	for i := 0; i < N; i++ { //for (size_t i = 0; i < N; ++i)
+	c.s1 = make([]crypto.Key, MN, MN)
+	c.s2 = make([]crypto.Key, MN, MN)
+	for i := 0; i < MN; i++ { //for (size_t i = 0; i < MN; ++i)
 
 		// Convert the index to binary IN REVERSE and construct the scalar exponent
 		g_scalar := proof.a                         //rct::key g_scalar = proof.a;
 		h_scalar := crypto.Zero                     // rct::key h_scalar;
 		crypto.ScMul(&h_scalar, &proof.b, &yinvpow) //sc_mul(h_scalar.bytes, proof.b.bytes, yinvpow.bytes);
 
-		// is this okay ???
 		for j := rounds; j > 0; { // for (size_t j = rounds; j-- > 0; )
 			j--
-			// FIXME below len can be ommitted and represents rounds
-			J := len(w) - j - 1 //size_t J = w.size() - j - 1;
+			J := len(c.w) - j - 1 //size_t J = w.size() - j - 1;
 
 			if i&((1)<<uint(j)) == 0 { /////if ((i & (((size_t)1)<<j)) == 0)
-				crypto.ScMul(&g_scalar, &g_scalar, &winv[J]) //sc_mul(g_scalar.bytes, g_scalar.bytes, winv[J].bytes);
-				crypto.ScMul(&h_scalar, &h_scalar, &w[J])    // sc_mul(h_scalar.bytes, h_scalar.bytes, w[J].bytes);
+				crypto.ScMul(&g_scalar, &g_scalar, &c.winv[J]) //sc_mul(g_scalar.bytes, g_scalar.bytes, winv[J].bytes);
+				crypto.ScMul(&h_scalar, &h_scalar, &c.w[J])    // sc_mul(h_scalar.bytes, h_scalar.bytes, w[J].bytes);
 			} else {
-				crypto.ScMul(&g_scalar, &g_scalar, &w[J])    //sc_mul(g_scalar.bytes, g_scalar.bytes, w[J].bytes);
-				crypto.ScMul(&h_scalar, &h_scalar, &winv[J]) //sc_mul(h_scalar.bytes, h_scalar.bytes, winv[J].bytes);
+				crypto.ScMul(&g_scalar, &g_scalar, &c.w[J])    //sc_mul(g_scalar.bytes, g_scalar.bytes, w[J].bytes);
+				crypto.ScMul(&h_scalar, &h_scalar, &c.winv[J]) //sc_mul(h_scalar.bytes, h_scalar.bytes, winv[J].bytes);
 			}
 		}
 
 		// Adjust the scalars using the exponents from PAPER LINE 62
 		crypto.ScAdd(&g_scalar, &g_scalar, &z)                // sc_add(g_scalar.bytes, g_scalar.bytes, z.bytes);
-		crypto.ScMul(&tmp, &zsq, &twoN[i])                    //sc_mul(tmp.bytes, zsq.bytes, twoN[i].bytes);
+		crypto.ScMul(&tmp, &c.zpow[2+i/maxN], &twoN[i%maxN])  //sc_mul(tmp.bytes, zpow[2+i/N].bytes, twoN[i%N].bytes);
 		crypto.ScMulAdd(&tmp, &z, &ypow, &tmp)                //sc_muladd(tmp.bytes, z.bytes, ypow.bytes, tmp.bytes);
 		crypto.ScMulSub(&h_scalar, &tmp, &yinvpow, &h_scalar) //  sc_mulsub(h_scalar.bytes, tmp.bytes, yinvpow.bytes, h_scalar.bytes);
 
-		// Now compute the basepoint's scalar multiplication
-		// Each of these could be written as a multiexp operation instead
-		// cross-check this line again
-		// TODO can be a major  performance improvement
-		// TODO maybe this can be used https://boringssl.googlesource.com/boringssl/+/2357/crypto/ec/wnaf.c
-		// https://github.com/bitcoin-core/secp256k1/blob/master/src/ecmult_impl.h
-		//crypto.AddKeys3_3(&tmp, &g_scalar, &Gi_Precomputed[i], &h_scalar, &Hi_Precomputed[i]) //rct::addKeys3(tmp, g_scalar, Gprecomp[i], h_scalar, Hprecomp[i]);
-		//crypto.AddKeys(&inner_prod, &inner_prod, &tmp)                                        //rct::addKeys(inner_prod, inner_prod, tmp);
+		c.s1[i] = g_scalar
+		c.s2[i] = h_scalar
 
-		s1[i] = g_scalar
-		s2[i] = h_scalar
-
-		if i != N-1 {
+		if i != MN-1 {
 			crypto.ScMul(&yinvpow, &yinvpow, &yinv) //sc_mul(yinvpow.bytes, yinvpow.bytes, yinv.bytes);
 			crypto.ScMul(&ypow, &ypow, &y)          //sc_mul(ypow.bytes, ypow.bytes, y.bytes);
 		}
 	}
-
-	var intermediate_inner_prod crypto.ExtendedGroupElement
-	crypto.DoubleScalarDoubleBaseMulPrecomputed64(&intermediate_inner_prod, s1[:], s2[:], Gi_Hi[:])
-	intermediate_inner_prod.ToBytes(&inner_prod)
-	//inner_prod = crypto.Multiscalarmult_compatibility(scalars,points)
-	//inner_prod = crypto.Multiscalarmult(scalars,points)
-
-	//fmt.Printf("inner prod ultra fast %s \n",inner_prod)
-
-	// PAPER LINE 26
-	var pprime crypto.Key                       //rct::key pprime;
-	crypto.ScSub(&tmp, &crypto.Zero, &proof.mu) //sc_sub(tmp.bytes, rct::zero().bytes, proof.mu.bytes);
-
-	tmp_base := crypto.ScalarmultBase(tmp)
-	crypto.AddKeys(&pprime, &P, &tmp_base) //rct::addKeys(pprime, P, rct::scalarmultBase(tmp));
-
-	for i := 0; i < rounds; i++ { //for (size_t i = 0; i < rounds; ++i)
-
-		crypto.ScMul(&tmp, &w[i], &w[i])        //sc_mul(tmp.bytes, w[i].bytes, w[i].bytes);
-		crypto.ScMul(&tmp2, &winv[i], &winv[i]) //sc_mul(tmp2.bytes, winv[i].bytes, winv[i].bytes);
-		//#if 1
-		// ge_dsmp cacheL, cacheR;
-		// rct::precomp(cacheL, proof.L[i]);
-		//rct::precomp(cacheR, proof.R[i]);
-
-		ProofLi := new(crypto.ExtendedGroupElement)
-		ProofLi.FromBytes(&proof.L[i])
-
-		ProofRi := new(crypto.ExtendedGroupElement)
-		ProofRi.FromBytes(&proof.R[i])
-
-		var ProofLi_precomputed [8]crypto.CachedGroupElement // A,3A,5A,7A,9A,11A,13A,15A
-		crypto.GePrecompute(&ProofLi_precomputed, ProofLi)
-
-		var ProofRi_precomputed [8]crypto.CachedGroupElement // A,3A,5A,7A,9A,11A,13A,15A
-		crypto.GePrecompute(&ProofRi_precomputed, ProofRi)
-
-		// optimise these at the end only if possible
-		crypto.AddKeys3_3(&tmp, &tmp, &ProofLi_precomputed, &tmp2, &ProofRi_precomputed) //rct::addKeys3(tmp, tmp, cacheL, tmp2, cacheR);
-		crypto.AddKeys(&pprime, &pprime, &tmp)                                           //rct::addKeys(pprime, pprime, tmp);
-
-		//#endif
-	}
-
-	crypto.ScMul(&tmp, &proof.t, &x_ip)                                     // sc_mul(tmp.bytes, proof.t.bytes, x_ip.bytes);
-	crypto.AddKeys(&pprime, &pprime, crypto.ScalarMultKey(&crypto.H, &tmp)) //rct::addKeys(pprime, pprime, rct::scalarmultKey(rct::H, tmp));
-
-	crypto.ScMul(&tmp, &proof.a, &proof.b)         //  sc_mul(tmp.bytes, proof.a.bytes, proof.b.bytes);
-	crypto.ScMul(&tmp, &tmp, &x_ip)                // sc_mul(tmp.bytes, tmp.bytes, x_ip.bytes);
-	tmp = *(crypto.ScalarMultKey(&crypto.H, &tmp)) //tmp = rct::scalarmultKey(rct::H, tmp);
-	crypto.AddKeys(&tmp, &tmp, &inner_prod)        //rct::addKeys(tmp, tmp, inner_prod);
-
-	if !(pprime == tmp) {
-		// MERROR("Verification failure at step 2");
-		// fmt.Printf("Verification failure at step 2");
-		return false
-	}
-
-	//fmt.Printf("\n prime      %s\n tmp %s  bulletproof verified successfully\n", pprime, tmp)
-
-	return true
+	return
 }
@@ -0,0 +1,278 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package ringct
+
+import "fmt"
+
+import "github.com/deroproject/derosuite/crypto"
+
+/* This file implements CLSAG signatures for the transactions, see https://eprint.iacr.org/2019/654 */
+
+// CLSAG signs for the key and the commitment of the same ring member at once
+// both the rows are aggregated into a single row using the mu_P and mu_C challenges
+// thus only a single response is needed per ring member, instead of 2 as in MLSAG
+// D is the commitment key image, which is needed to prove the aggregated row
+
+// domain separators, so as hashes used in CLSAG cannot be reused elsewhere
+var clsag_domain_agg_0 = clsag_domain("CLSAG_agg_0")
+var clsag_domain_agg_1 = clsag_domain("CLSAG_agg_1")
+var clsag_domain_round = clsag_domain("CLSAG_round")
+
+func clsag_domain(name string) (result crypto.Key) {
+	copy(result[:], name)
+	return
+}
+
+// mu_P = H(agg_0 || P || C_nonzero || I || D || C_offset), mu_C is same except the domain
+func clsag_aggregation_coefficients(P []crypto.Key, C_nonzero []crypto.Key, I crypto.Key, D crypto.Key, C_offset crypto.Key) (mu_P, mu_C crypto.Key) {
+	data := make([]byte, 0, (2*len(P)+4)*32)
+	data = append(data, clsag_domain_agg_0[:]...)
+	for i := range P {
+		data = append(data, P[i][:]...)
+	}
+	for i := range C_nonzero {
+		data = append(data, C_nonzero[i][:]...)
+	}
+	data = append(data, I[:]...)
+	data = append(data, D[:]...)
+	data = append(data, C_offset[:]...)
+
+	mu_P = *crypto.HashToScalar(data)
+	copy(data, clsag_domain_agg_1[:])
+	mu_C = *crypto.HashToScalar(data)
+	return
+}
+
+// every round hashes round || P || C_nonzero || C_offset || message || L || R
+// this returns everything except L and R, which change every round
+func clsag_round_prefix(message crypto.Key, P []crypto.Key, C_nonzero []crypto.Key, C_offset crypto.Key) []byte {
+	data := make([]byte, 0, (2*len(P)+5)*32)
+	data = append(data, clsag_domain_round[:]...)
+	for i := range P {
+		data = append(data, P[i][:]...)
+	}
+	for i := range C_nonzero {
+		data = append(data, C_nonzero[i][:]...)
+	}
+	data = append(data, C_offset[:]...)
+	data = append(data, message[:]...)
+	return data
+}
+
+// point must be valid and in the prime order subgroup
+func clsag_point_valid(point *crypto.Key) bool {
+	var p crypto.ExtendedGroupElement
+	if !p.FromBytes(point) {
+		return false
+	}
+	return *crypto.ScalarMultKey(point, &L1) == Identity
+}
+
+// Concise Linkable Spontaneous Anonymous Group signatures (CLSAG signatures)
+// P are the ring member keys, C_nonzero are the ring member commitments
+// C_offset is the pseudo out, so the commitments signed are C_nonzero[i] - C_offset
+// p is the secret key for P[index], z is the secret key for C_nonzero[index] - C_offset
+// I = p * Hp(P[index]) and D = z * Hp(P[index])
+// this follows CLSAG_Gen from rctSigs.cpp, except D is stored as is and not divided by 8
+func CLSAG_Gen(message crypto.Key, P []crypto.Key, p crypto.Key, C_nonzero []crypto.Key, z crypto.Key, C_offset crypto.Key, index int) (sig ClsagSig) {
+	n := len(P)
+	if n < 2 {
+		panic("RingCT CLSAG_Gen  must have ring size > 1")
+	}
+	if len(C_nonzero) != n {
+		panic("RingCT CLSAG_Gen  keys and commitments size mismatch")
+	}
+	if index >= n {
+		panic("RingCT CLSAG_Gen Index out of range")
+	}
+
+	C := make([]crypto.Key, n, n)
+	for i := range C {
+		crypto.SubKeys(&C[i], &C_nonzero[i], &C_offset)
+	}
+
+	H_l := P[index].HashToPoint()
+	sig.I = *crypto.ScalarMultKey(&H_l, &p)
+	sig.D = *crypto.ScalarMultKey(&H_l, &z)
+
+	mu_P, mu_C := clsag_aggregation_coefficients(P, C_nonzero, sig.I, sig.D, C_offset)
+
+	prefix := clsag_round_prefix(message, P, C_nonzero, C_offset)
+	toHash_bytes := make([]byte, 0, len(prefix)+64)
+
+	alpha := crypto.SkGen()
+	aG := crypto.ScalarmultBase(alpha)
+	aH := *crypto.ScalarMultKey(&H_l, &alpha)
+
+	toHash_bytes = append(append(append(toHash_bytes[:0], prefix...), aG[:]...), aH[:]...)
+	c := *crypto.HashToScalar(toHash_bytes)
+
+	sig.s = make([]crypto.Key, n, n)
+	i := (index + 1) % n
+	if i == 0 {
+		sig.c1 = c
+	}
+
+	var c_p, c_c, L, R, tmp crypto.Key
+	for i != index {
+		sig.s[i] = crypto.SkGen()
+		crypto.ScMul(&c_p, &c, &mu_P)
+		crypto.ScMul(&c_c, &c, &mu_C)
+
+		// L = s[i] * G + c_p * P[i] + c_c * C[i]
+		crypto.AddKeys2(&L, &sig.s[i], &c_p, &P[i])
+		crypto.AddKeys(&L, &L, crypto.ScalarMultKey(&C[i], &c_c))
+
+		// R = s[i] * Hp(P[i]) + c_p * I + c_c * D
+		H_i := P[i].HashToPoint()
+		R = *crypto.ScalarMultKey(&H_i, &sig.s[i])
+		crypto.AddKeys(&R, &R, crypto.ScalarMultKey(&sig.I, &c_p))
+		crypto.AddKeys(&R, &R, crypto.ScalarMultKey(&sig.D, &c_c))
+
+		toHash_bytes = append(append(append(toHash_bytes[:0], prefix...), L[:]...), R[:]...)
+		c = *crypto.HashToScalar(toHash_bytes)
+
+		i = (i + 1) % n
+		if i == 0 {
+			sig.c1 = c
+		}
+	}
+
+	// s[index] = alpha - c * (mu_P * p + mu_C * z)
+	crypto.ScMul(&tmp, &mu_P, &p)
+	crypto.ScMulAdd(&tmp, &mu_C, &z, &tmp)
+	crypto.ScMulSub(&sig.s[index], &c, &tmp, &alpha)
+
+	return
+}
+
+// verify a CLSAG signature, sig.I must have been setup from the tx/blockchain
+// instead of multiplying D by 8, D must be in the prime order subgroup
+func CLSAG_Ver(message crypto.Key, P []crypto.Key, C_nonzero []crypto.Key, C_offset crypto.Key, sig *ClsagSig) (result bool) {
+
+	defer func() { // safety so if anything wrong happens, verification fails
+		if r := recover(); r != nil {
+			result = false
+		}
+	}()
+
+	n := len(P)
+	if n < 2 {
+		if DEBUGGING_MODE {
+			fmt.Printf("RingCT CLSAG_Ver  must have ring size > 1\n")
+		}
+		return false
+	}
+
+	if len(C_nonzero) != n || len(sig.s) != n {
+		if DEBUGGING_MODE {
+			fmt.Printf("RingCT CLSAG_Ver  bad sizes, ring %d commitments %d responses %d\n", n, len(C_nonzero), len(sig.s))
+		}
+		return false
+	}
+
+	for i := range sig.s {
+		if !crypto.ScValid(&sig.s[i]) {
+			if DEBUGGING_MODE {
+				fmt.Printf("RingCT CLSAG_Ver Bad s slot\n")
+			}
+			return false
+		}
+	}
+	if !crypto.ScValid(&sig.c1) {
+		if DEBUGGING_MODE {
+			fmt.Printf("RingCT CLSAG_Ver Bad c1 slot\n")
+		}
+		return false
+	}
+
+	if sig.I == Identity || !clsag_point_valid(&sig.I) || !clsag_point_valid(&sig.D) {
+		if DEBUGGING_MODE {
+			fmt.Printf("RingCT CLSAG_Ver Bad key image or commitment key image\n")
+		}
+		return false
+	}
+
+	mu_P, mu_C := clsag_aggregation_coefficients(P, C_nonzero, sig.I, sig.D, C_offset)
+
+	var I_precomputed, D_precomputed [8]crypto.CachedGroupElement // do pre computation of key images
+	var point crypto.ExtendedGroupElement
+	point.FromBytes(&sig.I)
+	crypto.GePrecompute(&I_precomputed, &point)
+	point.FromBytes(&sig.D)
+	crypto.GePrecompute(&D_precomputed, &point)
+
+	// C[i] = C_nonzero[i] - C_offset is calculated without compressing, so -C_offset is prepared once
+	var C_offset_negated crypto.Key
+	var C_offset_cached crypto.CachedGroupElement
+	crypto.SubKeys(&C_offset_negated, &Identity, &C_offset)
+	if !point.FromBytes(&C_offset_negated) {
+		return false
+	}
+	point.ToCached(&C_offset_cached)
+
+	prefix := clsag_round_prefix(message, P, C_nonzero, C_offset)
+	toHash_bytes := make([]byte, 0, len(prefix)+64)
+
+	var c_p, c_c, L, R crypto.Key
+	var P_precomputed, C_precomputed, H_precomputed [8]crypto.CachedGroupElement
+	var L_point, R_point crypto.ExtendedGroupElement
+	var C_completed crypto.CompletedGroupElement
+	c := sig.c1
+	for i := 0; i < n; i++ {
+		crypto.ScMul(&c_p, &c, &mu_P)
+		crypto.ScMul(&c_c, &c, &mu_C)
+
+		if !point.FromBytes(&P[i]) { // ring members which are not points cannot be used
+			return false
+		}
+		crypto.GePrecompute(&P_precomputed, &point)
+		if !point.FromBytes(&C_nonzero[i]) {
+			return false
+		}
+		crypto.GeAdd(&C_completed, &point, &C_offset_cached)
+		C_completed.ToExtended(&point)
+		crypto.GePrecompute(&C_precomputed, &point)
+
+		// L = s[i] * G + c_p * P[i] + c_c * C[i]
+		crypto.MultiScalarMultPrecomputed(&L_point, []crypto.Key{sig.s[i], c_p, c_c}, []*[8]crypto.CachedGroupElement{&crypto.GBASE_Cached, &P_precomputed, &C_precomputed})
+		L_point.ToBytes(&L)
+
+		// R = s[i] * Hp(P[i]) + c_p * I + c_c * D
+		crypto.GePrecompute(&H_precomputed, P[i].HashToEC())
+		crypto.MultiScalarMultPrecomputed(&R_point, []crypto.Key{sig.s[i], c_p, c_c}, []*[8]crypto.CachedGroupElement{&H_precomputed, &I_precomputed, &D_precomputed})
+		R_point.ToBytes(&R)
+
+		toHash_bytes = append(append(append(toHash_bytes[:0], prefix...), L[:]...), R[:]...)
+		c = *crypto.HashToScalar(toHash_bytes)
+	}
+
+	// c = c - c1
+	crypto.ScSub(&c, &c, &sig.c1)
+
+	// if 0 checksum verified, otherwise checksum failed
+	result = crypto.ScIsZero(&c)
+
+	if DEBUGGING_MODE {
+		if result {
+			fmt.Printf("RingCT CLSAG_Ver Success\n")
+		} else {
+			fmt.Printf("RingCT CLSAG_Ver  verification failed\n")
+		}
+	}
+	return
+}
@@ -0,0 +1,177 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package ringct
+
+import "bytes"
+import "testing"
+import "encoding/hex"
+
+import "github.com/deroproject/derosuite/crypto"
+
+// point of order 2, adding it to a point takes the point out of the prime order subgroup
+var clsag_test_torsion = crypto.HexToKey("ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f")
+
+// builds a ring of mixin members, each having a key and a commitment
+// the member at index is ours, its commitment hides the same amount as C_offset
+func clsag_test_ring(mixin int, index int) (P, C_nonzero []crypto.Key, p, z, C_offset crypto.Key) {
+	const amount = 1000
+	for i := 0; i < mixin; i++ {
+		secret, mask := crypto.SkGen(), crypto.SkGen()
+		P = append(P, crypto.ScalarmultBase(secret))
+		C_nonzero = append(C_nonzero, Commitment_From_Amount(amount))
+		crypto.AddKeys(&C_nonzero[i], &C_nonzero[i], crypto.ScalarMultKey(&crypto.GBASE, &mask))
+		if i == index {
+			p = secret
+			pseudo_mask := crypto.SkGen()
+			C_offset = Commitment_From_Amount(amount)
+			crypto.AddKeys(&C_offset, &C_offset, crypto.ScalarMultKey(&crypto.GBASE, &pseudo_mask))
+			crypto.ScSub(&z, &mask, &pseudo_mask)
+		}
+	}
+	return
+}
+
+func Test_CLSAG(t *testing.T) {
+	message := crypto.SkGen()
+
+	for _, mixin := range []int{2, 5, 11} {
+		for index := 0; index < mixin; index += mixin - 1 {
+			P, C_nonzero, p, z, C_offset := clsag_test_ring(mixin, index)
+			sig := CLSAG_Gen(message, P, p, C_nonzero, z, C_offset, index)
+			if !CLSAG_Ver(message, P, C_nonzero, C_offset, &sig) {
+				t.Fatalf("CLSAG verification failed mixin %d index %d", mixin, index)
+			}
+
+			if sig.I != crypto.GenerateKeyImage(P[index], p) {
+				t.Fatalf("CLSAG key image mismatch mixin %d index %d", mixin, index)
+			}
+
+			if len(sig.Serialize()) != (mixin+2)*32 {
+				t.Fatalf("CLSAG serialized size %d mixin %d", len(sig.Serialize()), mixin)
+			}
+
+			other_message := crypto.SkGen()
+			if CLSAG_Ver(other_message, P, C_nonzero, C_offset, &sig) {
+				t.Fatalf("CLSAG verified with wrong message mixin %d index %d", mixin, index)
+			}
+
+			sig.s[0][0] ^= 1
+			if CLSAG_Ver(message, P, C_nonzero, C_offset, &sig) {
+				t.Fatalf("CLSAG verified with tampered s mixin %d index %d", mixin, index)
+			}
+			sig.s[0][0] ^= 1
+
+			original_s := sig.s[1]
+			var carry uint16
+			for i := range sig.s[1] { // s + l, same scalar but not reduced
+				carry += uint16(sig.s[1][i]) + uint16(L1[i])
+				sig.s[1][i] = byte(carry)
+				carry >>= 8
+			}
+			if CLSAG_Ver(message, P, C_nonzero, C_offset, &sig) {
+				t.Fatalf("CLSAG verified with non reduced s mixin %d index %d", mixin, index)
+			}
+			sig.s[1] = original_s
+
+			other_offset := Commitment_From_Amount(1001)
+			if CLSAG_Ver(message, P, C_nonzero, other_offset, &sig) {
+				t.Fatalf("CLSAG verified with wrong pseudo out mixin %d index %d", mixin, index)
+			}
+
+			I := sig.I
+			sig.I = crypto.ScalarmultBase(crypto.SkGen())
+			if CLSAG_Ver(message, P, C_nonzero, C_offset, &sig) {
+				t.Fatalf("CLSAG verified with wrong key image mixin %d index %d", mixin, index)
+			}
+			sig.I = I
+
+			D := sig.D
+			crypto.AddKeys(&sig.D, &sig.D, &clsag_test_torsion)
+			if CLSAG_Ver(message, P, C_nonzero, C_offset, &sig) {
+				t.Fatalf("CLSAG verified with D outside prime order subgroup mixin %d index %d", mixin, index)
+			}
+			sig.D = D
+
+			if !CLSAG_Ver(message, P, C_nonzero, C_offset, &sig) {
+				t.Fatalf("CLSAG verification failed after restore mixin %d index %d", mixin, index)
+			}
+		}
+	}
+}
+
+// fixed signature, so any change to the hashing or the serialization gets caught
+func Test_CLSAG_Vector(t *testing.T) {
+	message := crypto.HexToKey("09e85c54378be3f74818291873993ce1f122935d57a1fdb653290169fbaa9d0e")
+	P := []crypto.Key{
+		crypto.HexToKey("9d540fd5f56e9090889075a1cff2c8d90aa0993edc15684c5a1fcff05438fe23"),
+		crypto.HexToKey("60dd29ea909e8ba53548c676d79f493809227304342c72a12b239c0ec27c723b"),
+		crypto.HexToKey("2e57ccffe8f63ac3bfabdf885a09a45f14e70ddaf969c491df6c022f60e931c6"),
+		crypto.HexToKey("039b8bc2064995c14989c6972b7813f4970009f8301f26f8b5adb5c258c9056f"),
+	}
+	C_nonzero := []crypto.Key{
+		crypto.HexToKey("db64baa983b2dd23bc624af16d1dfcf2059bf4ff86e0f1d893d05c90add235d3"),
+		crypto.HexToKey("41a3840d8652af5db15c39d27e340e96fb3305db5ee358551378b0af1c120be8"),
+		crypto.HexToKey("fef16839dd223796249f33e827b14cd9fd79e66421ea360e7700c39277af9a4e"),
+		crypto.HexToKey("5839c0ccf47bfe4011a497db3f474a385714582355bbc1037fbfb4d5a70706e8"),
+	}
+	C_offset := crypto.HexToKey("df830889edd1dc5d934e76fa11e0f274d3495a614d9b7c80b5a0be680580eaff")
+	I := crypto.HexToKey("cf4c8061411ab6a9af70a1976fa9e23c5c8f715dca6fcac1c24772543f143ffd")
+	sig_hex := "8ba554229ec3e433e25009144869c036e0ff395a240ffe2b8a3151bf36e717046d6af64ac8f848e80c33bb170bfd2e8319911e17a12203d71c9db54ca41774056807f4cbfa45a3d869f3edd8b11ff63fb2279c968be617047113cfd80f0603091dd64c658fde52775b1c4e7ffe3ff138e51c183fa94ce3c2f9f9e7d691eca1037f3337dc4069b01e1049be81e88dc98b909266fd5be41c9f9e7b2a47283bfb0c83540b44292193a4322a992698a0ba07c3d037504a6b0c69bff4e8f9c8c6cf36"
+
+	sig_bytes, _ := hex.DecodeString(sig_hex)
+	sig, err := ParseClsagSig(bytes.NewReader(sig_bytes), len(P))
+	if err != nil {
+		t.Fatalf("CLSAG vector parsing failed err %s", err)
+	}
+	sig.I = I
+
+	if !bytes.Equal(sig.Serialize(), sig_bytes) {
+		t.Fatalf("CLSAG vector serialization mismatch")
+	}
+	if !CLSAG_Ver(message, P, C_nonzero, C_offset, &sig) {
+		t.Fatalf("CLSAG vector verification failed")
+	}
+
+	// ring members are bound to their positions
+	P[0], P[1] = P[1], P[0]
+	if CLSAG_Ver(message, P, C_nonzero, C_offset, &sig) {
+		t.Fatalf("CLSAG vector verified with reordered ring")
+	}
+	P[0], P[1] = P[1], P[0]
+
+	C_nonzero[3], C_nonzero[2] = C_nonzero[2], C_nonzero[3]
+	if CLSAG_Ver(message, P, C_nonzero, C_offset, &sig) {
+		t.Fatalf("CLSAG vector verified with reordered commitments")
+	}
+
+	if _, err := ParseClsagSig(bytes.NewReader(sig_bytes[:len(sig_bytes)-32]), len(P)); err == nil {
+		t.Fatalf("CLSAG parsed from truncated data")
+	}
+}
+
+func BenchmarkCLSAG_Ver(b *testing.B) {
+	message := crypto.SkGen()
+	P, C_nonzero, p, z, C_offset := clsag_test_ring(11, 3)
+	sig := CLSAG_Gen(message, P, p, C_nonzero, z, C_offset, 3)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !CLSAG_Ver(message, P, C_nonzero, C_offset, &sig) {
+			b.Fatalf("CLSAG verification failed")
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build ctaudit
+// +build ctaudit
+
+package ringct
+
+import "sort"
+import "math"
+import "time"
+import "testing"
+import "math/rand"
+import "runtime"
+import "runtime/debug"
+
+import "github.com/deroproject/derosuite/crypto"
+
+// constant time audit mode, it is not part of the normal tests since it takes a while
+// go test -tags ctaudit -run ConstantTime -v ./crypto/ringct/
+//
+// every secret dependent operation is timed with 2 classes of secrets, interleaved randomly
+// class 0 is a fixed secret with almost all bits zero, class 1 is a random secret
+// the timings are compared using Welch's t-test, as done by dudect https://eprint.iacr.org/2016/1123
+// if the timing depends on the secret, |t| grows with the number of samples
+
+// |t| above this is treated as leak, dudect considers anything above 4.5 as suspicious
+const ct_audit_threshold = 10.0
+
+// running mean and variance using Welford's method
+type ct_audit_stats struct {
+	n, mean, m2 float64
+}
+
+func (s *ct_audit_stats) push(x float64) {
+	s.n++
+	delta := x - s.mean
+	s.mean += delta / s.n
+	s.m2 += delta * (x - s.mean)
+}
+
+// Welch's t-test
+func ct_audit_t(a, b *ct_audit_stats) float64 {
+	va := a.m2 / (a.n - 1)
+	vb := b.m2 / (b.n - 1)
+	return (a.mean - b.mean) / math.Sqrt(va/a.n+vb/b.n)
+}
+
+// times op for samples count, returns t value
+// measurements above the 90th percentile are cropped, they are mostly interrupts and scheduling
+func ct_audit_measure(samples int, op func(secret *crypto.Key)) float64 {
+	fixed := crypto.Key{1} // scalar 1, so almost every window of the scalar is 0
+	class := make([]int, samples, samples)
+	timing := make([]float64, samples, samples)
+
+	old := debug.SetGCPercent(-1) // no collection while measuring
+	defer debug.SetGCPercent(old)
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	for i := 0; i < samples; i++ {
+		// both the classes do the same work before measurement
+		secrets := [2]crypto.Key{fixed, crypto.SkGen()}
+		class[i] = rand.Intn(2)
+		secret := secrets[class[i]]
+		start := time.Now()
+		op(&secret)
+		timing[i] = float64(time.Since(start))
+
+		if i%1024 == 1023 {
+			runtime.GC() // garbage is collected outside of the measurement
+		}
+	}
+
+	sorted := append([]float64{}, timing...)
+	sort.Float64s(sorted)
+	crop := sorted[samples*9/10]
+
+	var stats [2]ct_audit_stats
+	for i := range timing {
+		if timing[i] < crop {
+			stats[class[i]].push(timing[i])
+		}
+	}
+	return ct_audit_t(&stats[0], &stats[1])
+}
+
+func Test_ConstantTime(t *testing.T) {
+	pub := crypto.ScalarmultBase(crypto.SkGen())
+	message := crypto.SkGen()
+	pk, _ := mlsag_test_ring(2, 1) // smallest ring, so most of the time is spent on secrets
+	P, C_nonzero, _, _, C_offset := clsag_test_ring(2, 1)
+
+	tests := []struct {
+		name    string
+		samples int
+		leaky   bool // harness must detect the leak, otherwise it cannot be trusted
+		op      func(secret *crypto.Key)
+	}{
+		{"harness self check, leaky by design", 4000, true, func(secret *crypto.Key) {
+			for i := 0; i <= int(secret[31]&0x0f); i++ {
+				crypto.GenerateKeyImage(pub, *secret)
+			}
+		}},
+		{"GenerateKeyImage", 20000, false, func(secret *crypto.Key) {
+			crypto.GenerateKeyImage(pub, *secret)
+		}},
+		{"KeyDerivation", 20000, false, func(secret *crypto.Key) {
+			crypto.KeyDerivation(&pub, secret)
+		}},
+		{"ScalarmultBase", 20000, false, func(secret *crypto.Key) {
+			crypto.ScalarmultBase(*secret)
+		}},
+		{"ScMulSub", 200000, false, func(secret *crypto.Key) {
+			var result crypto.Key
+			crypto.ScMulSub(&result, &message, secret, &message)
+		}},
+		{"MLSAG_Gen", 10000, false, func(secret *crypto.Key) {
+			MLSAG_Gen(message, pk, []crypto.Key{*secret, *secret}, 1, 1)
+		}},
+		{"CLSAG_Gen", 10000, false, func(secret *crypto.Key) {
+			CLSAG_Gen(message, P, *secret, C_nonzero, *secret, C_offset, 1)
+		}},
+	}
+
+	for _, test := range tests {
+		tvalue := ct_audit_measure(test.samples, test.op)
+		t.Logf("%-40s samples %6d t %8.2f", test.name, test.samples, tvalue)
+
+		if leak := math.Abs(tvalue) > ct_audit_threshold; leak != test.leaky {
+			t.Errorf("%s timing depends on secret %v, expected %v, t %.2f", test.name, leak, test.leaky, tvalue)
+		}
+	}
+}
@@ -19,7 +19,7 @@ func Get_pre_mlsag_hash(sig *RctSig) crypto.Hash {
 	// now join the borromean signature and extract a sig
 	var other_data []byte
 
-	if sig.sigType == RCTTypeSimpleBulletproof || sig.sigType == RCTTypeFullBulletproof {
+	if sig.sigType == RCTTypeSimpleBulletproof || sig.sigType == RCTTypeFullBulletproof || sig.sigType == RCTTypeSimpleBulletproofAggregate || sig.sigType == RCTTypeCLSAG {
 		for i := range sig.BulletSigs {
 			//for j := range sig.BulletSigs[i].V{
 			//	other_data= append(other_data,sig.BulletSigs[i].V[j][:]...)
@@ -191,13 +191,18 @@ func MLSAG_Ver(message crypto.Key, pk [][]crypto.Key, rv *MlsagSig, dsRows int,
 	for i := 0; i < cols; i++ {
 		crypto.Sc_0(&c) // zero out c
 
-		var L, R, Hi crypto.Key
+		var L, R crypto.Key
 
 		// first loop
 		for j := 0; j < dsRows; j++ {
 			crypto.AddKeys2(&L, &rv.ss[i][j], &c_old, &pk[i][j])
-			Hi = pk[i][j].HashToPoint()
-			crypto.AddKeys3(&R, &rv.ss[i][j], &Hi, &c_old, &Ip[j])
+
+			// Hi is used as point directly, avoiding compression and decompression
+			var Hi_precomputed [8]crypto.CachedGroupElement
+			crypto.GePrecompute(&Hi_precomputed, pk[i][j].HashToEC())
+			var R_point crypto.ExtendedGroupElement
+			crypto.MultiScalarMultPrecomputed(&R_point, []crypto.Key{rv.ss[i][j], c_old}, []*[8]crypto.CachedGroupElement{&Hi_precomputed, &Ip[j]})
+			R_point.ToBytes(&R)
 
 			toHash[3*j+1] = pk[i][j]
 			toHash[3*j+2] = L
@@ -0,0 +1,82 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package ringct
+
+import "testing"
+
+import "github.com/deroproject/derosuite/crypto"
+
+// builds a ring of mixin keys, with 2 rows (key and commitment) as used by simple ringct
+// the secret keys are placed at index
+func mlsag_test_ring(mixin int, index int) (pk [][]crypto.Key, xx []crypto.Key) {
+	const rows = 2
+	pk = make([][]crypto.Key, mixin)
+	for i := range pk {
+		pk[i] = make([]crypto.Key, rows)
+		for j := range pk[i] {
+			secret := crypto.SkGen()
+			pk[i][j] = crypto.ScalarmultBase(secret)
+			if i == index {
+				xx = append(xx, secret)
+			}
+		}
+	}
+	return
+}
+
+func Test_MLSAG(t *testing.T) {
+	message := crypto.SkGen()
+
+	for _, mixin := range []int{2, 5, 11} {
+		for index := 0; index < mixin; index += mixin - 1 {
+			pk, xx := mlsag_test_ring(mixin, index)
+			sig := MLSAG_Gen(message, pk, xx, index, 1)
+			if !MLSAG_Ver(message, pk, &sig, 1, nil) {
+				t.Fatalf("MLSAG verification failed mixin %d index %d", mixin, index)
+			}
+
+			other_message := crypto.SkGen()
+			if MLSAG_Ver(other_message, pk, &sig, 1, nil) {
+				t.Fatalf("MLSAG verified with wrong message mixin %d index %d", mixin, index)
+			}
+
+			sig.ss[0][0][0] ^= 1
+			if MLSAG_Ver(message, pk, &sig, 1, nil) {
+				t.Fatalf("MLSAG verified with tampered ss mixin %d index %d", mixin, index)
+			}
+			sig.ss[0][0][0] ^= 1
+
+			sig.II[0] = crypto.ScalarmultBase(crypto.SkGen())
+			if MLSAG_Ver(message, pk, &sig, 1, nil) {
+				t.Fatalf("MLSAG verified with wrong key image mixin %d index %d", mixin, index)
+			}
+		}
+	}
+}
+
+func BenchmarkMLSAG_Ver(b *testing.B) {
+	message := crypto.SkGen()
+	pk, xx := mlsag_test_ring(11, 3)
+	sig := MLSAG_Gen(message, pk, xx, 3, 1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !MLSAG_Ver(message, pk, &sig, 1, nil) {
+			b.Fatalf("MLSAG verification failed")
+		}
+	}
+}
@@ -51,6 +51,8 @@ const (
 	RCTTypeSimple
 	RCTTypeFullBulletproof // we DO NOT parse/support/generate these
 	RCTTypeSimpleBulletproof
+	RCTTypeSimpleBulletproofAggregate // single bulletproof covers all outputs, allowed from hard fork 6
+	RCTTypeCLSAG                      // single bulletproof covers all outputs, CLSAG ring signatures, allowed from hard fork 7
 )
 
 // Pedersen Commitment is generated from this struct
@@ -80,8 +82,9 @@ type BoroSig struct {
 }
 
 // size of  single bullet proof range
-// we are currently only implementing non-aggregate version only
-// as aggregate have following benefits and disadvntages
+// RCTTypeSimpleBulletproof carries a non-aggregate proof per output
+// RCTTypeSimpleBulletproofAggregate carries a single proof for all outputs, see bulletproof_aggregate.go
+// aggregate have following benefits and disadvntages
 // 1) they are logarithmic in size but verification is linear, thus aggregate version may make it very easy to DOD
 // 2) they can only be used for 2^n outputs  not for randon n
 // 3) are very optimised and speedy to verify
@@ -116,6 +119,15 @@ type MlsagSig struct {
 	II []crypto.Key // this stores the keyimage, but is taken from the tx/blockchain,it is NOT serialized
 }
 
+// CLSAG (Concise Linkable Spontaneous Anonymous Group) Signature
+// serialised size (ring size + 2) * 32, while MLSAG takes (2 * ring size + 1) * 32
+type ClsagSig struct {
+	s  []crypto.Key // 1 response per ring member
+	c1 crypto.Key   // this stores the starting point
+	D  crypto.Key   // commitment key image
+	I  crypto.Key   // this stores the keyimage, but is taken from the tx/blockchain,it is NOT serialized
+}
+
 // Confidential Transaction Keys, mask is Pedersen Commitment
 // most of the time, it holds public keys, except (transaction making ) where it holds private keys
 type CtKey struct {
@@ -141,6 +153,10 @@ type RctSigPrunable struct {
 	rangeSigs  []RangeSig    //borrowmean range proof
 	BulletSigs []BulletProof // bulletproofs range proofs
 	MlsagSigs  []MlsagSig    // there can be as many mlsagsigs as many vins
+	ClsagSigs  []ClsagSig    // used instead of mlsagsigs by RCTTypeCLSAG, 1 per vin
+
+	pruned        bool        // signatures have been discarded, only their hash is available
+	prunable_hash crypto.Hash // hash of the discarded signatures, valid only if pruned
 }
 
 // Ring Confidential Signature struct that can verify everything
@@ -177,6 +193,15 @@ func (m *MlsagSig) Serialize() (result []byte) {
 	return
 }
 
+func (c *ClsagSig) Serialize() (result []byte) {
+	for i := range c.s {
+		result = append(result, c.s[i][:]...)
+	}
+	result = append(result, c.c1[:]...)
+	result = append(result, c.D[:]...)
+	return
+}
+
 func (r *RctSigBase) SerializeBase() (result []byte) {
 	result = []byte{r.sigType}
 	// Null type returns right away
@@ -184,7 +209,7 @@ func (r *RctSigBase) SerializeBase() (result []byte) {
 		return
 	}
 	result = append(result, Uint64ToBytes(r.txFee)...)
-	if r.sigType == RCTTypeSimple {
+	if r.sigType == RCTTypeSimple || r.sigType == RCTTypeSimpleBulletproofAggregate || r.sigType == RCTTypeCLSAG {
 		for _, input := range r.pseudoOuts {
 			result = append(result, input[:]...)
 		}
@@ -214,7 +239,7 @@ func (r *RctSigBase) BaseHash() (result crypto.Hash) {
 }
 
 func (r *RctSig) SerializePrunable() (result []byte) {
-	if r.sigType == RCTTypeNull {
+	if r.sigType == RCTTypeNull || r.pruned {
 		return
 	}
 	for _, rangeSig := range r.rangeSigs {
@@ -226,6 +251,9 @@ func (r *RctSig) SerializePrunable() (result []byte) {
 	for _, mlsagSig := range r.MlsagSigs {
 		result = append(result, mlsagSig.Serialize()...)
 	}
+	for _, clsagSig := range r.ClsagSigs {
+		result = append(result, clsagSig.Serialize()...)
+	}
 
 	if XMR_COMPATIBILITY == true {
 		// XMR pseudoouts are serialized differently  and considered prunable and at the end
@@ -253,15 +281,43 @@ func (r *RctSig) PrunableHash() (result crypto.Hash) {
 	if r.sigType == RCTTypeNull {
 		return
 	}
+	if r.pruned {
+		return r.prunable_hash
+	}
 	result = crypto.Keccak256(r.SerializePrunable())
 	return
 }
 
+// discard range proofs and mlsag signatures, keeping only their hash
+// base remains intact, so the tx hash and outputs are still available
+// a pruned signature can never be verified again
+func (r *RctSig) Prune() {
+	if r.sigType == RCTTypeNull || r.pruned {
+		return
+	}
+	r.prunable_hash = r.PrunableHash()
+	r.pruned = true
+	r.rangeSigs = nil
+	r.BulletSigs = nil
+	r.MlsagSigs = nil
+	r.ClsagSigs = nil
+}
+
+func (r *RctSig) IsPruned() bool {
+	return r.pruned
+}
+
 // this is the function which should be used by external world
 // if any exceptions occur while handling, we simply return false
 // transaction must be expanded before verification
 // coinbase transactions are always success, since they are tied to PoW of block
 func (r *RctSig) Verify() (result bool) {
+	return r.verify(nil)
+}
+
+// if proofs is not nil, bulletproofs of RCTTypeSimpleBulletproof are not verified but appended to it
+// so they can be batch verified along with proofs of other transactions
+func (r *RctSig) verify(proofs *[]*BulletProof) (result bool) {
 
 	result = false
 	defer func() { // safety so if anything wrong happens, verification fails
@@ -272,6 +328,10 @@ func (r *RctSig) Verify() (result bool) {
 		}
 	}()
 
+	if r.pruned { // nothing left to verify
+		return false
+	}
+
 	switch r.sigType {
 	case RCTTypeNull:
 		return false /// this is only possible for miner tx
@@ -281,8 +341,8 @@ func (r *RctSig) Verify() (result bool) {
 		return r.VerifyRctSimple()
 	case RCTTypeFullBulletproof:
 		return false // these TX are NOT supported
-	case RCTTypeSimpleBulletproof:
-		return r.VerifyRctSimpleBulletProof()
+	case RCTTypeSimpleBulletproof, RCTTypeSimpleBulletproofAggregate, RCTTypeCLSAG:
+		return r.verify_simple_bulletproof(proofs)
 
 	default:
 		return false
@@ -345,6 +405,11 @@ func (r *RctSig) VerifyRctSimple() bool {
 
 // Verify a RCTTypeSimple RingCT Signature
 func (r *RctSig) VerifyRctSimpleBulletProof() bool {
+	return r.verify_simple_bulletproof(nil)
+}
+
+// if proofs is not nil, bulletproofs of RCTTypeSimpleBulletproof are appended to it instead of being verified
+func (r *RctSig) verify_simple_bulletproof(proofs *[]*BulletProof) bool {
 	sumOutPks := identity()
 	for _, ctKey := range r.OutPk {
 		crypto.AddKeys(sumOutPks, sumOutPks, &ctKey.Mask)
@@ -360,17 +425,45 @@ func (r *RctSig) VerifyRctSimpleBulletProof() bool {
 		return false
 	}
 
-	if len(r.pseudoOuts) != len(r.MlsagSigs) { //if the signatures are partial reject
+	if r.sigType == RCTTypeCLSAG {
+		if len(r.pseudoOuts) != len(r.ClsagSigs) { //if the signatures are partial reject
+			return false
+		}
+	} else if len(r.pseudoOuts) != len(r.MlsagSigs) { //if the signatures are partial reject
 		return false
 	}
 
-	/* verify bulletproof in single threaded */
-	for i, _ := range r.OutPk {
-		r.BulletSigs[i].V = []crypto.Key{crypto.Key(r.OutPk[i].Mask)}
-		//if !r.BulletSigs[i].BULLETPROOF_Verify() {
-		if !r.BulletSigs[i].BULLETPROOF_Verify_ultrafast() {
+	if r.sigType == RCTTypeSimpleBulletproofAggregate || r.sigType == RCTTypeCLSAG { // single proof covers all the outputs
+		if len(r.BulletSigs) != 1 {
 			return false
 		}
+		r.BulletSigs[0].V = make([]crypto.Key, len(r.OutPk), len(r.OutPk))
+		for i := range r.OutPk {
+			r.BulletSigs[0].V[i] = crypto.Key(r.OutPk[i].Mask)
+		}
+		if !r.BulletSigs[0].BULLETPROOF_Verify_Aggregate() {
+			return false
+		}
+		if r.sigType == RCTTypeCLSAG {
+			return r.VerifyRCTCLSAG_Core()
+		}
+		return r.VerifyRCTSimple_Core()
+	}
+
+	if len(r.BulletSigs) != len(r.OutPk) {
+		return false
+	}
+
+	/* verify all bulletproofs of the tx as a single batch, or leave them to caller's batch */
+	tx_proofs := make([]*BulletProof, len(r.OutPk))
+	for i, _ := range r.OutPk {
+		r.BulletSigs[i].V = []crypto.Key{crypto.Key(r.OutPk[i].Mask)}
+		tx_proofs[i] = &r.BulletSigs[i]
+	}
+	if proofs != nil {
+		*proofs = append(*proofs, tx_proofs...)
+	} else if result, _ := BULLETPROOF_BatchVerify(tx_proofs); !result {
+		return false
 	}
 
 	/*
@@ -457,6 +550,15 @@ func ParseRangeSig(buf io.Reader) (result RangeSig, err error) {
 // parser for ringct signature
 // we need to be extra cautious as almost anything cam come as input
 func ParseRingCtSignature(buf io.Reader, nInputs, nOutputs, nMixin int) (result *RctSig, err error) {
+	return parse_ringct_signature(buf, nInputs, nOutputs, nMixin, false)
+}
+
+// parser for ringct signature, which was serialized as base followed by PrunableHash()
+func ParseRingCtSignaturePruned(buf io.Reader, nInputs, nOutputs int) (result *RctSig, err error) {
+	return parse_ringct_signature(buf, nInputs, nOutputs, 0, true)
+}
+
+func parse_ringct_signature(buf io.Reader, nInputs, nOutputs, nMixin int, pruned bool) (result *RctSig, err error) {
 	r := new(RctSig)
 	sigType := make([]byte, 1)
 	_, err = buf.Read(sigType)
@@ -479,6 +581,8 @@ func ParseRingCtSignature(buf io.Reader, nInputs, nOutputs, nMixin int) (result
 	case RCTTypeFull:
 	case RCTTypeSimple:
 	case RCTTypeSimpleBulletproof:
+	case RCTTypeSimpleBulletproofAggregate:
+	case RCTTypeCLSAG:
 
 	case RCTTypeFullBulletproof:
 		err = fmt.Errorf("Bad signature Type %d", r.sigType)
@@ -496,12 +600,12 @@ func ParseRingCtSignature(buf io.Reader, nInputs, nOutputs, nMixin int) (result
 	var nMg, nSS int
 
 	// pseudoouts for bulletproofs are serialised at the end
-	if r.sigType == RCTTypeSimple || r.sigType == RCTTypeSimpleBulletproof {
+	if r.sigType == RCTTypeSimple || r.sigType == RCTTypeSimpleBulletproof || r.sigType == RCTTypeSimpleBulletproofAggregate || r.sigType == RCTTypeCLSAG {
 		nMg = nInputs
 		nSS = 2
 		r.pseudoOuts = make([]crypto.Key, nInputs)
 
-		if r.sigType == RCTTypeSimple {
+		if r.sigType == RCTTypeSimple || r.sigType == RCTTypeSimpleBulletproofAggregate || r.sigType == RCTTypeCLSAG {
 			for i := 0; i < nInputs; i++ {
 				if r.pseudoOuts[i], err = crypto.ParseKey(buf); err != nil {
 					return
@@ -538,6 +642,21 @@ func ParseRingCtSignature(buf io.Reader, nInputs, nOutputs, nMixin int) (result
 		}
 	}
 
+	if pruned { // signatures have been replaced by their hash
+		if XMR_COMPATIBILITY == true && r.sigType == RCTTypeSimpleBulletproof {
+			err = fmt.Errorf("XMR bulletproof pseudoouts are prunable, cannot parse pruned signature")
+			return
+		}
+		var hash crypto.Key
+		if hash, err = crypto.ParseKey(buf); err != nil {
+			return
+		}
+		r.prunable_hash = crypto.Hash(hash)
+		r.pruned = true
+		result = r
+		return
+	}
+
 	switch r.sigType {
 	case RCTTypeFull, RCTTypeSimple:
 		r.rangeSigs = make([]RangeSig, nOutputs)
@@ -555,6 +674,23 @@ func ParseRingCtSignature(buf io.Reader, nInputs, nOutputs, nMixin int) (result
 			}
 
 		}
+
+	case RCTTypeSimpleBulletproofAggregate, RCTTypeCLSAG: // single proof for all outputs
+		r.BulletSigs = make([]BulletProof, 1)
+		if r.BulletSigs[0], err = ParseBulletProof(buf); err != nil {
+			return
+		}
+	}
+
+	if r.sigType == RCTTypeCLSAG { // 1 clsag per input, each having 1 response per ring member
+		r.ClsagSigs = make([]ClsagSig, nInputs)
+		for i := 0; i < nInputs; i++ {
+			if r.ClsagSigs[i], err = ParseClsagSig(buf, nMixin+1); err != nil {
+				return
+			}
+		}
+		result = r
+		return
 	}
 
 	r.MlsagSigs = make([]MlsagSig, nMg)
@@ -589,6 +725,23 @@ func ParseRingCtSignature(buf io.Reader, nInputs, nOutputs, nMixin int) (result
 	return
 }
 
+// clsag consists of 1 response per ring member, followed by c1 and D
+func ParseClsagSig(buf io.Reader, ring_size int) (c ClsagSig, err error) {
+	c.s = make([]crypto.Key, ring_size, ring_size)
+	for i := range c.s {
+		if c.s[i], err = crypto.ParseKey(buf); err != nil {
+			return
+		}
+	}
+	if c.c1, err = crypto.ParseKey(buf); err != nil {
+		return
+	}
+	if c.D, err = crypto.ParseKey(buf); err != nil {
+		return
+	}
+	return
+}
+
 func ParseBulletProof(buf io.Reader) (b BulletProof, err error) {
 	if b.A, err = crypto.ParseKey(buf); err != nil {
 		return
@@ -30,7 +30,7 @@ import "github.com/deroproject/derosuite/crypto"
 func (r *RctSig) VerifyRCTSimple_Core() (result bool) {
 
 	result = false
-	if !(r.sigType == RCTTypeSimple || r.sigType == RCTTypeSimpleBulletproof) {
+	if !(r.sigType == RCTTypeSimple || r.sigType == RCTTypeSimpleBulletproof || r.sigType == RCTTypeSimpleBulletproofAggregate) {
 		if DEBUGGING_MODE {
 			fmt.Printf("Signature NOT RingCT Simple or bulletproof  type, verification failed\n")
 		}
@@ -83,6 +83,45 @@ func (r *RctSig) VerifyRCTSimple_Core() (result bool) {
 	return
 }
 
+// same as VerifyRCTSimple_Core, except every input carries a CLSAG instead of an MLSAG
+// this is implementation of verRctCLSAGSimple from rctSigs.cpp file
+func (r *RctSig) VerifyRCTCLSAG_Core() (result bool) {
+
+	result = false
+	if r.sigType != RCTTypeCLSAG {
+		if DEBUGGING_MODE {
+			fmt.Printf("Signature NOT CLSAG type, verification failed\n")
+		}
+		return
+	}
+
+	pre_mlsag_hash := crypto.Key(Get_pre_mlsag_hash(r))
+
+	// loop through all the inputs
+	for inputi := 0; inputi < len(r.pseudoOuts); inputi++ {
+		cols := len(r.MixRing[inputi])
+
+		P := make([]crypto.Key, cols, cols)
+		C_nonzero := make([]crypto.Key, cols, cols)
+		for i := 0; i < cols; i++ {
+			P[i] = r.MixRing[inputi][i].Destination
+			C_nonzero[i] = r.MixRing[inputi][i].Mask
+		}
+
+		// do the clsag verification
+		result = CLSAG_Ver(pre_mlsag_hash, P, C_nonzero, r.pseudoOuts[inputi], &r.ClsagSigs[inputi])
+
+		if result == false { // verification of 1 one vin failed mark, entire TX as failed
+			if DEBUGGING_MODE {
+				fmt.Printf("RCT CLSAG signature verification failed for input %d\n", inputi)
+			}
+			return
+		}
+	}
+
+	return
+}
+
 // structure using which information is fed from wallet
 // these are only used while proving ringct simple
 type Input_info struct {
@@ -179,18 +218,53 @@ func (r *RctSig) Gen_RingCT_Simple(Message crypto.Hash, inputs []Input_info, out
 // fees is the fees to provide
 // this function is equivalent to genRctSimple in rctSigs.cpp
 func (r *RctSig) Gen_RingCT_Simple_BulletProof(Message crypto.Hash, inputs []Input_info, outputs []Output_info, fees uint64) {
+	r.gen_ringct_simple_bulletproof(Message, inputs, outputs, fees, RCTTypeSimpleBulletproof)
+}
+
+// same as Gen_RingCT_Simple_BulletProof, except a single aggregated bulletproof covers all the outputs
+// such signatures are valid only after hard fork 6
+func (r *RctSig) Gen_RingCT_Simple_BulletProof_Aggregate(Message crypto.Hash, inputs []Input_info, outputs []Output_info, fees uint64) {
+	r.gen_ringct_simple_bulletproof(Message, inputs, outputs, fees, RCTTypeSimpleBulletproofAggregate)
+}
+
+// same as Gen_RingCT_Simple_BulletProof_Aggregate, except inputs are signed using CLSAG
+// such signatures are valid only after hard fork 7
+func (r *RctSig) Gen_RingCT_CLSAG(Message crypto.Hash, inputs []Input_info, outputs []Output_info, fees uint64) {
+	r.gen_ringct_simple_bulletproof(Message, inputs, outputs, fees, RCTTypeCLSAG)
+}
+
+func (r *RctSig) gen_ringct_simple_bulletproof(Message crypto.Hash, inputs []Input_info, outputs []Output_info, fees uint64, sigType uint8) {
 
-	r.sigType = RCTTypeSimpleBulletproof
+	r.sigType = sigType
+	aggregate := sigType != RCTTypeSimpleBulletproof
 	r.Message = crypto.Key(Message)
 	r.txFee = fees
 
 	var sumouts, sumpouts crypto.Key
 
+	// all the masks are needed before the single proof can be generated
+	var aggregate_masks []crypto.Key
+	var aggregate_proof *BulletProof
+	if aggregate {
+		amounts := make([]uint64, len(outputs), len(outputs))
+		aggregate_masks = make([]crypto.Key, len(outputs), len(outputs))
+		for i := range outputs {
+			amounts[i] = outputs[i].Amount
+			aggregate_masks[i] = crypto.SkGen()
+		}
+		aggregate_proof = BULLETPROOF_Prove_Amounts(amounts, aggregate_masks)
+		r.BulletSigs = append(r.BulletSigs, *aggregate_proof)
+	}
+
 	for i := range outputs {
 		var public_mask, secret_mask crypto.Key
 		var public_maskc, secret_maskc crypto.Key
 
-		r.BulletSigs = append(r.BulletSigs, ProveRangeBulletproof(&public_maskc, &secret_maskc, outputs[i].Amount))
+		if aggregate {
+			public_maskc, secret_maskc = aggregate_proof.V[i], aggregate_masks[i]
+		} else {
+			r.BulletSigs = append(r.BulletSigs, ProveRangeBulletproof(&public_maskc, &secret_maskc, outputs[i].Amount))
+		}
 
 		public_mask = crypto.Key(public_maskc)
 		secret_mask = crypto.Key(secret_maskc)
@@ -229,9 +303,16 @@ func (r *RctSig) Gen_RingCT_Simple_BulletProof(Message crypto.Hash, inputs []Inp
 	message := crypto.Key(Get_pre_mlsag_hash(r))
 	for i := range inputs {
 
+		r.MixRing = append(r.MixRing, inputs[i].Pubs) // setup mixring for temoprary validation
+
+		if r.sigType == RCTTypeCLSAG {
+			r.ClsagSigs = append(r.ClsagSigs, proveRctCLSAGSimple(message, inputs[i].Pubs, inputs[i].Sk, a[i], r.pseudoOuts[i], inputs[i].Index))
+			r.ClsagSigs[i].I = crypto.Key(inputs[i].Key_image)
+			continue
+		}
+
 		r.MlsagSigs = append(r.MlsagSigs, proveRctMGSimple(message, inputs[i].Pubs, inputs[i].Sk, a[i], r.pseudoOuts[i], inputs[i].Index))
 
-		r.MixRing = append(r.MixRing, inputs[i].Pubs) // setup mixring for temoprary validation
 		r.MlsagSigs[i].II = make([]crypto.Key, 1, 1)
 		r.MlsagSigs[i].II[0] = crypto.Key(inputs[i].Key_image)
 
@@ -244,6 +325,26 @@ func (r *RctSig) Gen_RingCT_Simple_BulletProof(Message crypto.Hash, inputs []Inp
 
 }
 
+// Ring-ct CLSAG Simple
+// same inputs as proveRctMGSimple, the key and commitment rows are signed together using CLSAG
+func proveRctCLSAGSimple(message crypto.Key, pubs []CtKey, inSk CtKey, a crypto.Key, Cout crypto.Key, index int) ClsagSig {
+	if len(pubs) < 1 {
+		panic("Pubs are empty")
+	}
+
+	P := make([]crypto.Key, len(pubs), len(pubs))
+	C_nonzero := make([]crypto.Key, len(pubs), len(pubs))
+	for i := range pubs {
+		P[i] = pubs[i].Destination
+		C_nonzero[i] = pubs[i].Mask
+	}
+
+	var z crypto.Key
+	crypto.ScSub(&z, &inSk.Mask, &a)
+
+	return CLSAG_Gen(message, P, inSk.Destination, C_nonzero, z, Cout, index)
+}
+
 //Ring-ct MG sigs Simple
 //   Simple version for when we assume only
 //       post rct inputs
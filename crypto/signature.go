@@ -0,0 +1,49 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package crypto
+
+// schnorr signature, this is equivalent to generate_signature/check_signature from cryptonote
+// it signs a hash using a secret key, so anyone knowing the public key can verify it
+
+type Signature struct {
+	C Key `msgpack:"C"` // challenge
+	R Key `msgpack:"R"` // response
+}
+
+// sign a hash using secret key, pub must be the public key of secret
+func GenerateSignature(prefix_hash Hash, pub Key, secret Key) (sig Signature) {
+	k := RandomScalar()
+	comm := ScalarmultBase(*k)
+
+	sig.C = *HashToScalar(prefix_hash[:], pub[:], comm[:])
+	ScMulSub(&sig.R, &sig.C, &secret, k) // r = k - c*secret
+	return
+}
+
+// verify signature of a hash against a public key
+func CheckSignature(prefix_hash Hash, pub Key, sig Signature) bool {
+	if !pub.Public_Key_Valid() || !Sc_check(&sig.C) || !Sc_check(&sig.R) {
+		return false
+	}
+
+	var comm Key
+	AddKeys2(&comm, &sig.R, &sig.C, &pub) // r*G + c*pub == k*G
+
+	c := HashToScalar(prefix_hash[:], pub[:], comm[:])
+	ScSub(c, c, &sig.C)
+	return ScIsZero(c)
+}
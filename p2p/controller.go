@@ -106,10 +106,11 @@ func P2P_Init(params map[string]interface{}) error {
 	metrics.Registry.MustRegister(block_propagation)
 	metrics.Registry.MustRegister(transaction_propagation)
 
-	go P2P_Server_v2()        // start accepting connections
-	go P2P_engine()           // start outgoing engine
-	go syncroniser()          // start sync engine
-	go clean_up_propagation() // clean up propagation map
+	go P2P_Server_v2()         // start accepting connections
+	go P2P_engine()            // start outgoing engine
+	go syncroniser()           // start sync engine
+	go sync_retrieved_blocks() // add synced blocks to chain
+	go clean_up_propagation()  // clean up propagation map
 	logger.Infof("P2P started")
 	atomic.AddUint32(&globals.Subsystem_Active, 1) // increment subsystem
 	return nil
@@ -134,6 +134,7 @@ func (connection *Connection) Handle_Notification_Block(buf []byte) {
 	} else { // the block is NOT complete, we consider it as an ultra compact block
 
 		connection.logger.Debugf("Received an ultra compact block %s, total %d contains %d skipped %d transactions", blid,len(bl.Tx_hashes), len(request.CBlock.Txs), len(bl.Tx_hashes)-len(request.CBlock.Txs))
+		var txs []*transaction.Transaction
 		for j := range request.CBlock.Txs {
 			var tx transaction.Transaction
 			err = tx.DeserializeHeader(request.CBlock.Txs[j])
@@ -142,12 +143,13 @@ func (connection *Connection) Handle_Notification_Block(buf []byte) {
 				connection.Exit()
 				return
 			}
-			chain.Add_TX_To_Pool(&tx) // add tx to pool
+			txs = append(txs, &tx)
 		}
+		chain.Add_TXs_To_Pool(txs) // add txs to pool, they are verified together
 
 		// lets build a complete block ( tx from db or mempool )
 		for i := range bl.Tx_hashes {
-			if tx, err := chain.Load_TX_FROM_ID(nil, bl.Tx_hashes[i]); err == nil {
+			if tx, err := chain.Load_TX_FROM_ID(nil, bl.Tx_hashes[i]); err == nil && !tx.RctSignature.IsPruned() {
 				cbl.Txs = append(cbl.Txs, tx) // tx is from disk
 			} else {
 				tx := chain.Mempool.Mempool_Get_TX(bl.Tx_hashes[i]) // tx is from mempool
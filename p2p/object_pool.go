@@ -31,6 +31,7 @@ import "github.com/deroproject/derosuite/crypto"
 //import "github.com/deroproject/derosuite/globals"
 
 import "github.com/deroproject/derosuite/block"
+import "github.com/deroproject/derosuite/errormsg"
 
 // if block request pool is empty, we are syncronised otherwise we are syncronising
 var block_request_pool = map[crypto.Hash]uint64{} // these are received, we must attach a connection to blacklist peers
@@ -92,45 +93,49 @@ func retrieve_objects() {
 
 }
 
-// this goroutine will keep searching the queue for any blocks and see if they are can be attached somewhere, if yes they will be attached and cleaned up
+// blocks received from peers during sync pass through this pipeline in arrival order
+// their PoW and ringct signatures are preverified in parallel as soon as they are queued
+// while the state dependent checks are done one block at a time by Add_Complete_Block
+type sync_pipeline_entry struct {
+	cbl        *block.Complete_Block
+	connection *Connection // peer which sent the block, it is disconnected on invalid PoW
+	done       chan bool   // closed once preverification has finished
+}
+
+// number of blocks which can be preverified ahead of the one being added
+const SYNC_PIPELINE_DEPTH = 64
+
+var sync_pipeline = make(chan sync_pipeline_entry, SYNC_PIPELINE_DEPTH)
+
+// queues a received block, blocks if pipeline is full
+func queue_block_for_sync(connection *Connection, cbl *block.Complete_Block) {
+	entry := sync_pipeline_entry{cbl: cbl, connection: connection, done: chain.Preverify_Block(cbl)}
+	select {
+	case sync_pipeline <- entry:
+	case <-Exit_Event:
+	}
+}
+
+// this goroutine adds preverified blocks to chain, one at a time in the order they were received
 func sync_retrieved_blocks() {
-	// success := make(chan bool)
 	for {
-
+		var entry sync_pipeline_entry
 		select {
 		case <-Exit_Event:
 			return
-		//case <- success:
-		case <-time.After(1 * time.Second):
+		case entry = <-sync_pipeline:
 		}
 
-		block_request_pool_mutex.Lock()
+		select {
+		case <-Exit_Event:
+			return
+		case <-entry.done:
+		}
 
-		for blid, cbl := range block_received {
-			if chain.Block_Exists(nil, blid) { /// block is already in chain, discard it
-				delete(block_received, blid)
-				continue
-			}
-			_ = cbl
-			/*if cbl == nil {
-			    delete(block_received,blid)
-			     block_request_pool[blid]=0 // need to request object again
-			    continue
-			}*/
-
-			/*
-				// attach it to parent, else skip for now
-				if chain.Block_Exists(cbl.Bl.Prev_Hash) {
-					if chain.Add_Complete_Block(cbl) {
-						// if block successfully added , delete it now
-						delete(block_received, blid)
-
-						continue
-					} else { // checksum of something failed, request it randomly from another peer
-						block_request_pool[blid] = 0
-					}
-				}*/
+		err, ok := chain.Add_Complete_Block(entry.cbl)
+		if !ok && err == errormsg.ErrInvalidPoW {
+			entry.connection.logger.Warnf("This peer should be banned")
+			entry.connection.Exit()
 		}
-		block_request_pool_mutex.Unlock()
 	}
 }
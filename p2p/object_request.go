@@ -104,6 +104,10 @@ func (connection *Connection) Handle_ObjectRequest(buf []byte) {
 					//rlog.Tracef(1, "ERR Cannot load tx from DB\n")
 					return
 				}
+				if tx.RctSignature.IsPruned() { // pruned tx cannot be verified by peer
+					rlog.Tracef(1, "Cannot serve block %s, tx %s has been pruned %s", request.Block_list[i], bl.Tx_hashes[j], connection.logid)
+					return
+				}
 				cbl.Txs = append(cbl.Txs, tx.Serialize()) // append all the txs
 			}
 		}
@@ -31,7 +31,6 @@ import "github.com/vmihailenco/msgpack"
 //import "github.com/deroproject/derosuite/globals"
 
 import "github.com/deroproject/derosuite/block"
-import "github.com/deroproject/derosuite/errormsg"
 import "github.com/deroproject/derosuite/transaction"
 
 // peer has responded with  some objects, we must respond
@@ -102,12 +101,8 @@ func (connection *Connection) Handle_ObjectResponse(buf []byte) {
 			cbl.Txs = append(cbl.Txs, &tx)
 		}
 
-		// check if we can add ourselves to chain
-		err, ok := chain.Add_Complete_Block(&cbl)
-		if !ok && err == errormsg.ErrInvalidPoW {
-			connection.logger.Warnf("This peer should be banned")
-			connection.Exit()
-		}
+		// preverify the block in parallel and add it to chain once its turn comes, see object_pool.go
+		queue_block_for_sync(connection, &cbl)
 
 		// add the object to object pool from where it will be consume
 		// queue_block_received(bl.GetHash(),&cbl)
@@ -21,6 +21,7 @@ package storage
 import "os"
 import "fmt"
 import "sync"
+import "bytes"
 import "runtime"
 import "path/filepath"
 import "encoding/binary"
@@ -176,6 +177,136 @@ func (b *BadgerTXWrapper) LoadObject(universe_name []byte, galaxy_name []byte, s
 	return data, nil
 }
 
+// badger has a flat key space, so buckets are emulated using key prefixes
+// NOTE: since bucket names are simply concatenated, a prefix scan will also match keys of any other solar bucket
+// whose name starts with the requested solar bucket name, callers should use fixed size bucket names
+type BadgerCursor struct {
+	iterator  *badger.Iterator
+	prefix    []byte
+	start_key []byte // full keys including prefix
+	stop_key  []byte
+	reverse   bool
+	started   bool
+	key       []byte
+	value     []byte
+}
+
+// range scan over a solar bucket, the range is [start_key, stop_key)
+func (b *BadgerTXWrapper) Iterate(universe_name []byte, galaxy_name []byte, solar_name []byte, start_key []byte, stop_key []byte, reverse bool) (Cursor, error) {
+	prefix := make([]byte, 0, len(universe_name)+len(galaxy_name)+len(solar_name))
+	prefix = append(prefix, universe_name...)
+	prefix = append(prefix, galaxy_name...)
+	prefix = append(prefix, solar_name...)
+
+	c := &BadgerCursor{prefix: prefix, reverse: reverse}
+	if len(start_key) != 0 {
+		c.start_key = append(Duplicate(prefix), start_key...)
+	}
+	if len(stop_key) != 0 {
+		c.stop_key = append(Duplicate(prefix), stop_key...)
+	}
+
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = reverse
+	c.iterator = b.tx.NewIterator(opts)
+
+	return c, nil
+}
+
+// positions the cursor on the next key within range
+func (c *BadgerCursor) Next() bool {
+	if c.started {
+		c.iterator.Next()
+	} else {
+		c.started = true
+		switch {
+		case !c.reverse && len(c.start_key) != 0:
+			c.iterator.Seek(c.start_key)
+		case !c.reverse:
+			c.iterator.Seek(c.prefix)
+		case len(c.stop_key) != 0: // reverse seek lands on the largest key <= seek key
+			c.iterator.Seek(c.stop_key)
+		default:
+			c.iterator.Seek(prefix_successor(c.prefix))
+		}
+
+		// stop key is excluded, reverse seek may have landed on it
+		if c.reverse && c.iterator.Valid() {
+			k := c.iterator.Item().Key()
+			if (len(c.stop_key) != 0 && bytes.Equal(k, c.stop_key)) || (len(c.stop_key) == 0 && !bytes.HasPrefix(k, c.prefix)) {
+				c.iterator.Next()
+			}
+		}
+	}
+
+	if !c.iterator.ValidForPrefix(c.prefix) {
+		c.key, c.value = nil, nil
+		return false
+	}
+
+	item := c.iterator.Item()
+	k := item.Key()
+	if (!c.reverse && len(c.stop_key) != 0 && bytes.Compare(k, c.stop_key) >= 0) ||
+		(c.reverse && len(c.start_key) != 0 && bytes.Compare(k, c.start_key) < 0) {
+		c.key, c.value = nil, nil
+		return false
+	}
+
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		logger.Warnf("Error while reading value during iteration, err %s", err)
+		c.key, c.value = nil, nil
+		return false
+	}
+
+	c.key = Duplicate(k[len(c.prefix):])
+	c.value = value
+	return true
+}
+
+func (c *BadgerCursor) Key() []byte {
+	return c.key
+}
+
+func (c *BadgerCursor) Value() []byte {
+	return c.value
+}
+
+// badger iterators must always be closed
+func (c *BadgerCursor) Close() {
+	if c.iterator != nil {
+		c.iterator.Close()
+		c.iterator = nil
+	}
+}
+
+// load all key values for specific bucket
+func (b *BadgerTXWrapper) LoadObjects(universe_name []byte, galaxy_name []byte, solar_name []byte) (keys [][]byte, values [][]byte, err error) {
+	cursor, err := b.Iterate(universe_name, galaxy_name, solar_name, nil, nil, false)
+	if err != nil {
+		return
+	}
+	defer cursor.Close()
+
+	for cursor.Next() {
+		keys = append(keys, cursor.Key())
+		values = append(values, cursor.Value())
+	}
+	return
+}
+
+// smallest key which is larger than all keys having the prefix
+func prefix_successor(prefix []byte) []byte {
+	successor := Duplicate(prefix)
+	for i := len(successor) - 1; i >= 0; i-- {
+		if successor[i] != 0xff {
+			successor[i]++
+			return successor[:i+1]
+		}
+	}
+	return nil // prefix is all 0xff, no successor exists
+}
+
 // this function stores a uint64
 // this will automcatically use the transaction
 func (b *BadgerTXWrapper) StoreUint64(universe_bucket []byte, galaxy_bucket []byte, solar_bucket []byte, key []byte, data uint64) error {
@@ -21,6 +21,7 @@ package storage
 import "os"
 import "fmt"
 import "sync"
+import "bytes"
 import "strconv" // has intsize which give whether int is 64 bits or 32 bits
 import "runtime"
 import "path/filepath"
@@ -350,6 +351,99 @@ func (b *BoltTXWrapper) LoadObjects(universe_name []byte, galaxy_name []byte, so
 
 }
 
+// boltdb cursor wrapper, which keeps the keys within the requested range
+type BoltCursor struct {
+	bdb       *BoltStore
+	cursor    *bolt.Cursor
+	start_key []byte
+	stop_key  []byte
+	reverse   bool
+	started   bool
+	key       []byte
+	value     []byte
+}
+
+// range scan over a solar bucket, the range is [start_key, stop_key)
+func (b *BoltStore) Iterate(tx *bolt.Tx, universe_name []byte, bucket_name []byte, solar_bucket []byte, start_key []byte, stop_key []byte, reverse bool) (Cursor, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	// a missing bucket is same as an empty bucket, badger cannot distinguish the two
+	var solar *bolt.Bucket
+	if universe := tx.Bucket(universe_name); universe != nil {
+		if bucket := universe.Bucket(bucket_name); bucket != nil {
+			solar = bucket.Bucket(solar_bucket)
+		}
+	}
+	if solar == nil {
+		return &BoltCursor{bdb: b}, nil
+	}
+
+	return &BoltCursor{bdb: b, cursor: solar.Cursor(), start_key: Duplicate(start_key), stop_key: Duplicate(stop_key), reverse: reverse}, nil
+}
+
+func (b *BoltTXWrapper) Iterate(universe_name []byte, galaxy_name []byte, solar_name []byte, start_key []byte, stop_key []byte, reverse bool) (Cursor, error) {
+	return b.bdb.Iterate(b.tx, universe_name, galaxy_name, solar_name, start_key, stop_key, reverse)
+}
+
+// positions the cursor on the next key within range
+func (c *BoltCursor) Next() bool {
+	if c.cursor == nil { // bucket does not exist
+		return false
+	}
+
+	c.bdb.Lock()
+	defer c.bdb.Unlock()
+
+	var k, v []byte
+	switch {
+	case c.started && c.reverse:
+		k, v = c.cursor.Prev()
+	case c.started:
+		k, v = c.cursor.Next()
+	case c.reverse: // position on the last key below stop key
+		if len(c.stop_key) == 0 {
+			k, v = c.cursor.Last()
+		} else if k, v = c.cursor.Seek(c.stop_key); k == nil {
+			k, v = c.cursor.Last()
+		} else {
+			k, v = c.cursor.Prev() // seek lands on a key >= stop key, which is excluded
+		}
+	default:
+		if len(c.start_key) == 0 {
+			k, v = c.cursor.First()
+		} else {
+			k, v = c.cursor.Seek(c.start_key)
+		}
+	}
+	c.started = true
+
+	// nil key means end of bucket, also check whether we crossed the range
+	if k == nil || (!c.reverse && len(c.stop_key) != 0 && bytes.Compare(k, c.stop_key) >= 0) ||
+		(c.reverse && len(c.start_key) != 0 && bytes.Compare(k, c.start_key) < 0) {
+		c.key, c.value = nil, nil
+		return false
+	}
+
+	// data returned by bolt is only valid during the tx, so make copies
+	c.key = Duplicate(k)
+	c.value = Duplicate(v)
+	return true
+}
+
+func (c *BoltCursor) Key() []byte {
+	return c.key
+}
+
+func (c *BoltCursor) Value() []byte {
+	return c.value
+}
+
+// bolt cursors do not hold any resources, they are released with the tx
+func (c *BoltCursor) Close() {
+	c.key, c.value = nil, nil
+}
+
 // this function stores a uint64
 // this will automcatically use the lock
 func (b *BoltTXWrapper) StoreUint64(universe_bucket []byte, galaxy_bucket []byte, solar_bucket []byte, key []byte, data uint64) error {
@@ -0,0 +1,295 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+
+package storage
+
+import "sync"
+import "time"
+import "bytes"
+import "testing"
+
+// every backend must behave exactly the same for the blockchain
+// to validate a new backend, just add it to test_store_backends
+// note: badger concatenates bucket names, so buckets are only distinct if the concatenated names differ
+// eg "UG"/"S" and "U"/"GS" are the same bucket, and a scan of bucket "S" also returns keys of bucket "ST"
+// this is why bucket names used in the suite are all of same length, as are the names used by the chain
+
+var conformance_tests = []struct {
+	name string
+	fn   func(*testing.T, Store)
+}{
+	{"commit_visibility", conformance_commit_visibility},
+	{"rollback", conformance_rollback},
+	{"readonly_rejects_writes", conformance_readonly_rejects_writes},
+	{"concurrent_readers", conformance_concurrent_readers},
+	{"uint64_roundtrip", conformance_uint64_roundtrip},
+	{"bucket_isolation", conformance_bucket_isolation},
+	{"iterate_missing_bucket", conformance_iterate_missing_bucket},
+}
+
+// run full suite against each backend, every test gets a fresh store
+func Test_Conformance(t *testing.T) {
+	for name, backend := range test_store_backends {
+		for _, test := range conformance_tests {
+			backend, test := backend, test
+			t.Run(name+"/"+test.name, func(t *testing.T) {
+				defer setup_test_datadir(t)()
+
+				store := backend()
+				if err := store.Init(nil); err != nil {
+					t.Fatalf("Cannot init store err %s", err)
+				}
+				defer store.Shutdown()
+
+				test.fn(t, store)
+			})
+		}
+	}
+}
+
+// missing objects are either reported as error or returned empty, blockchain handles both
+func absent(data []byte, err error) bool {
+	return err != nil || len(data) == 0
+}
+
+func begin_tx(t *testing.T, store Store, writable bool) DBTX {
+	dbtx, err := store.BeginTX(writable)
+	if err != nil {
+		t.Fatalf("Cannot begin tx writable %t err %s", writable, err)
+	}
+	return dbtx
+}
+
+func store_object(t *testing.T, dbtx DBTX, key, value []byte) {
+	if err := dbtx.StoreObject([]byte("U"), []byte("G"), []byte("S"), key, value); err != nil {
+		t.Fatalf("StoreObject failed err %s", err)
+	}
+}
+
+func load_object(dbtx DBTX, key []byte) ([]byte, error) {
+	return dbtx.LoadObject([]byte("U"), []byte("G"), []byte("S"), key)
+}
+
+// a snapshot must only see committed data, and never data committed after it started
+func conformance_commit_visibility(t *testing.T, store Store) {
+	writer := begin_tx(t, store, true)
+	store_object(t, writer, []byte("key"), []byte("value"))
+
+	if data, err := load_object(writer, []byte("key")); err != nil || !bytes.Equal(data, []byte("value")) {
+		t.Fatalf("tx cannot read its own write data %x err %v", data, err)
+	}
+
+	reader := begin_tx(t, store, false)
+	if data, err := load_object(reader, []byte("key")); !absent(data, err) {
+		t.Fatalf("uncommitted data visible to reader %x", data)
+	}
+
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("commit failed err %s", err)
+	}
+
+	if data, err := load_object(reader, []byte("key")); !absent(data, err) {
+		t.Fatalf("data committed after reader started is visible %x", data)
+	}
+	reader.Rollback()
+
+	reader = begin_tx(t, store, false)
+	defer reader.Rollback()
+	if data, err := load_object(reader, []byte("key")); err != nil || !bytes.Equal(data, []byte("value")) {
+		t.Fatalf("committed data not visible data %x err %v", data, err)
+	}
+}
+
+// rolled back data must disappear, previously committed data must survive
+func conformance_rollback(t *testing.T, store Store) {
+	writer := begin_tx(t, store, true)
+	store_object(t, writer, []byte("old"), []byte("committed"))
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("commit failed err %s", err)
+	}
+
+	writer = begin_tx(t, store, true)
+	store_object(t, writer, []byte("old"), []byte("overwritten"))
+	store_object(t, writer, []byte("new"), []byte("discarded"))
+	writer.Rollback()
+
+	reader := begin_tx(t, store, false)
+	defer reader.Rollback()
+	if data, err := load_object(reader, []byte("new")); !absent(data, err) {
+		t.Fatalf("rolled back data visible %x", data)
+	}
+	if data, err := load_object(reader, []byte("old")); err != nil || !bytes.Equal(data, []byte("committed")) {
+		t.Fatalf("rollback modified committed data %x err %v", data, err)
+	}
+}
+
+func conformance_readonly_rejects_writes(t *testing.T, store Store) {
+	reader := begin_tx(t, store, false)
+	if err := reader.StoreObject([]byte("U"), []byte("G"), []byte("S"), []byte("key"), []byte("value")); err == nil {
+		t.Fatalf("readonly tx accepted StoreObject")
+	}
+	if err := reader.StoreUint64([]byte("U"), []byte("G"), []byte("S"), []byte("int"), 7); err == nil {
+		t.Fatalf("readonly tx accepted StoreUint64")
+	}
+	reader.Rollback()
+
+	reader = begin_tx(t, store, false)
+	defer reader.Rollback()
+	if data, err := load_object(reader, []byte("key")); !absent(data, err) {
+		t.Fatalf("write from readonly tx visible %x", data)
+	}
+}
+
+// readers must neither block on an open writer nor see its pending data
+func conformance_concurrent_readers(t *testing.T, store Store) {
+	writer := begin_tx(t, store, true)
+	store_object(t, writer, []byte("key"), []byte("v1"))
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("commit failed err %s", err)
+	}
+
+	writer = begin_tx(t, store, true)
+	store_object(t, writer, []byte("key"), []byte("v2"))
+
+	const readers = 8
+	var wg sync.WaitGroup
+	errors := make(chan string, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dbtx, err := store.BeginTX(false)
+			if err != nil {
+				errors <- err.Error()
+				return
+			}
+			defer dbtx.Rollback()
+			for j := 0; j < 100; j++ {
+				if data, err := load_object(dbtx, []byte("key")); err != nil || !bytes.Equal(data, []byte("v1")) {
+					errors <- "reader saw invalid data " + string(data)
+					return
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("readers blocked by open writer")
+	}
+	close(errors)
+	for e := range errors {
+		t.Errorf("%s", e)
+	}
+
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("commit failed err %s", err)
+	}
+	reader := begin_tx(t, store, false)
+	defer reader.Rollback()
+	if data, err := load_object(reader, []byte("key")); err != nil || !bytes.Equal(data, []byte("v2")) {
+		t.Fatalf("committed data not visible data %x err %v", data, err)
+	}
+}
+
+// uint64 are stored big endian, so raw objects are portable between backends
+func conformance_uint64_roundtrip(t *testing.T, store Store) {
+	values := []uint64{0, 1, 0xff, 0x100, 1 << 32, 0xffffffffffffffff}
+
+	writer := begin_tx(t, store, true)
+	for i, v := range values {
+		if err := writer.StoreUint64([]byte("U"), []byte("G"), []byte("S"), []byte{byte(i)}, v); err != nil {
+			t.Fatalf("StoreUint64 failed err %s", err)
+		}
+	}
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("commit failed err %s", err)
+	}
+
+	reader := begin_tx(t, store, false)
+	defer reader.Rollback()
+	for i, v := range values {
+		value, err := reader.LoadUint64([]byte("U"), []byte("G"), []byte("S"), []byte{byte(i)})
+		if err != nil || value != v {
+			t.Errorf("uint64 roundtrip failed expected %d actual %d err %v", v, value, err)
+		}
+		if data, err := load_object(reader, []byte{byte(i)}); err != nil || !bytes.Equal(data, itob(v)) {
+			t.Errorf("uint64 not stored big endian %x err %v", data, err)
+		}
+	}
+
+	if _, err := reader.LoadUint64([]byte("U"), []byte("G"), []byte("S"), []byte("missing")); err == nil {
+		t.Errorf("LoadUint64 of missing key must fail")
+	}
+}
+
+// same key in different buckets are different objects
+// only buckets with same length names are covered, see the note above
+func conformance_bucket_isolation(t *testing.T, store Store) {
+	buckets := [][3][]byte{
+		{[]byte("U"), []byte("G"), []byte("S")},
+		{[]byte("U"), []byte("G"), []byte("T")},
+		{[]byte("U"), []byte("H"), []byte("S")},
+		{[]byte("V"), []byte("G"), []byte("S")},
+	}
+
+	writer := begin_tx(t, store, true)
+	for i, b := range buckets {
+		if err := writer.StoreObject(b[0], b[1], b[2], []byte("key"), []byte{byte(i)}); err != nil {
+			t.Fatalf("StoreObject failed err %s", err)
+		}
+	}
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("commit failed err %s", err)
+	}
+
+	reader := begin_tx(t, store, false)
+	defer reader.Rollback()
+	for i, b := range buckets {
+		if data, err := reader.LoadObject(b[0], b[1], b[2], []byte("key")); err != nil || !bytes.Equal(data, []byte{byte(i)}) {
+			t.Errorf("bucket %s/%s/%s returned %x err %v", b[0], b[1], b[2], data, err)
+		}
+
+		// a scan must not leak into other buckets
+		keys, values, err := reader.LoadObjects(b[0], b[1], b[2])
+		if err != nil || len(keys) != 1 || !bytes.Equal(keys[0], []byte("key")) || !bytes.Equal(values[0], []byte{byte(i)}) {
+			t.Errorf("bucket %s/%s/%s scan returned %d keys err %v", b[0], b[1], b[2], len(keys), err)
+		}
+	}
+}
+
+// iterating a bucket which was never created yields an empty cursor and not an error
+// so callers can treat any error as a real failure
+func conformance_iterate_missing_bucket(t *testing.T, store Store) {
+	writer := begin_tx(t, store, true)
+	store_object(t, writer, []byte("key"), []byte("value"))
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("commit failed err %s", err)
+	}
+
+	missing := [][3][]byte{
+		{[]byte("X"), []byte("G"), []byte("S")}, // missing universe
+		{[]byte("U"), []byte("X"), []byte("S")}, // missing galaxy
+		{[]byte("U"), []byte("G"), []byte("X")}, // missing solar
+	}
+
+	reader := begin_tx(t, store, false)
+	defer reader.Rollback()
+	for _, b := range missing {
+		for _, reverse := range []bool{false, true} {
+			cursor, err := reader.Iterate(b[0], b[1], b[2], nil, nil, reverse)
+			if err != nil {
+				t.Fatalf("Iterate on missing bucket %s/%s/%s failed err %s", b[0], b[1], b[2], err)
+			}
+			if cursor.Next() {
+				t.Errorf("missing bucket %s/%s/%s returned key %x", b[0], b[1], b[2], cursor.Key())
+			}
+			cursor.Close()
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+
+package storage
+
+import "os"
+import "bytes"
+import "testing"
+import "io/ioutil"
+
+import log "github.com/sirupsen/logrus"
+
+import "github.com/deroproject/derosuite/globals"
+
+// all backends are opened in a fresh temporary data directory
+func setup_test_datadir(t *testing.T) (cleanup func()) {
+	dir, err := ioutil.TempDir("", "derod_storage_test")
+	if err != nil {
+		t.Fatalf("Cannot create temp dir err %s", err)
+	}
+	globals.Logger = log.New()
+	globals.Arguments = map[string]interface{}{"--data-dir": dir}
+	if err = os.MkdirAll(globals.GetDataDirectory(), 0750); err != nil {
+		t.Fatalf("Cannot create data dir err %s", err)
+	}
+	return func() { os.RemoveAll(dir) }
+}
+
+// every backend we have, new backends must be added here
+var test_store_backends = map[string]func() Store{
+	"boltdb":   func() Store { return &BoltStore{} },
+	"badgerdb": func() Store { return &BadgerDBStore{} },
+	"memdb":    func() Store { return &MemStore{} },
+}
+
+// returns every backend we have, initialised and ready to use
+func open_test_stores(t *testing.T) map[string]Store {
+	stores := map[string]Store{}
+	for name, backend := range test_store_backends {
+		stores[name] = backend()
+		if err := stores[name].Init(nil); err != nil {
+			t.Fatalf("Cannot init %s err %s", name, err)
+		}
+	}
+	return stores
+}
+
+func collect_keys(t *testing.T, dbtx DBTX, start, stop []byte, reverse bool) (keys []byte) {
+	cursor, err := dbtx.Iterate([]byte("U"), []byte("G"), []byte("S"), start, stop, reverse)
+	if err != nil {
+		t.Fatalf("Iterate failed err %s", err)
+	}
+	defer cursor.Close()
+	for cursor.Next() {
+		if len(cursor.Key()) != 1 || !bytes.Equal(cursor.Value(), []byte{cursor.Key()[0] + 100}) {
+			t.Fatalf("Cursor returned invalid key %x value %x", cursor.Key(), cursor.Value())
+		}
+		keys = append(keys, cursor.Key()[0])
+	}
+	return
+}
+
+// test range scans in both directions on all backends
+func Test_Cursor(t *testing.T) {
+	defer setup_test_datadir(t)()
+
+	for name, store := range open_test_stores(t) {
+		dbtx, err := store.BeginTX(true)
+		if err != nil {
+			t.Fatalf("%s cannot begin tx err %s", name, err)
+		}
+		for _, k := range []byte{5, 1, 3, 9, 7} {
+			dbtx.StoreObject([]byte("U"), []byte("G"), []byte("S"), []byte{k}, []byte{k + 100})
+		}
+		// a neighbouring bucket must not be visible while scanning
+		dbtx.StoreObject([]byte("U"), []byte("G"), []byte("T"), []byte{4}, []byte{104})
+
+		test_cases := []struct {
+			start, stop []byte
+			reverse     bool
+			expected    []byte
+		}{
+			{nil, nil, false, []byte{1, 3, 5, 7, 9}},
+			{nil, nil, true, []byte{9, 7, 5, 3, 1}},
+			{[]byte{3}, []byte{9}, false, []byte{3, 5, 7}},
+			{[]byte{3}, []byte{9}, true, []byte{7, 5, 3}},
+			{[]byte{4}, nil, false, []byte{5, 7, 9}},
+			{nil, []byte{4}, true, []byte{3, 1}},
+			{[]byte{10}, nil, false, nil},
+			{nil, []byte{1}, true, nil},
+		}
+
+		// pending writes must be visible within the tx and persisted after commit
+		for pass := 0; pass < 2; pass++ {
+			for i, tc := range test_cases {
+				if keys := collect_keys(t, dbtx, tc.start, tc.stop, tc.reverse); !bytes.Equal(keys, tc.expected) {
+					t.Errorf("%s pass %d case %d expected %v actual %v", name, pass, i, tc.expected, keys)
+				}
+			}
+
+			if pass == 0 {
+				if err = dbtx.Commit(); err != nil {
+					t.Fatalf("%s commit failed err %s", name, err)
+				}
+				if dbtx, err = store.BeginTX(false); err != nil {
+					t.Fatalf("%s cannot begin tx err %s", name, err)
+				}
+			}
+		}
+
+		keys, values, err := dbtx.LoadObjects([]byte("U"), []byte("G"), []byte("S"))
+		if err != nil || len(keys) != 5 || len(values) != 5 || keys[0][0] != 1 || values[4][0] != 109 {
+			t.Errorf("%s LoadObjects failed err %v keys %v", name, err, keys)
+		}
+
+		dbtx.Rollback()
+		store.Shutdown()
+	}
+}
+
+func Test_Prefix_Successor(t *testing.T) {
+	if !bytes.Equal(prefix_successor([]byte("UGS")), []byte("UGT")) {
+		t.Errorf("prefix successor failed")
+	}
+	if !bytes.Equal(prefix_successor([]byte{1, 0xff, 0xff}), []byte{2}) {
+		t.Errorf("prefix successor failed with trailing 0xff")
+	}
+	if prefix_successor([]byte{0xff}) != nil {
+		t.Errorf("prefix successor of 0xff must be nil")
+	}
+}
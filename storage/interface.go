@@ -32,7 +32,10 @@ type DBTX interface {
 	StoreObject(universe_bucket []byte, galaxy_bucket []byte, solar_bucket []byte, key []byte, data []byte) error // store object to a specific universe
 	LoadObject(universe_bucket []byte, galaxy_bucket []byte, solar_bucket []byte, key []byte) ([]byte, error)     // load object from a specific universe
 
-	//  LoadObjects(universe_bucket []byte, galaxy_bucket []byte, solar_bucket []byte) ([][]byte, [][]byte, error)     // load all key values for specific bucket
+	LoadObjects(universe_bucket []byte, galaxy_bucket []byte, solar_bucket []byte) ([][]byte, [][]byte, error) // load all key values for specific bucket
+
+	// range scan over a solar bucket in sorted key order, see Cursor for details
+	Iterate(universe_bucket []byte, galaxy_bucket []byte, solar_bucket []byte, start_key []byte, stop_key []byte, reverse bool) (Cursor, error)
 
 	StoreUint64(universe_bucket []byte, galaxy_bucket []byte, solar_bucket []byte, key []byte, data uint64) error // store
 	LoadUint64(universe_bucket []byte, galaxy_bucket []byte, solar_bucket []byte, key []byte) (uint64, error)     // load object
@@ -40,6 +43,18 @@ type DBTX interface {
 
 }
 
+// cursor is used to walk a solar bucket key by key in sorted ( byte-wise) order
+// the range covered is always [start_key, stop_key), nil start_key means from the first key
+// and nil stop_key means till the last key, reverse only changes the direction of the walk
+// a cursor is only valid till the DBTX which created it is committed or rolled back
+// iterating a bucket which does not exist is not an error, it yields an empty cursor
+type Cursor interface {
+	Next() bool    // move to next key, must be called before first access, returns false when range is exhausted
+	Key() []byte   // key at current position, without the bucket names
+	Value() []byte // value at current position
+	Close()        // release any resources held by cursor
+}
+
 type Store interface {
 	Init(param map[string]interface{}) error // init the backend and connect to it
 	Shutdown() error                         // shutdown the backend
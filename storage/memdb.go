@@ -0,0 +1,366 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// this DB lives completely in RAM, it is used by tests and simulator
+// everything is lost when the store is shutdown
+
+package storage
+
+import "fmt"
+import "sort"
+import "sync"
+import "bytes"
+import "encoding/binary"
+
+import log "github.com/sirupsen/logrus"
+
+import "github.com/deroproject/derosuite/globals"
+
+// every key keeps the versions which may still be visible to some reader
+// a version is the commit counter at the time the value was written
+type mem_value struct {
+	version uint64
+	data    []byte
+}
+
+type MemStore struct {
+	buckets      map[string]map[string][]mem_value // bucket name -> key -> versions ( oldest first)
+	version      uint64                            // last committed version
+	readers      map[uint64]int                    // snapshot versions in use by readonly txs
+	writer       sync.Mutex                        // only a single writable tx is allowed at a time, same as boltdb
+	sync.RWMutex                                   // lock this struct
+}
+
+// this object is returned
+type MemTXWrapper struct {
+	mdb      *MemStore
+	writable bool
+	version  uint64                       // snapshot version seen by this tx
+	pending  map[string]map[string][]byte // writes which will become visible on commit
+	done     bool                         // tx has been committed or rolled back
+	sync.Mutex
+}
+
+var ErrMemTXNotWritable = fmt.Errorf("tx not writable")
+var ErrMemTXClosed = fmt.Errorf("tx closed")
+
+func (m *MemStore) Init(params map[string]interface{}) (err error) {
+	logger = globals.Logger.WithFields(log.Fields{"com": "STORE"})
+	logger.Infof("Initializing in memory store, data will be lost on exit")
+
+	m.Lock()
+	m.buckets = map[string]map[string][]mem_value{}
+	m.readers = map[uint64]int{}
+	m.version = 0
+	m.Unlock()
+	return nil
+}
+
+func (m *MemStore) Shutdown() (err error) {
+	logger.Infof("Shutting in memory store")
+	m.Lock()
+	m.buckets = map[string]map[string][]mem_value{}
+	m.Unlock()
+	return nil
+}
+
+// get a new writable/readable tx,
+// a writable tx blocks till any other writable tx is finished
+func (m *MemStore) BeginTX(writable bool) (DBTX, error) {
+	txwrapper := &MemTXWrapper{mdb: m, writable: writable}
+
+	if writable {
+		m.writer.Lock()
+		txwrapper.pending = map[string]map[string][]byte{}
+	}
+
+	m.Lock()
+	if m.buckets == nil {
+		m.Unlock()
+		if writable {
+			m.writer.Unlock()
+		}
+		return nil, fmt.Errorf("Error while creating new tx, in memory store is not initialized")
+	}
+	txwrapper.version = m.version
+	if !writable {
+		m.readers[txwrapper.version]++
+	}
+	m.Unlock()
+
+	return txwrapper, nil
+}
+
+// bucket names are length prefixed, so no 2 different buckets can ever collide
+func mem_bucket_name(universe_name []byte, galaxy_name []byte, solar_name []byte) string {
+	name := make([]byte, 0, len(universe_name)+len(galaxy_name)+len(solar_name)+3*binary.MaxVarintLen64)
+	var buf [binary.MaxVarintLen64]byte
+	for _, part := range [][]byte{universe_name, galaxy_name, solar_name} {
+		name = append(name, buf[:binary.PutUvarint(buf[:], uint64(len(part)))]...)
+		name = append(name, part...)
+	}
+	return string(name)
+}
+
+// release the tx, this assumes tx lock is taken
+func (b *MemTXWrapper) close() {
+	if b.done {
+		return
+	}
+	b.done = true
+	b.pending = nil
+
+	if b.writable {
+		b.mdb.writer.Unlock()
+		return
+	}
+
+	b.mdb.Lock()
+	b.mdb.readers[b.version]--
+	if b.mdb.readers[b.version] <= 0 {
+		delete(b.mdb.readers, b.version)
+	}
+	b.mdb.Unlock()
+}
+
+// make all pending writes visible atomically
+func (b *MemTXWrapper) Commit() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.done {
+		return ErrMemTXClosed
+	}
+	if !b.writable { // nothing to commit
+		b.close()
+		return nil
+	}
+
+	m := b.mdb
+	m.Lock()
+	m.version++
+
+	// versions older than the oldest active reader can never be seen again
+	oldest := m.version
+	for v := range m.readers {
+		if v < oldest {
+			oldest = v
+		}
+	}
+
+	for bucket_name, pending_bucket := range b.pending {
+		bucket, ok := m.buckets[bucket_name]
+		if !ok {
+			bucket = map[string][]mem_value{}
+			m.buckets[bucket_name] = bucket
+		}
+		for k, data := range pending_bucket {
+			versions := append(bucket[k], mem_value{version: m.version, data: data})
+			for len(versions) > 1 && versions[1].version <= oldest {
+				versions = versions[1:]
+			}
+			bucket[k] = versions
+		}
+	}
+	m.Unlock()
+
+	b.close()
+	return nil
+}
+
+// discard all pending writes
+func (b *MemTXWrapper) Rollback() {
+	b.Lock()
+	b.close()
+	b.Unlock()
+}
+
+// in memory store cannot be synced anywhere
+func (b *MemTXWrapper) Sync() {
+
+}
+
+func (b *MemTXWrapper) StoreObject(universe_name []byte, galaxy_name []byte, solar_name []byte, key []byte, data []byte) (err error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.done {
+		return ErrMemTXClosed
+	}
+	if !b.writable {
+		return ErrMemTXNotWritable
+	}
+
+	bucket_name := mem_bucket_name(universe_name, galaxy_name, solar_name)
+	pending_bucket, ok := b.pending[bucket_name]
+	if !ok {
+		pending_bucket = map[string][]byte{}
+		b.pending[bucket_name] = pending_bucket
+	}
+	pending_bucket[string(key)] = Duplicate(data)
+	return nil
+}
+
+// finds the value visible to this tx, this assumes tx lock is taken
+func (b *MemTXWrapper) load(bucket_name string, key string) (data []byte, ok bool) {
+	if data, ok = b.pending[bucket_name][key]; ok {
+		return
+	}
+
+	b.mdb.RLock()
+	defer b.mdb.RUnlock()
+	versions := b.mdb.buckets[bucket_name][key]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].version <= b.version {
+			return versions[i].data, true
+		}
+	}
+	return nil, false
+}
+
+func (b *MemTXWrapper) LoadObject(universe_name []byte, galaxy_name []byte, solar_name []byte, key []byte) (data []byte, err error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.done {
+		return data, ErrMemTXClosed
+	}
+
+	value, ok := b.load(mem_bucket_name(universe_name, galaxy_name, solar_name), string(key))
+	if !ok {
+		return data, fmt.Errorf("No Such Key %x", key)
+	}
+	return Duplicate(value), nil
+}
+
+// range scan over a solar bucket, the range is [start_key, stop_key)
+// the keys are collected and sorted upfront, so the cursor is not affected by later writes
+func (b *MemTXWrapper) Iterate(universe_name []byte, galaxy_name []byte, solar_name []byte, start_key []byte, stop_key []byte, reverse bool) (Cursor, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.done {
+		return nil, ErrMemTXClosed
+	}
+
+	bucket_name := mem_bucket_name(universe_name, galaxy_name, solar_name)
+
+	b.mdb.RLock()
+	var keys []string
+	committed := b.mdb.buckets[bucket_name]
+	for k := range committed {
+		keys = append(keys, k)
+	}
+	b.mdb.RUnlock()
+
+	pending := b.pending[bucket_name] // missing bucket gives an empty cursor, same as other backends
+	for k := range pending {
+		if _, exists := committed[k]; !exists {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	cursor := &MemCursor{reverse: reverse, position: -1}
+	for _, k := range keys {
+		if (len(start_key) != 0 && bytes.Compare([]byte(k), start_key) < 0) || (len(stop_key) != 0 && bytes.Compare([]byte(k), stop_key) >= 0) {
+			continue
+		}
+		if value, ok := b.load(bucket_name, k); ok {
+			cursor.keys = append(cursor.keys, []byte(k))
+			cursor.values = append(cursor.values, Duplicate(value))
+		}
+	}
+	return cursor, nil
+}
+
+// load all key values for specific bucket
+func (b *MemTXWrapper) LoadObjects(universe_name []byte, galaxy_name []byte, solar_name []byte) (keys [][]byte, values [][]byte, err error) {
+	cursor, err := b.Iterate(universe_name, galaxy_name, solar_name, nil, nil, false)
+	if err != nil {
+		return
+	}
+	defer cursor.Close()
+
+	for cursor.Next() {
+		keys = append(keys, cursor.Key())
+		values = append(values, cursor.Value())
+	}
+	return
+}
+
+// this function stores a uint64
+// this will automcatically use the transaction
+func (b *MemTXWrapper) StoreUint64(universe_bucket []byte, galaxy_bucket []byte, solar_bucket []byte, key []byte, data uint64) error {
+	return b.StoreObject(universe_bucket, galaxy_bucket, solar_bucket, key, itob(data))
+}
+
+// this function loads the data as 64 byte integer
+func (b *MemTXWrapper) LoadUint64(universe_bucket []byte, galaxy_bucket []byte, solar_bucket []byte, key []byte) (uint64, error) {
+	object_data, err := b.LoadObject(universe_bucket, galaxy_bucket, solar_bucket, key)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(object_data) == 0 {
+		return 0, fmt.Errorf("No value stored here, we should look more")
+	}
+
+	if len(object_data) != 8 {
+		panic("Database corruption, invalid data ")
+	}
+
+	value := binary.BigEndian.Uint64(object_data)
+	return value, nil
+}
+
+// cursor over a sorted copy of the keys
+type MemCursor struct {
+	keys     [][]byte
+	values   [][]byte
+	reverse  bool
+	position int
+}
+
+func (c *MemCursor) Next() bool {
+	c.position++
+	return c.position < len(c.keys)
+}
+
+func (c *MemCursor) index() int {
+	if c.reverse {
+		return len(c.keys) - 1 - c.position
+	}
+	return c.position
+}
+
+func (c *MemCursor) Key() []byte {
+	if c.position < 0 || c.position >= len(c.keys) {
+		return nil
+	}
+	return c.keys[c.index()]
+}
+
+func (c *MemCursor) Value() []byte {
+	if c.position < 0 || c.position >= len(c.keys) {
+		return nil
+	}
+	return c.values[c.index()]
+}
+
+func (c *MemCursor) Close() {
+	c.keys, c.values = nil, nil
+}
@@ -165,6 +165,7 @@ type (
 		Reward         uint64                     `json:"reward"`  // miner tx rewards are decided by the protocol during execution
 		Ignored        bool                       `json:"ignored"` // tell whether this tx is okau as per client protocol or bein ignored
 		In_pool        bool                       `json:"in_pool"`
+		Pruned         bool                       `json:"pruned"` // as_hex contains prunable hash instead of signatures
 		Output_Indices []uint64                   `json:"output_indices"`
 		Tx_hash        string                     `json:"tx_hash"`
 		ValidBlock     string                     `json:"valid_block"`   // TX is valid in this block
@@ -241,12 +242,119 @@ type (
 		Top_block_hash             string  `json:"top_block_hash"`
 		Tx_count                   uint64  `json:"tx_count"`
 		Tx_pool_size               uint64  `json:"tx_pool_size"`
-		Dynamic_fee_per_kb         uint64  `json:"dynamic_fee_per_kb"` // our addition
-		Total_Supply               uint64  `json:"total_supply"`       // our addition
-		Median_Block_Size          uint64  `json:"median_block_size"`  // our addition
+		Tx_pool_bytes              uint64  `json:"tx_pool_bytes"`        // our addition
+		Tx_pool_max_bytes          uint64  `json:"tx_pool_max_bytes"`    // our addition
+		Tx_pool_max_size           uint64  `json:"tx_pool_max_size"`     // our addition
+		Tx_pool_evicted            uint64  `json:"tx_pool_evicted"`      // our addition
+		Min_relay_fee_per_kb       uint64  `json:"min_relay_fee_per_kb"` // our addition
+		Dynamic_fee_per_kb         uint64  `json:"dynamic_fee_per_kb"`   // our addition
+		Total_Supply               uint64  `json:"total_supply"`         // our addition
+		Median_Block_Size          uint64  `json:"median_block_size"`    // our addition
 		White_peerlist_size        uint64  `json:"white_peerlist_size"`
 		Version                    string  `json:"version"`
 
 		Status string `json:"status"`
 	}
 )
+
+// events pushed over websocket at /ws
+// a subscriber may send Event_Subscribe at any time to change the events it receives
+// subscribers over the limit receive a single error event and are disconnected
+type (
+	Event_Subscribe struct {
+		Events []string `json:"events"` // new_block, topo_change, mempool_add, mempool_delete, empty means all
+	}
+	Event struct {
+		Type               string              `json:"type"`
+		Block              string              `json:"block,omitempty"`              // new_block
+		Height             int64               `json:"height,omitempty"`             // chain height after the event, for chain events
+		TopoHeight         int64               `json:"topoheight,omitempty"`         // chain topoheight after the event, for chain events
+		Changed_TopoHeight int64               `json:"changed_topoheight,omitempty"` // topo_change, blocks from this topoheight have changed
+		Seq                uint64              `json:"seq,omitempty"`                // topo_change, numbered so as gettopochanges can resume
+		Blocks             []Topo_Change_Block `json:"blocks,omitempty"`             // topo_change, blocks whose topoheight changed
+		TXs                []Topo_Change_TX    `json:"txs,omitempty"`                // topo_change, txs whose validity flipped
+		TXID               string              `json:"txid,omitempty"`               // mempool events
+		Fee                uint64              `json:"fee,omitempty"`
+		Size               uint64              `json:"size,omitempty"`
+		Status             string              `json:"status,omitempty"` // error, sent before the subscriber is disconnected
+	}
+)
+
+type (
+	Topo_Change_Block struct {
+		BLID           string `json:"blid"`
+		Old_TopoHeight int64  `json:"old_topoheight"`
+		New_TopoHeight int64  `json:"new_topoheight"` // -1 if block is no longer part of topo order
+	}
+	Topo_Change_TX struct {
+		TXID  string `json:"txid"`
+		BLID  string `json:"blid"`  // validity of a tx is with respect to the block including it
+		Valid bool   `json:"valid"` // validity after the change
+	}
+)
+
+// gettopochanges, returns recent topo_change events with seq greater than after
+type (
+	GetTopoChanges_Params struct {
+		After uint64 `json:"after"`
+	}
+	GetTopoChanges_Result struct {
+		Changes []Event `json:"changes"`
+		Status  string  `json:"status"`
+	}
+)
+
+// indexer_register, registers keys of a view only wallet with the indexer and rescans from start_topoheight
+type (
+	Indexer_Register_Params struct {
+		Spend_Public_Key string `json:"spend_public_key"` // hex
+		View_Secret_Key  string `json:"view_secret_key"`  // hex
+		Start_TopoHeight int64  `json:"start_topoheight"`
+	}
+	Indexer_Register_Result struct {
+		Address string `json:"address"` // use this address to fetch transfers
+		Status  string `json:"status"`
+	}
+)
+
+// indexer_get_transfers, returns incoming transfers of a registered account with seq greater than after
+// view secret key used while registering is required, as transfers reveal amounts and payment ids
+type (
+	Indexer_Get_Transfers_Params struct {
+		Address         string `json:"address"`
+		View_Secret_Key string `json:"view_secret_key"` // hex
+		After           uint64 `json:"after"`
+		Limit           int    `json:"limit"` // 0 means maximum allowed by daemon
+	}
+	Indexer_Transfer struct {
+		Seq             uint64 `json:"seq"`
+		TXID            string `json:"txid"`
+		BLID            string `json:"blid"`
+		Height          uint64 `json:"height"`
+		TopoHeight      int64  `json:"topoheight"`
+		Block_Time      uint64 `json:"block_time"`
+		Index_within_tx uint64 `json:"index_within_tx"`
+		Index_Global    uint64 `json:"index_global"`
+		Amount          uint64 `json:"amount"`
+		Unlock_Height   uint64 `json:"unlock_height"`
+		PaymentID       string `json:"payment_id,omitempty"` // hex, decrypted if it was encrypted
+		Coinbase        bool   `json:"coinbase,omitempty"`
+	}
+	Indexer_Get_Transfers_Result struct {
+		Transfers []Indexer_Transfer `json:"transfers"`
+		Status    string             `json:"status"`
+	}
+)
+
+// estimatefee, returns fee per KB required to get mined within specified blocks
+type (
+	EstimateFee_Params struct {
+		Blocks uint64 `json:"blocks"` // confirmation target, 0 means 1 block
+	}
+	EstimateFee_Result struct {
+		Fee_per_kb uint64 `json:"fee_per_kb"`
+		Blocks     uint64 `json:"blocks"`
+		Estimated  bool   `json:"estimated"` // false if there is not enough data, minimum fee is returned
+		Status     string `json:"status"`
+	}
+)
@@ -0,0 +1,84 @@
+// Copyright 2017-2018 DERO Project. All rights reserved.
+// Use of this source code in any form is governed by RESEARCH license.
+// license can be found in the LICENSE file.
+// GPG: 0F39 E425 8C65 3947 702A  8234 08B2 0360 A03A 9DE8
+//
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND ANY
+// EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL
+// THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+// PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT,
+// STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF
+// THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package structures
+
+// this file contains structures of the stratum mining protocol, spoken by derod and dero-miner
+// protocol is newline delimited json-rpc over tcp, requests are login, submit and keepalived
+// server pushes job notifications as the chain moves
+
+import "encoding/json"
+
+const (
+	STRATUM_LOGIN      = "login"
+	STRATUM_SUBMIT     = "submit"
+	STRATUM_KEEPALIVED = "keepalived"
+	STRATUM_JOB        = "job" // notification from server, carries a Stratum_Job
+)
+
+// error message of a share submitted for a job which was replaced by a new block
+const STRATUM_STALE_JOB = "Stale job"
+
+// requests, responses and notifications all use this message
+// notifications do not carry an id, responses carry either result or error
+type Stratum_Message struct {
+	ID      json.RawMessage `json:"id,omitempty"`
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Stratum_Error  `json:"error,omitempty"`
+}
+
+type Stratum_Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// blob is the blockhashing blob, miner fills the nonce (bytes 39-42) and first 16 bytes of extra nonce (bytes 43-58)
+// remaining extra nonce is unique to the connection and must not be modified
+type Stratum_Job struct {
+	Job_ID             string `json:"job_id"`
+	Blob               string `json:"blob"`
+	Difficulty         uint64 `json:"difficulty"` // share difficulty
+	Network_Difficulty uint64 `json:"network_difficulty"`
+	Height             uint64 `json:"height"`
+}
+
+type (
+	Stratum_Login_Params struct {
+		Login string `json:"login"` // wallet address, which is rewarded when a block is found
+		Pass  string `json:"pass"`
+		Agent string `json:"agent"`
+	}
+	Stratum_Login_Result struct {
+		ID     string      `json:"id"` // connection id, must be sent back with submit
+		Job    Stratum_Job `json:"job"`
+		Status string      `json:"status"`
+	}
+)
+
+type Stratum_Submit_Params struct {
+	ID          string `json:"id"`
+	Job_ID      string `json:"job_id"`
+	Nonce       string `json:"nonce"`       // 4 bytes in hex, as placed in blob
+	Extra_Nonce string `json:"extra_nonce"` // 16 bytes in hex, as placed in blob
+}
+
+// result of submit and keepalived
+type Stratum_Status_Result struct {
+	Status string `json:"status"`
+}
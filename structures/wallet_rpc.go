@@ -90,6 +90,9 @@ type Outgoing_Transfer_Details struct {
 	Amount           []uint64 `json:"amount,omitempty"`    // amount sent
 	Daddress   []string `json:"to,omitempty"`// taken from address
 	TXsecretkey string `json:"tx_secret_key,omitempty"` //secret key for transaction
+	Inputs      []uint64 `json:"inputs,omitempty"`      // wallet fund indexes spent, used to replace tx with higher fees
+	Unlock_Time uint64   `json:"unlock_time,omitempty"`
+	Change      uint64   `json:"change,omitempty"` // if non zero, last output is change
 }
 
 
@@ -210,6 +210,16 @@ func (tx *Transaction) IsCoinbase() (result bool) {
 }
 
 func (tx *Transaction) DeserializeHeader(buf []byte) (err error) {
+	return tx.deserialize(buf, false)
+}
+
+// deserialize a transaction serialized using SerializePruned
+// signatures are not available, so such a tx cannot be verified or relayed
+func (tx *Transaction) DeserializePruned(buf []byte) (err error) {
+	return tx.deserialize(buf, true)
+}
+
+func (tx *Transaction) deserialize(buf []byte, pruned bool) (err error) {
 
 	Key_offset_count := uint64(0) // used to calculate expected signatures in v1
 
@@ -253,11 +263,18 @@ func (tx *Transaction) DeserializeHeader(buf []byte) (err error) {
 
 	for i := uint64(0); i < vin_length; i++ {
 
+		if len(buf) < 1 {
+			return fmt.Errorf("Invalid Vin type in Transaction\n")
+		}
 		vin_type := buf[0]
 
 		buf = buf[1:] // consume 1 more byte
 
-		rlog.Tracef(10, "Processing i %d  vin_type %s  hex %x\n", i, TX_IN_NAME[vin_type], buf[:40])
+		trace_length := len(buf)
+		if trace_length > 40 {
+			trace_length = 40
+		}
+		rlog.Tracef(10, "Processing i %d  vin_type %s  hex %x\n", i, TX_IN_NAME[vin_type], buf[:trace_length])
 
 		switch vin_type {
 		case TXIN_GEN:
@@ -449,7 +466,11 @@ func (tx *Transaction) DeserializeHeader(buf []byte) (err error) {
 
 		Mixin -= 1 // one is ours, rest are mixin
 
-		tx.RctSignature, err = ringct.ParseRingCtSignature(bufreader, len(tx.Vin), len(tx.Vout), Mixin)
+		if pruned {
+			tx.RctSignature, err = ringct.ParseRingCtSignaturePruned(bufreader, len(tx.Vin), len(tx.Vout))
+		} else {
+			tx.RctSignature, err = ringct.ParseRingCtSignature(bufreader, len(tx.Vin), len(tx.Vout), Mixin)
+		}
 		if err != nil {
 			return err
 		}
@@ -697,6 +718,17 @@ func (tx *Transaction) Serialize() []byte {
 
 }
 
+// serialize transaction, replacing prunable signatures with their hash
+// tx hash remains same, use DeserializePruned to load it back
+func (tx *Transaction) SerializePruned() []byte {
+	buf := append(tx.SerializeHeader(), tx.RctSignature.SerializeBase()...)
+	if tx.RctSignature.Get_Sig_Type() != ringct.RCTTypeNull {
+		prunable_hash := tx.RctSignature.PrunableHash()
+		buf = append(buf, prunable_hash[:]...)
+	}
+	return buf
+}
+
 /*
 
 func (tx *Transaction) IsCoinbase() (result bool){
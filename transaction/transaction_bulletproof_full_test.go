@@ -27,7 +27,7 @@ import "github.com/deroproject/derosuite/crypto/ringct"
 // TODO we need to figure out a way to control the variable with exporting it
 var bulletproof_compatibility bool
 
-//go:linkname setcompatibility crypto.setcompatibility
+// go:linkname setcompatibility crypto.setcompatibility
 //func setcompatibility(value bool)
 
 // test transaction from XMR testnet 0a65ce0098f48085fe2dce36773d696f98b334d1ca2a2510617843bd40600a8d
@@ -163,6 +163,33 @@ func (w *Wallet) IsDaemonOnline() (err error) {
 	return nil
 }
 
+// get fee per KB required to get mined within specified blocks, as estimated by daemon from its mempool
+func (w *Wallet) Get_Fee_Estimate(blocks uint64) (fee_per_kb uint64, err error) {
+	if rpcClient == nil || !Connected {
+		err = fmt.Errorf("Daemon is not connected")
+		return
+	}
+
+	response, err := rpcClient.CallNamed("estimatefee", map[string]interface{}{"blocks": blocks})
+	if err != nil {
+		return
+	}
+	if response.Error != nil {
+		err = fmt.Errorf("%s", response.Error)
+		return
+	}
+
+	var result structures.EstimateFee_Result
+	if err = response.GetObject(&result); err != nil {
+		return
+	}
+	if result.Status != "OK" {
+		err = fmt.Errorf("%s", result.Status)
+		return
+	}
+	return result.Fee_per_kb, nil
+}
+
 // do the entire sync
 // lagging behind is the NOT the major problem
 // the problem is the frequent soft-forks
@@ -26,6 +26,7 @@ import "encoding/hex"
 import "encoding/binary"
 import "runtime/pprof"
 
+import "github.com/deroproject/derosuite/config"
 import "github.com/deroproject/derosuite/globals"
 import "github.com/deroproject/derosuite/crypto"
 import "github.com/deroproject/derosuite/crypto/ringct"
@@ -175,6 +176,193 @@ func Test_Creation_TX(t *testing.T) {
 
 }
 
+// once the daemon crosses hard fork 6, a single aggregated bulletproof covers all outputs
+// and after hard fork 7, inputs are signed using CLSAG
+func Test_Creation_TX_Hard_Forks(t *testing.T) {
+
+	temp_db := filepath.Join(os.TempDir(), "dero_temporary_test_wallet.db")
+
+	os.Remove(temp_db)
+
+	w, err := Create_Encrypted_Wallet(temp_db, "QWER", *crypto.RandomScalar())
+	if err != nil {
+		t.Fatalf("Cannot create encrypted wallet, err %s", err)
+	}
+
+	defer os.Remove(temp_db) // cleanup after test
+
+	txw := TX_Wallet_Data{WAmount: 4000000000000}
+	txw.TXdata.Index_Global = 739
+	txw.WKey.Destination = crypto.HexToKey("dbdfd2a3e9da6911b0a3e37e8e448f2de2477f81760585c2f197736bac127e0f")
+	txw.WKey.Mask = crypto.HexToKey("01e4e85ab0b5e30dd86b5356f0f6b4177738b9e6b32041c4e4781a2f26083101")
+	txw.WKimage = crypto.HexToKey("d8fb3b4260aea6582400a5f48244ff3f7c4dc36420698e3decc5d28ba04733c2")
+	txw.TXdata.InKey.Destination = crypto.HexToKey("ed0da9e74d240088a07909ea354b8d140b753642e25495e0931b4623b25ff523")
+	txw.TXdata.InKey.Mask = crypto.HexToKey("dbddab6c6b3063074e7cfd1a7f83f184ad78e92c8ff25118c0ed4edc77015948")
+
+	// hard forks 6 and 7 are scheduled only on testnet
+	defer func(previous config.CHAIN_CONFIG) { globals.Config = previous }(globals.Config)
+	globals.Config = config.Testnet
+
+	for _, output_count := range []int{1, 2, 3, config.MAX_VOUT - 1} {
+		var receivers []*Account
+		var outs []ringct.Output_info
+		for i := 0; i < output_count; i++ {
+			r, _ := Generate_Keys_From_Random()
+			receivers = append(receivers, r)
+			out := ringct.Output_info{Public_Spend_Key: r.GetAddress().SpendKey, Public_View_Key: r.GetAddress().ViewKey}
+			if i == 0 { // balance the outputs
+				out.Amount = txw.WAmount
+			}
+			outs = append(outs, out)
+		}
+
+		ins := []ringct.Input_info{{Amount: txw.WAmount, Key_image: crypto.Hash(txw.WKimage), Sk: txw.WKey, Index_Global: txw.TXdata.Index_Global}}
+		ins[0].Ring_Members = append(ins[0].Ring_Members, txw.TXdata.Index_Global)
+		ins[0].Pubs = append(ins[0].Pubs, txw.TXdata.InKey)
+		for j := uint64(0); j < 5; j++ {
+			ins[0].Ring_Members = append(ins[0].Ring_Members, txw.TXdata.Index_Global+j+1)
+			ins[0].Pubs = append(ins[0].Pubs, ringct.CtKey{Destination: crypto.ScalarmultBase(*crypto.RandomScalar()), Mask: crypto.ScalarmultBase(*crypto.RandomScalar())})
+		}
+
+		w.Daemon_Height = aggregate_bulletproofs_height() - 1
+		tx_single := w.Create_TX_v2(ins, outs, 0, 0, nil, true)
+		if tx_single.RctSignature.Get_Sig_Type() != ringct.RCTTypeSimpleBulletproof {
+			t.Fatalf("Aggregated bulletproofs used before hard fork 6")
+		}
+
+		w.Daemon_Height = clsag_height() - 1
+		tx_aggregate := w.Create_TX_v2(ins, outs, 0, 0, nil, true)
+		if tx_aggregate.RctSignature.Get_Sig_Type() != ringct.RCTTypeSimpleBulletproofAggregate {
+			t.Fatalf("Aggregated bulletproofs not used after hard fork 6")
+		}
+
+		w.Daemon_Height = clsag_height()
+		tx_clsag := w.Create_TX_v2(ins, outs, 0, 0, nil, true)
+		if tx_clsag.RctSignature.Get_Sig_Type() != ringct.RCTTypeCLSAG {
+			t.Fatalf("CLSAG not used after hard fork 7")
+		}
+		if len(tx_clsag.RctSignature.BulletSigs) != 1 || len(tx_clsag.RctSignature.MlsagSigs) != 0 || len(tx_clsag.RctSignature.ClsagSigs) != len(ins) {
+			t.Fatalf("CLSAG tx has wrong signatures, outputs %d", output_count)
+		}
+
+		for _, tx := range []*transaction.Transaction{tx_aggregate, tx_clsag} {
+			if !tx.RctSignature.Verify() {
+				t.Fatalf("TX ring signature verification failed, type %d outputs %d", tx.RctSignature.Get_Sig_Type(), output_count)
+			}
+
+			// serdes must be stable
+			var tx2 transaction.Transaction
+			if err := tx2.DeserializeHeader(tx.Serialize()); err != nil {
+				t.Fatalf("Deserialization failed, err %s", err)
+			}
+			if !bytes.Equal(tx.Serialize(), tx2.Serialize()) || tx.GetHash() != tx2.GetHash() {
+				t.Fatalf("Serialization mismatch, type %d outputs %d", tx.RctSignature.Get_Sig_Type(), output_count)
+			}
+
+			tx2.Parse_Extra()
+
+			public_key := tx2.Extra_map[transaction.TX_PUBLIC_KEY].(crypto.Key)
+			for output_index := range outs {
+				tx_out_to_key := tx2.Vout[output_index].Target.(transaction.Txout_to_key)
+				if !receivers[output_index].Is_Output_Ours(public_key, uint64(output_index), tx_out_to_key.Key) {
+					t.Fatalf("Output mismatch index %d", output_index)
+				}
+			}
+		}
+
+		// a clsag is 8 keys for a ring of 6, while mlsag is 13 keys
+		if len(tx_clsag.Serialize()) >= len(tx_single.Serialize()) || len(tx_clsag.Serialize()) != len(tx_aggregate.Serialize())-5*32 {
+			t.Fatalf("CLSAG tx size %d is not smaller, outputs %d", len(tx_clsag.Serialize()), output_count)
+		}
+		if output_count > 1 && len(tx_aggregate.Serialize()) >= len(tx_single.Serialize()) {
+			t.Fatalf("Aggregated tx size %d is not smaller than %d, outputs %d", len(tx_aggregate.Serialize()), len(tx_single.Serialize()), output_count)
+		}
+		t.Logf("outputs %d\ttx size %5d bytes, aggregated %5d bytes, without aggregation %5d bytes", output_count, len(tx_clsag.Serialize()), len(tx_aggregate.Serialize()), len(tx_single.Serialize()))
+	}
+}
+
+// outputs of txs created after the hard forks must be received with correct amounts and masks
+func Test_Receive_TX_Hard_Forks(t *testing.T) {
+	sender_db := filepath.Join(os.TempDir(), "dero_temporary_test_wallet_sender.db")
+	receiver_db := filepath.Join(os.TempDir(), "dero_temporary_test_wallet_receiver.db")
+	os.Remove(sender_db)
+	os.Remove(receiver_db)
+	defer os.Remove(sender_db) // cleanup after test
+	defer os.Remove(receiver_db)
+
+	w, err := Create_Encrypted_Wallet(sender_db, "QWER", *crypto.RandomScalar())
+	if err != nil {
+		t.Fatalf("Cannot create encrypted wallet, err %s", err)
+	}
+	receiver, err := Create_Encrypted_Wallet(receiver_db, "QWER", *crypto.RandomScalar())
+	if err != nil {
+		t.Fatalf("Cannot create encrypted wallet, err %s", err)
+	}
+
+	// use testnet, where the forks are scheduled, so txs of every type can be created
+	defer func(previous config.CHAIN_CONFIG) { globals.Config = previous }(globals.Config)
+	globals.Config = config.Testnet
+
+	var sk ringct.CtKey
+	sk.Destination, sk.Mask = *crypto.RandomScalar(), *crypto.RandomScalar()
+	in_key := ringct.CtKey{Destination: crypto.ScalarmultBase(sk.Destination)}
+	amount_commitment, mask_commitment := ringct.Commitment_From_Amount(4000000000000), crypto.ScalarmultBase(sk.Mask)
+	crypto.AddKeys(&in_key.Mask, &amount_commitment, &mask_commitment)
+	ins := []ringct.Input_info{{Amount: 4000000000000, Key_image: crypto.Hash(crypto.GenerateKeyImage(in_key.Destination, sk.Destination)), Sk: sk, Index_Global: 739}}
+	ins[0].Ring_Members = append(ins[0].Ring_Members, 739)
+	ins[0].Pubs = append(ins[0].Pubs, in_key)
+	for j := uint64(0); j < 5; j++ {
+		ins[0].Ring_Members = append(ins[0].Ring_Members, 740+j)
+		ins[0].Pubs = append(ins[0].Pubs, ringct.CtKey{Destination: crypto.ScalarmultBase(*crypto.RandomScalar()), Mask: crypto.ScalarmultBase(*crypto.RandomScalar())})
+	}
+
+	address := receiver.GetAddress()
+	amounts := []uint64{3000000000000, 1000000000000}
+	var outs []ringct.Output_info
+	for i := range amounts {
+		outs = append(outs, ringct.Output_info{Amount: amounts[i], Public_Spend_Key: address.SpendKey, Public_View_Key: address.ViewKey})
+	}
+
+	index_global := uint64(1000)
+	for _, fork := range []struct {
+		height   uint64
+		sig_type uint8
+	}{
+		{aggregate_bulletproofs_height(), ringct.RCTTypeSimpleBulletproofAggregate},
+		{clsag_height(), ringct.RCTTypeCLSAG},
+	} {
+		w.Daemon_Height = fork.height
+		tx := w.Create_TX_v2(ins, outs, 0, 0, nil, true)
+		if tx.RctSignature.Get_Sig_Type() != fork.sig_type {
+			t.Fatalf("Wrong signature type %d expected %d", tx.RctSignature.Get_Sig_Type(), fork.sig_type)
+		}
+
+		tx.Parse_Extra()
+		for i := range tx.Vout {
+			index_global++
+			output := globals.TX_Output_Data{TXID: tx.GetHash(), Tx_Public_Key: tx.Extra_map[transaction.TX_PUBLIC_KEY].(crypto.Key),
+				InKey:     ringct.CtKey{Destination: crypto.Key(tx.Vout[i].Target.(transaction.Txout_to_key).Key), Mask: tx.RctSignature.OutPk[i].Mask},
+				ECDHTuple: tx.RctSignature.ECdhInfo[i], SigType: uint64(tx.RctSignature.Get_Sig_Type()), Index_within_tx: uint64(i), Index_Global: index_global}
+
+			if amount, result := receiver.Add_Transaction_Record_Funds(&output); !result || amount != amounts[i] {
+				t.Fatalf("Output %d of signature type %d not received, amount %d expected %d", i, fork.sig_type, amount, amounts[i])
+			}
+
+			// mask must open the commitment of the output, else the funds cannot be spent
+			tx_wallet, err := receiver.load_funds_data(index_global, FUNDS_BUCKET)
+			if err != nil {
+				t.Fatalf("Received funds not stored err %s", err)
+			}
+			var commitment crypto.Key
+			amount_commitment, mask_commitment := ringct.Commitment_From_Amount(tx_wallet.WAmount), crypto.ScalarmultBase(tx_wallet.WKey.Mask)
+			crypto.AddKeys(&commitment, &amount_commitment, &mask_commitment)
+			if tx_wallet.WAmount != amounts[i] || commitment != tx.RctSignature.OutPk[i].Mask {
+				t.Fatalf("Output %d of signature type %d has wrong mask", i, fork.sig_type)
+			}
+		}
+	}
+}
+
 // this will test that the keys are placed properly and thus can be decoded by recievers
 // this also forces the ring size from 2 to 10
 func Test_Creation_TX_Size(t *testing.T) {
@@ -63,6 +63,7 @@ type Account struct {
 	Keys           _Keys   `json:"keys"`
 	SeedLanguage   string  `json:"seedlanguage"`
 	FeesMultiplier float32 `json:"feesmultiplier"` // fees multiplier accurate to 2 decimals
+	FeePriority    string  `json:"feepriority,omitempty"` // low, normal or high, fees are estimated by daemon, empty means multiplier is used
 	Mixin          int     `json:"mixin"`          // default mixn to use for txs
 
 	ViewOnly bool `json:"viewonly"` // is this viewonly wallet
@@ -324,7 +325,7 @@ func (w *Wallet) Decode_RingCT_Output(tx_public crypto.Key, output_index uint64,
 	case 1: // ringct MG  // Both ringct outputs can be decoded using the same methods
 		// however, original implementation has different methods, maybe need to evaluate more
 		fallthrough
-	case 2, 4: // ringct sample
+	case 2, 4, 5, 6: // ringct sample, simplebulletproof, aggregated bulletproof, clsag
 
 		amount, mask, result = ringct.Decode_Amount(tuple, *scalar_key, pkkey)
 
@@ -383,7 +384,7 @@ func (w *Wallet) Add_Transaction_Record_Funds(txdata *globals.TX_Output_Data) (a
 		tx_wallet.WAmount = txdata.Amount
 		tx_wallet.WKey.Mask = ringct.Identity // secret mask for miner tx is Identity
 
-	case 1, 2, 4: // ringct full/simple, simplebulletproof
+	case 1, 2, 4, 5, 6: // ringct full/simple, simplebulletproof, aggregated bulletproof, clsag
 		tx_wallet.WAmount, tx_wallet.WKey.Mask, result = w.Decode_RingCT_Output(txdata.Tx_Public_Key, txdata.Index_within_tx, crypto.Key(txdata.InKey.Mask), txdata.ECDHTuple,
 			txdata.SigType)
 
@@ -1124,8 +1125,51 @@ func (w *Wallet) GetFeeMultiplier() float32 {
 	return w.account.FeesMultiplier
 }
 
+// fee priorities, daemon estimates fees required to get mined within these many blocks
+var fee_priority_blocks = map[string]uint64{"low": 24, "normal": 6, "high": 1}
+
+// sets a fee priority, empty priority switches back to fee multiplier
+func (w *Wallet) SetFeePriority(priority string) error {
+	priority = strings.ToLower(priority)
+	if _, ok := fee_priority_blocks[priority]; !ok && priority != "" {
+		return fmt.Errorf("Unknown priority \"%s\", valid priorities are low, normal and high", priority)
+	}
+
+	defer w.Save_Wallet() // save wallet
+	w.Lock()
+	defer w.Unlock()
+	w.account.FeePriority = priority
+	return nil
+}
+
+// gets current fee priority, empty if fee multiplier is in use
+func (w *Wallet) GetFeePriority() string {
+	w.Lock()
+	defer w.Unlock()
+	return w.account.FeePriority
+}
+
+// fees per KB as per fee priority, 0 if no priority is set or daemon could not estimate
+func (w *Wallet) priority_fees_per_kb() uint64 {
+	blocks, ok := fee_priority_blocks[w.account.FeePriority]
+	if !ok {
+		return 0
+	}
+
+	fee_per_kb, err := w.Get_Fee_Estimate(blocks)
+	if err != nil {
+		rlog.Warnf("Fee estimation failed, priority %s err %s", w.account.FeePriority, err)
+		return 0
+	}
+	return fee_per_kb
+}
+
 // get fees multiplied by multiplier
+// multiplier is not used with a fee priority, since estimated fees already cover congestion
 func (w *Wallet) getfees(txfee uint64) uint64 {
+	if _, ok := fee_priority_blocks[w.account.FeePriority]; ok {
+		return txfee
+	}
 	multiplier := w.account.FeesMultiplier
 	if multiplier < 1.0 {
 		multiplier = 2.0
@@ -41,6 +41,13 @@ import "github.com/deroproject/derosuite/blockchain/inputmaturity"
 
 // send amount to specific addresses
 func (w *Wallet) Transfer(addr []address.Address, amount []uint64, unlock_time uint64, payment_id_hex string, fees_per_kb uint64, mixin uint64) (tx *transaction.Transaction, inputs_selected []uint64, inputs_sum uint64, change_amount uint64, err error) {
+	return w.transfer(addr, amount, unlock_time, payment_id_hex, fees_per_kb, mixin, nil, 0)
+}
+
+// inputs are selected randomly, unless forced_inputs are provided
+// replaced_fees are paid on top of normal fees, this is used to replace a pending tx
+// since daemon only accepts a replacement paying fees of the replaced tx plus its own relay fee
+func (w *Wallet) transfer(addr []address.Address, amount []uint64, unlock_time uint64, payment_id_hex string, fees_per_kb uint64, mixin uint64, forced_inputs []uint64, replaced_fees uint64) (tx *transaction.Transaction, inputs_selected []uint64, inputs_sum uint64, change_amount uint64, err error) {
 
     
         var  transfer_details structures.Outgoing_Transfer_Details
@@ -57,6 +64,9 @@ func (w *Wallet) Transfer(addr []address.Address, amount []uint64, unlock_time u
 	// otherwise use whatever user has provided
 	//if w.GetMode()  {
 	fees_per_kb = w.dynamic_fees_per_kb // TODO disabled as protection while lots more testing is going on
+	if priority_fees := w.priority_fees_per_kb(); priority_fees > fees_per_kb { // user has chosen a priority
+		fees_per_kb = priority_fees
+	}
 	rlog.Infof("Fees per KB %d\n", fees_per_kb)
 	//}
 
@@ -130,7 +140,7 @@ func (w *Wallet) Transfer(addr []address.Address, amount []uint64, unlock_time u
 		// we need to make sure that account has sufficient unlocked balance ( to send amount ) + required amount of fees
 		unlocked, _ := w.Get_Balance()
 
-		if total_amount_required >= unlocked {
+		if len(forced_inputs) == 0 && total_amount_required >= unlocked {
 			err = fmt.Errorf("Insufficent unlocked balance")
 			return
 		}
@@ -138,7 +148,17 @@ func (w *Wallet) Transfer(addr []address.Address, amount []uint64, unlock_time u
 		// now we need to select outputs with sufficient balance
 		//total_amount_required += fees
 		// select few outputs randomly
-		inputs_selected, inputs_sum = w.select_outputs_for_transfer(total_amount_required, fees+expected_fee, false)
+		if len(forced_inputs) > 0 { // same inputs as the tx being replaced
+			inputs_selected, inputs_sum = forced_inputs, 0
+			for i := range forced_inputs {
+				if txw, err = w.load_funds_data(forced_inputs[i], FUNDS_BUCKET); err != nil {
+					return
+				}
+				inputs_sum += txw.WAmount
+			}
+		} else {
+			inputs_selected, inputs_sum = w.select_outputs_for_transfer(total_amount_required, fees+expected_fee, false)
+		}
 
 		if inputs_sum < (total_amount_required + fees) {
                         err = fmt.Errorf("Insufficent unlocked balance")
@@ -327,6 +347,7 @@ func (w *Wallet) Transfer(addr []address.Address, amount []uint64, unlock_time u
 		minimum_fee := size_in_kb * fees_per_kb
 
 		needed_fee := w.getfees(minimum_fee) // multiply minimum fees by multiplier
+		needed_fee += replaced_fees
 
 		rlog.Infof("minimum fee %s required fees %s provided fee %s size %d fee/kb %s\n", globals.FormatMoney(minimum_fee), globals.FormatMoney(needed_fee), globals.FormatMoney(fees), size_in_kb, globals.FormatMoney(fees_per_kb))
 
@@ -348,6 +369,9 @@ func (w *Wallet) Transfer(addr []address.Address, amount []uint64, unlock_time u
 	
 	// log enough information to wallet to display it again to users
 	transfer_details.PaymentID =  hex.EncodeToString(payment_id) 
+	transfer_details.Inputs = inputs_selected
+	transfer_details.Unlock_Time = unlock_time
+	transfer_details.Change = change_amount
         
         // get the tx secret key and store it
         txhash := tx.GetHash()
@@ -386,6 +410,54 @@ func (w *Wallet) Transfer(addr []address.Address, amount []uint64, unlock_time u
 	return
 }
 
+// rebuild a pending transaction with higher fees, spending exactly the same inputs
+// daemon will replace the pending tx in mempool, since it pays the old fees plus fees of the new tx
+// extra fees are taken from change, so tx must have sufficient change
+func (w *Wallet) Bump_Fee(txid crypto.Hash) (tx *transaction.Transaction, err error) {
+	details := w.GetTXOutDetails(txid)
+	if details.TXID == "" || len(details.Inputs) == 0 {
+		err = fmt.Errorf("TX %s not found or it cannot be replaced", txid)
+		return
+	}
+
+	for i := range details.Inputs {
+		if w.check_key_exists(BLOCKCHAIN_UNIVERSE, []byte(FUNDS_SPENT), itob(details.Inputs[i])) {
+			err = fmt.Errorf("TX %s already mined", txid)
+			return
+		}
+	}
+
+	destinations := len(details.Amount)
+	if details.Change > 0 { // last output is change, it will be rebuilt
+		destinations--
+	}
+	if destinations < 1 || destinations > len(details.Daddress) {
+		err = fmt.Errorf("TX %s has invalid destinations", txid)
+		return
+	}
+
+	payment_id := details.PaymentID
+	var addr []address.Address
+	for i := 0; i < destinations; i++ {
+		var a *address.Address
+		if a, err = address.NewAddress(details.Daddress[i]); err != nil {
+			return
+		}
+		if a.IsIntegratedAddress() { // payment id will be taken from address itself
+			payment_id = ""
+		}
+		addr = append(addr, *a)
+	}
+
+	if details.Change < details.Fees { // new tx is of same size, so it needs roughly the same fees once again
+		err = fmt.Errorf("Change is not enough to pay higher fees")
+		return
+	}
+
+	tx, _, _, _, err = w.transfer(addr, details.Amount[:destinations], details.Unlock_Time, payment_id, 0, 0, details.Inputs, details.Fees)
+	return
+}
+
 // send all unlocked balance amount to specific address
 func (w *Wallet) Transfer_Everything(addr address.Address, payment_id_hex string, unlock_time uint64, fees_per_kb uint64, mixin uint64) (tx *transaction.Transaction, inputs_selected []uint64, inputs_sum uint64, err error) {
 
@@ -404,6 +476,9 @@ func (w *Wallet) Transfer_Everything(addr address.Address, payment_id_hex string
 	// otherwise use whatever user has provided
 	//if w.GetMode()  {
 	fees_per_kb = w.dynamic_fees_per_kb // TODO disabled as protection while lots more testing is going on
+	if priority_fees := w.priority_fees_per_kb(); priority_fees > fees_per_kb { // user has chosen a priority
+		fees_per_kb = priority_fees
+	}
 	rlog.Infof("Fees per KB %d\n", fees_per_kb)
 	//}
 
@@ -759,6 +834,31 @@ func (w *Wallet) load_funds_data(index uint64, bucket string) (tx_wallet *TX_Wal
 	return // everything was success
 }
 
+// hard forks 6 and 7 activate at different heights on mainnet and testnet
+func aggregate_bulletproofs_height() uint64 {
+	if globals.IsMainnet() {
+		return config.HF6_HEIGHT
+	}
+	return config.TESTNET_HF6_HEIGHT
+}
+
+func clsag_height() uint64 {
+	if globals.IsMainnet() {
+		return config.HF7_HEIGHT
+	}
+	return config.TESTNET_HF7_HEIGHT
+}
+
+// aggregated bulletproofs are accepted by the network only after hard fork 6
+func (w *Wallet) use_aggregate_bulletproofs() bool {
+	return w.Get_Daemon_Height() >= aggregate_bulletproofs_height()
+}
+
+// CLSAG ring signatures are accepted by the network only after hard fork 7
+func (w *Wallet) use_clsag() bool {
+	return w.Get_Daemon_Height() >= clsag_height()
+}
+
 // this will create ringct simple 2 transaction to transfer x amount
 func (w *Wallet) Create_TX_v2(inputs []ringct.Input_info, outputs []ringct.Output_info, fees uint64, unlock_time uint64, payment_id []byte, bulletproof bool) (txout *transaction.Transaction) {
 	var tx transaction.Transaction
@@ -847,7 +947,11 @@ func (w *Wallet) Create_TX_v2(inputs []ringct.Input_info, outputs []ringct.Outpu
 
 	// fmt.Printf("txprefix hash %s\n",tx.GetPrefixHash() )
 
-	if bulletproof {
+	if bulletproof && w.use_clsag() { // smaller ring signatures on top of aggregated bulletproofs
+		tx.RctSignature.Gen_RingCT_CLSAG(tx.GetPrefixHash(), inputs, outputs, fees)
+	} else if bulletproof && w.use_aggregate_bulletproofs() { // single proof for all outputs, much smaller tx
+		tx.RctSignature.Gen_RingCT_Simple_BulletProof_Aggregate(tx.GetPrefixHash(), inputs, outputs, fees)
+	} else if bulletproof {
 		tx.RctSignature.Gen_RingCT_Simple_BulletProof(tx.GetPrefixHash(), inputs, outputs, fees)
 	} else {
 		tx.RctSignature.Gen_RingCT_Simple(tx.GetPrefixHash(), inputs, outputs, fees)